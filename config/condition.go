@@ -0,0 +1,116 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+)
+
+// Condition is an expression that decides whether or not a question should be
+// asked based on the answers to other questions. It supports:
+//
+//	enable_sql              the setting is set to something truthy
+//	!enable_sql             the setting is empty or falsy
+//	enable_sql == y         the setting equals a value
+//	region != us-central1   the setting does not equal a value
+//
+// Terms can be combined with && and ||, where && binds tighter. An empty
+// Condition is always true.
+type Condition string
+
+// Evaluate reports whether the condition holds for the given settings
+func (c Condition) Evaluate(s Settings) bool {
+	if strings.TrimSpace(string(c)) == "" {
+		return true
+	}
+
+	for _, any := range strings.Split(string(c), "||") {
+		all := true
+		for _, term := range strings.Split(any, "&&") {
+			if !evaluateTerm(strings.TrimSpace(term), s) {
+				all = false
+				break
+			}
+		}
+
+		if all {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Settings returns the names of the settings the condition depends on
+func (c Condition) Settings() []string {
+	result := []string{}
+	seen := map[string]bool{}
+
+	for _, any := range strings.Split(string(c), "||") {
+		for _, term := range strings.Split(any, "&&") {
+			name, _, _ := parseTerm(strings.TrimSpace(term))
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
+
+func parseTerm(term string) (name, operator, value string) {
+	for _, op := range []string{"!=", "=="} {
+		if sl := strings.SplitN(term, op, 2); len(sl) == 2 {
+			value = strings.Trim(strings.TrimSpace(sl[1]), "\"'")
+			return strings.TrimSpace(sl[0]), op, value
+		}
+	}
+
+	if strings.HasPrefix(term, "!") {
+		return strings.TrimSpace(term[1:]), "!", ""
+	}
+
+	return term, "", ""
+}
+
+func evaluateTerm(term string, s Settings) bool {
+	name, operator, value := parseTerm(term)
+
+	current := ""
+	if set := s.Find(name); set != nil {
+		current = set.Value
+	}
+
+	switch operator {
+	case "==":
+		return current == value
+	case "!=":
+		return current != value
+	case "!":
+		return !truthy(current)
+	}
+
+	return truthy(current)
+}
+
+func truthy(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "n", "no", "false", "0":
+		return false
+	}
+	return true
+}
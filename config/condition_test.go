@@ -0,0 +1,80 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConditionEvaluate(t *testing.T) {
+	settings := Settings{
+		Setting{Name: "enable_sql", Value: "y"},
+		Setting{Name: "enable_cache", Value: "n"},
+		Setting{Name: "region", Value: "us-central1"},
+		Setting{Name: "tier", Value: "premium"},
+	}
+
+	tests := map[string]struct {
+		in   Condition
+		want bool
+	}{
+		"empty":             {in: "", want: true},
+		"truthy":            {in: "enable_sql", want: true},
+		"falsy":             {in: "enable_cache", want: false},
+		"missing":           {in: "enable_pubsub", want: false},
+		"negated":           {in: "!enable_cache", want: true},
+		"negated missing":   {in: "!enable_pubsub", want: true},
+		"equals":            {in: "region == us-central1", want: true},
+		"equals quoted":     {in: `region == "us-central1"`, want: true},
+		"equals mismatch":   {in: "region == us-east1", want: false},
+		"not equals":        {in: "region != us-east1", want: true},
+		"case insensitive":  {in: "ENABLE_SQL == y", want: true},
+		"and":               {in: "enable_sql && tier == premium", want: true},
+		"and false":         {in: "enable_sql && enable_cache", want: false},
+		"or":                {in: "enable_cache || tier == premium", want: true},
+		"or false":          {in: "enable_cache || tier == basic", want: false},
+		"and binds tighter": {in: "enable_cache && tier == basic || enable_sql", want: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tc.in.Evaluate(settings)
+			if tc.want != got {
+				t.Fatalf("want '%t' got '%t'", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConditionSettings(t *testing.T) {
+	tests := map[string]struct {
+		in   Condition
+		want []string
+	}{
+		"empty":    {in: "", want: []string{}},
+		"single":   {in: "enable_sql", want: []string{"enable_sql"}},
+		"compound": {in: "!enable_sql || region != us-east1 && enable_sql", want: []string{"enable_sql", "region"}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tc.in.Settings()
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("want '%v' got '%v'", tc.want, got)
+			}
+		})
+	}
+}
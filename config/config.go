@@ -8,7 +8,9 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"gopkg.in/src-d/go-git.v4"
@@ -19,6 +21,7 @@ import (
 // be in a json file. The idea is minimal programming has to be done to setup
 // a DeployStack and export out a tfvars file for terraform part of solution.
 type Config struct {
+	SchemaVersion        int               `json:"schema_version,omitempty" yaml:"schema_version,omitempty"`
 	Title                string            `json:"title" yaml:"title"`
 	Name                 string            `json:"name" yaml:"name"`
 	Description          string            `json:"description" yaml:"description"`
@@ -27,12 +30,16 @@ type Config struct {
 	ProjectNumber        bool              `json:"collect_project_number" yaml:"collect_project_number"`
 	BillingAccount       bool              `json:"collect_billing_account" yaml:"collect_billing_account"`
 	Domain               bool              `json:"register_domain" yaml:"register_domain"`
+	DomainWhen           Condition         `json:"domain_when,omitempty" yaml:"domain_when,omitempty"`
 	Region               bool              `json:"collect_region" yaml:"collect_region"`
 	RegionType           string            `json:"region_type" yaml:"region_type"`
 	RegionDefault        string            `json:"region_default" yaml:"region_default"`
+	RegionWhen           Condition         `json:"region_when,omitempty" yaml:"region_when,omitempty"`
 	Zone                 bool              `json:"collect_zone" yaml:"collect_zone"`
+	ZoneWhen             Condition         `json:"zone_when,omitempty" yaml:"zone_when,omitempty"`
 	HardSet              map[string]string `json:"hard_settings" yaml:"hard_settings"`
 	CustomSettings       Customs           `json:"custom_settings" yaml:"custom_settings"`
+	Validations          map[string]Rule   `json:"validations,omitempty" yaml:"validations,omitempty"`
 	AuthorSettings       Settings          `json:"author_settings" yaml:"author_settings"`
 	ConfigureGCEInstance bool              `json:"configure_gce_instance" yaml:"configure_gce_instance"`
 	DocumentationLink    string            `json:"documentation_link" yaml:"documentation_link"`
@@ -41,6 +48,10 @@ type Config struct {
 	PathScripts          string            `json:"path_scripts" yaml:"path_scripts"`
 	Projects             Projects          `json:"projects" yaml:"projects"`
 	Products             []Product         `json:"products" yaml:"products"`
+	Services             []string          `json:"services,omitempty" yaml:"services,omitempty"`
+	Profiles             Profiles          `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+	ProfileDefault       string            `json:"profile_default,omitempty" yaml:"profile_default,omitempty"`
+	TFvarsFormat         string            `json:"tfvars_format,omitempty" yaml:"tfvars_format,omitempty"`
 	WD                   string            `json:"-" yaml:"-"`
 }
 
@@ -66,39 +77,88 @@ func (c *Config) Setwd(wd string) {
 }
 
 // Copy produces a copy of a config file for manipulating it without changing
-// the original
+// the original. Every slice and map is copied too, so nothing in the copy is
+// shared with the original.
 func (c Config) Copy() Config {
-	out := Config{}
-	out.WD = c.WD
-	out.Name = c.Name
-	out.Title = c.Title
-	out.Project = c.Project
-	out.ProjectNumber = c.ProjectNumber
-	out.Region = c.Region
-	out.RegionType = c.RegionType
-	out.RegionDefault = c.RegionDefault
-	out.Zone = c.Zone
-	out.Description = c.Description
-	out.Duration = c.Duration
-	out.DocumentationLink = c.DocumentationLink
-	out.Domain = c.Domain
-	out.ConfigureGCEInstance = c.ConfigureGCEInstance
-	out.PathTerraform = c.PathTerraform
-	out.PathMessages = c.PathMessages
-	out.PathScripts = c.PathScripts
+	out := c
 
+	out.HardSet = copyStringMap(c.HardSet)
+
+	out.AuthorSettings = nil
 	for _, v := range c.AuthorSettings {
-		out.AuthorSettings.AddComplete(v)
+		out.AuthorSettings = append(out.AuthorSettings, v.copy())
 	}
 
+	out.CustomSettings = nil
 	for _, v := range c.CustomSettings {
-		out.CustomSettings = append(out.CustomSettings, v)
+		out.CustomSettings = append(out.CustomSettings, v.copy())
+	}
+
+	if c.Validations != nil {
+		out.Validations = map[string]Rule{}
+		for k, v := range c.Validations {
+			out.Validations[k] = v.copy()
+		}
+	}
+
+	out.Projects.Items = nil
+	if c.Projects.Items != nil {
+		out.Projects.Items = append([]Project{}, c.Projects.Items...)
+	}
+
+	out.Products = nil
+	if c.Products != nil {
+		out.Products = append([]Product{}, c.Products...)
+	}
+
+	out.Services = copyStrings(c.Services)
+
+	out.Profiles = nil
+	for _, v := range c.Profiles {
+		out.Profiles = append(out.Profiles, v.copy())
+	}
+
+	return out
+}
+
+var serviceName = regexp.MustCompile(`^[a-z][a-z0-9-]*(\.[a-z0-9-]+)*\.googleapis\.com$`)
+
+// lintServices reports extra services that can't be service names, which
+// would otherwise only fail when the stack tries to enable them
+func (c Config) lintServices() Issues {
+	issues := Issues{}
+
+	seen := map[string]bool{}
+	for i, v := range c.Services {
+		path := fmt.Sprintf("services[%d]", i)
+
+		if !serviceName.MatchString(v) {
+			issues = append(issues, Issue{Path: path, Key: v, Message: fmt.Sprintf("%s: '%s' is not a service name like compute.googleapis.com", path, v)})
+		}
+		if seen[v] {
+			issues = append(issues, Issue{Path: path, Key: v, Message: fmt.Sprintf("%s: service '%s' is listed more than once", path, v)})
+		}
+		seen[v] = true
 	}
 
-	for _, v := range c.Products {
-		out.Products = append(out.Products, v)
+	return issues
+}
+
+func copyStrings(in []string) []string {
+	if in == nil {
+		return nil
 	}
+	return append([]string{}, in...)
+}
 
+func copyStringMap(in map[string]string) map[string]string {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
 	return out
 }
 
@@ -226,8 +286,30 @@ type Setting struct {
 	Type  string            `json:"type"  yaml:"type"`
 	List  []string          `json:"list"  yaml:"list"`
 	Map   map[string]string `json:"map"  yaml:"map"`
+	// Secret settings are kept out of terraform.tfvars and redacted when
+	// displayed.
+	Secret bool `json:"secret,omitempty"  yaml:"secret,omitempty"`
+	// Source records where the value came from, one of the Source constants.
+	Source string `json:"source,omitempty"  yaml:"source,omitempty"`
+}
+
+func (s Setting) copy() Setting {
+	s.List = copyStrings(s.List)
+	s.Map = copyStringMap(s.Map)
+	return s
 }
 
+// Sources a setting's value can come from
+const (
+	SourceAuthor   = "author"
+	SourceDefault  = "default"
+	SourceUser     = "user"
+	SourceAnswers  = "answers"
+	SourceEnv      = "env"
+	SourcePrevious = "previous"
+	SourceComputed = "computed"
+)
+
 // TFVars emits the name value combination here in away that terraform excepts
 // in a tfvars file
 func (s *Setting) TFVars() string {
@@ -242,41 +324,101 @@ func (s Setting) TFvarsName() string {
 
 // TFvarsValue formats the value for the tfvars format
 func (s Setting) TFvarsValue() string {
-	result := ""
-	// If we used the workaround for lists in strings, convert it to a list
-	// under the covers
-	if s.Value != "" && s.Value[0:1] == "[" {
-		replacer := strings.NewReplacer("[", "", "]", "")
-		s.List = strings.Split(replacer.Replace(s.Value), ",")
-		s.Type = "list"
-		s.Value = ""
+	return hclValue(s.Native())
+}
+
+// BaseType reduces a Terraform type constraint like list(string) or
+// map(number) to the kinds of values a Setting can hold: string, number, bool,
+// list or map.
+func BaseType(t string) string {
+	t = strings.ToLower(strings.TrimSpace(t))
+	if i := strings.Index(t, "("); i > -1 {
+		t = t[:i]
 	}
 
+	switch t {
+	case "number", "bool", "list", "map":
+		return t
+	case "set", "tuple":
+		return "list"
+	case "object":
+		return "map"
+	}
+
+	return "string"
+}
+
+// NewSettingTyped turns an answer from a user into a Setting of the given
+// Terraform type. Lists are comma separated and maps are comma separated
+// key=value pairs, either can be wrapped in brackets or braces.
+func NewSettingTyped(name, kind, value string) (Setting, error) {
+	s := Setting{Name: strings.ToLower(name), Value: value, Type: BaseType(kind)}
+
 	switch s.Type {
-	case "string", "":
-		result = fmt.Sprintf("\"%s\"", s.Value)
+	case "number":
+		if _, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+			return s, fmt.Errorf("'%s' is not a number", value)
+		}
+		s.Value = strings.TrimSpace(value)
+	case "bool":
+		b, err := parseBool(value)
+		if err != nil {
+			return s, err
+		}
+		s.Value = strconv.FormatBool(b)
 	case "list":
-		tmp := []string{}
-		for _, v := range s.List {
-			tmp = append(tmp, fmt.Sprintf("\"%s\"", v))
+		// JSON that is more than a list of strings, like nested lists or
+		// numbers, is kept as the value and written out as it was given.
+		if v, ok := decodeJSON(value); ok {
+			if l, ok := v.([]interface{}); ok {
+				if strs, ok := stringSlice(l); ok {
+					s.List = strs
+				}
+				break
+			}
 		}
-		str := strings.Join(tmp, ",")
-
-		result = fmt.Sprintf("[%s]", str)
+		s.List = splitList(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(value), "["), "]"))
 	case "map":
-		tmp := []string{}
-
-		for i, v := range s.Map {
-			tmp = append(tmp, fmt.Sprintf("%s=\"%s\"", i, v))
+		if v, ok := decodeJSON(value); ok {
+			if m, ok := v.(map[string]interface{}); ok {
+				if strs, ok := stringMap(m); ok {
+					s.Map = strs
+				}
+				break
+			}
+		}
+		s.Map = map[string]string{}
+		for _, v := range splitList(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(value), "{"), "}")) {
+			kv := strings.SplitN(v, "=", 2)
+			if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+				return s, fmt.Errorf("'%s' is not a key=value pair", v)
+			}
+			s.Map[strings.TrimSpace(kv[0])] = strings.Trim(strings.TrimSpace(kv[1]), "\"")
 		}
+	}
+
+	return s, nil
+}
 
-		sort.Strings(tmp)
-		str := strings.Join(tmp, ",")
-		result = fmt.Sprintf("{%s}", str)
-	default:
-		result = s.Value
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "y", "yes", "true", "1":
+		return true, nil
+	case "n", "no", "false", "0":
+		return false, nil
 	}
+	return false, fmt.Errorf("'%s' is not true or false", value)
+}
 
+func splitList(value string) []string {
+	result := []string{}
+	for _, v := range strings.Split(value, ",") {
+		v = strings.Trim(strings.TrimSpace(v), "\"")
+		if v == "" {
+			continue
+		}
+		result = append(result, v)
+	}
 	return result
 }
 
@@ -298,6 +440,12 @@ func (s *Settings) AddComplete(set Setting) {
 
 // Add either creates a new setting or updates the existing one
 func (s *Settings) Add(key, value string) {
+	s.AddSource(key, value, "")
+}
+
+// AddSource adds a setting the way Add does, recording where the value came
+// from.
+func (s *Settings) AddSource(key, value, source string) {
 	k := strings.ToLower(key)
 
 	set := s.Find(key)
@@ -305,11 +453,12 @@ func (s *Settings) Add(key, value string) {
 		set.Name = key
 		set.Value = value
 		set.Type = "string"
+		set.Source = source
 		s.Replace(*set)
 		return
 	}
 
-	set = &Setting{Name: k, Value: value, Type: "string"}
+	set = &Setting{Name: k, Value: value, Type: "string", Source: source}
 	(*s) = append((*s), *set)
 	return
 }
@@ -361,13 +510,66 @@ func (s *Settings) Find(key string) *Setting {
 // We will collect these settings from the user before continuing.
 type Custom struct {
 	Setting        `json:"-"  yaml:"-"`
-	Name           string   `json:"name"  yaml:"name"`
-	Description    string   `json:"description"  yaml:"description"`
-	Default        string   `json:"default"  yaml:"default"`
-	Options        []string `json:"options"  yaml:"options"`
-	PrependProject bool     `json:"prepend_project"  yaml:"prepend_project"`
-	Validation     string   `json:"validation,omitempty"  yaml:"validation,omitempty"`
-	Project        string   `json:"-"  yaml:"-"`
+	Name           string    `json:"name"  yaml:"name"`
+	Description    string    `json:"description"  yaml:"description"`
+	Default        string    `json:"default"  yaml:"default"`
+	Type           string    `json:"type,omitempty"  yaml:"type,omitempty"`
+	Options        []string  `json:"options"  yaml:"options"`
+	PrependProject bool      `json:"prepend_project"  yaml:"prepend_project"`
+	Validation     string    `json:"validation,omitempty"  yaml:"validation,omitempty"`
+	Rule           *Rule     `json:"rule,omitempty"  yaml:"rule,omitempty"`
+	Secret         bool      `json:"secret,omitempty"  yaml:"secret,omitempty"`
+	SecretStore    string    `json:"secret_store,omitempty"  yaml:"secret_store,omitempty"`
+	When           Condition `json:"when,omitempty"  yaml:"when,omitempty"`
+	// Picker asks for the setting with one of the built in pickers instead of
+	// a text box, either PickerRegion or PickerZone.
+	Picker string `json:"picker,omitempty"  yaml:"picker,omitempty"`
+	// Translations are the description in other locales, keyed by locale,
+	// like es or pt-BR.
+	Translations map[string]string `json:"translations,omitempty"  yaml:"translations,omitempty"`
+	Project      string            `json:"-"  yaml:"-"`
+}
+
+func (c Custom) copy() Custom {
+	c.Setting = c.Setting.copy()
+	c.Options = copyStrings(c.Options)
+	c.Translations = copyStringMap(c.Translations)
+	if c.Rule != nil {
+		r := c.Rule.copy()
+		c.Rule = &r
+	}
+	return c
+}
+
+// Pickers a custom setting can be asked with
+const (
+	PickerRegion = "region"
+	PickerZone   = "zone"
+)
+
+// Pickers returns the names of every picker a custom setting can use
+func Pickers() []string {
+	return []string{PickerRegion, PickerZone}
+}
+
+// RegionFor returns the setting that holds the region a zone picker lists the
+// zones of. That is the region picker named like the zone one, like
+// secondary_region for secondary_zone, and region otherwise.
+func (c Config) RegionFor(zone string) string {
+	candidate := strings.Replace(zone, "zone", "region", 1)
+	if cu := c.CustomSettings.Get(candidate); cu.Name != "" && cu.Picker == PickerRegion {
+		return candidate
+	}
+	return "region"
+}
+
+// Store returns where a secret custom setting should be delivered to
+// Terraform, defaulting to SecretStoreEnv.
+func (c Custom) Store() string {
+	if c.SecretStore == "" {
+		return SecretStoreEnv
+	}
+	return c.SecretStore
 }
 
 // Customs are a slice of Custom variables.
@@ -422,20 +624,26 @@ func NewReport(file string) (Report, error) {
 // FindConfigReports walks through a directory and finds all of the configs in
 // the folder
 func FindConfigReports(dir string) ([]Report, error) {
-
 	var result []Report
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 
-		if info.Name() == "deploystack.json" || info.Name() == "deploystack.yaml" {
-			cr, err := NewReport(path)
-			if err != nil {
-				return err
-			}
+	files, err := findConfigFiles(dir)
+	if err != nil {
+		return result, err
+	}
 
-			result = append(result, cr)
+	for _, v := range files {
+		cr, err := NewReport(v)
+		if err != nil {
+			return result, err
 		}
-		return nil
-	})
-	return result, err
 
+		result = append(result, cr)
+	}
+
+	return result, nil
+}
+
+func findConfigFiles(dir string) ([]string, error) {
+	d, err := Discover(dir)
+	return d.Configs, err
 }
@@ -32,6 +32,7 @@ func TestConfig(t *testing.T) {
 		"Original": {
 			pwd: "original",
 			want: Config{
+				SchemaVersion:     CurrentSchemaVersion,
 				Title:             "Three Tier App (TODO)",
 				Duration:          9,
 				DocumentationLink: "https://cloud.google.com/shell/docs/cloud-shell-tutorials/deploystack/three-tier-app",
@@ -52,6 +53,7 @@ func TestConfig(t *testing.T) {
 		"YAML": {
 			pwd: "preferredyaml",
 			want: Config{
+				SchemaVersion:     CurrentSchemaVersion,
 				Title:             "Three Tier App (TODO)",
 				Duration:          9,
 				DocumentationLink: "https://cloud.google.com/shell/docs/cloud-shell-tutorials/deploystack/three-tier-app",
@@ -84,6 +86,7 @@ func TestConfig(t *testing.T) {
 		"withAuthorSettings": {
 			pwd: "withauthorsettings",
 			want: Config{
+				SchemaVersion:  CurrentSchemaVersion,
 				Title:          "Three Tier App (TODO)",
 				Duration:       9,
 				AuthorSettings: Settings{Setting{Name: "basename", Value: "three-tier-app", Type: "string"}},
@@ -130,6 +133,7 @@ func TestConfigNoMessageFolder(t *testing.T) {
 		"yaml": {
 			pwd: "nomessages",
 			want: Config{
+				SchemaVersion: CurrentSchemaVersion,
 				Title:         "Load Balanced VMs",
 				Name:          "terraform-google-load-balanced-vms",
 				Project:       true,
@@ -746,6 +750,26 @@ func TestConfigCopy(t *testing.T) {
 				},
 			},
 		},
+		"previouslydropped": {
+			in: Config{
+				BillingAccount: true,
+				HardSet:        map[string]string{"zone": "us-central1-a"},
+				Projects: Projects{
+					Items:           []Project{{Name: "project_id_2", UserPrompt: "Pick another"}},
+					AllowDuplicates: true,
+				},
+				Services: []string{"sqladmin.googleapis.com"},
+			},
+			want: Config{
+				BillingAccount: true,
+				HardSet:        map[string]string{"zone": "us-central1-a"},
+				Projects: Projects{
+					Items:           []Project{{Name: "project_id_2", UserPrompt: "Pick another"}},
+					AllowDuplicates: true,
+				},
+				Services: []string{"sqladmin.googleapis.com"},
+			},
+		},
 	}
 
 	for name, tc := range tests {
@@ -769,6 +793,40 @@ func TestConfigCopy(t *testing.T) {
 	}
 }
 
+func TestConfigCopyIndependent(t *testing.T) {
+	min := 1.0
+	in := Config{
+		HardSet:        map[string]string{"zone": "us-central1-a"},
+		AuthorSettings: Settings{{Name: "tags", Type: "list", List: []string{"a"}}},
+		CustomSettings: Customs{{Name: "nodes", Options: []string{"1"}, Rule: &Rule{Min: &min}}},
+		Validations:    map[string]Rule{"name": {Enum: []string{"a"}}},
+		Projects:       Projects{Items: []Project{{Name: "project_id_2"}}},
+		Profiles:       Profiles{{Name: "small", Defaults: map[string]string{"nodes": "1"}}},
+	}
+
+	got := in.Copy()
+	got.HardSet["zone"] = "changed"
+	got.AuthorSettings[0].List[0] = "changed"
+	got.CustomSettings[0].Options[0] = "changed"
+	*got.CustomSettings[0].Rule.Min = 2
+	got.Validations["name"].Enum[0] = "changed"
+	got.Projects.Items[0].Name = "changed"
+	got.Profiles[0].Defaults["nodes"] = "changed"
+
+	want := Config{
+		HardSet:        map[string]string{"zone": "us-central1-a"},
+		AuthorSettings: Settings{{Name: "tags", Type: "list", List: []string{"a"}}},
+		CustomSettings: Customs{{Name: "nodes", Options: []string{"1"}, Rule: &Rule{Min: &min}}},
+		Validations:    map[string]Rule{"name": {Enum: []string{"a"}}},
+		Projects:       Projects{Items: []Project{{Name: "project_id_2"}}},
+		Profiles:       Profiles{{Name: "small", Defaults: map[string]string{"nodes": "1"}}},
+	}
+
+	if min != 1.0 || !reflect.DeepEqual(want, in) {
+		t.Fatalf("changing the copy changed the original: %+v", in)
+	}
+}
+
 func TestConfigMarshall(t *testing.T) {
 	tests := map[string]struct {
 		in     Config
@@ -964,3 +1022,182 @@ products:
 		})
 	}
 }
+
+func TestBaseType(t *testing.T) {
+	tests := map[string]struct {
+		in   string
+		want string
+	}{
+		"empty":        {in: "", want: "string"},
+		"string":       {in: "string", want: "string"},
+		"number":       {in: "number", want: "number"},
+		"bool":         {in: "bool", want: "bool"},
+		"list":         {in: "list", want: "list"},
+		"list(string)": {in: "list(string)", want: "list"},
+		"set(string)":  {in: "set(string)", want: "list"},
+		"map(string)":  {in: "map(string)", want: "map"},
+		"object":       {in: "object({name=string})", want: "map"},
+		"any":          {in: "any", want: "string"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := BaseType(tc.in)
+			if tc.want != got {
+				t.Fatalf("want '%s' got '%s'", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestNewSettingTyped(t *testing.T) {
+	tests := map[string]struct {
+		kind   string
+		value  string
+		want   Setting
+		tfvars string
+		err    error
+	}{
+		"string": {
+			kind:   "string",
+			value:  "hello",
+			want:   Setting{Name: "test", Value: "hello", Type: "string"},
+			tfvars: `"hello"`,
+		},
+		"number": {
+			kind:   "number",
+			value:  " 3.5",
+			want:   Setting{Name: "test", Value: "3.5", Type: "number"},
+			tfvars: `3.5`,
+		},
+		"number bad": {
+			kind:  "number",
+			value: "three",
+			err:   fmt.Errorf("'three' is not a number"),
+		},
+		"bool": {
+			kind:   "bool",
+			value:  "yes",
+			want:   Setting{Name: "test", Value: "true", Type: "bool"},
+			tfvars: `true`,
+		},
+		"bool bad": {
+			kind:  "bool",
+			value: "maybe",
+			err:   fmt.Errorf("'maybe' is not true or false"),
+		},
+		"list": {
+			kind:   "list(string)",
+			value:  "a, b,c",
+			want:   Setting{Name: "test", Value: "a, b,c", Type: "list", List: []string{"a", "b", "c"}},
+			tfvars: `["a","b","c"]`,
+		},
+		"list brackets": {
+			kind:   "list",
+			value:  `["a","b"]`,
+			want:   Setting{Name: "test", Value: `["a","b"]`, Type: "list", List: []string{"a", "b"}},
+			tfvars: `["a","b"]`,
+		},
+		"list nested": {
+			kind:   "list(list(string))",
+			value:  `[["a","b"],["c"]]`,
+			want:   Setting{Name: "test", Value: `[["a","b"],["c"]]`, Type: "list"},
+			tfvars: `[["a","b"],["c"]]`,
+		},
+		"map json": {
+			kind:   "map",
+			value:  `{"env": "prod", "note": "say \"hi\""}`,
+			want:   Setting{Name: "test", Value: `{"env": "prod", "note": "say \"hi\""}`, Type: "map", Map: map[string]string{"env": "prod", "note": `say "hi"`}},
+			tfvars: `{env="prod",note="say \"hi\""}`,
+		},
+		"map": {
+			kind:   "map(string)",
+			value:  "env=prod, team = web",
+			want:   Setting{Name: "test", Value: "env=prod, team = web", Type: "map", Map: map[string]string{"env": "prod", "team": "web"}},
+			tfvars: `{env="prod",team="web"}`,
+		},
+		"map bad": {
+			kind:  "map",
+			value: "env=prod,team",
+			err:   fmt.Errorf("'team' is not a key=value pair"),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := NewSettingTyped("TEST", tc.kind, tc.value)
+
+			if tc.err != nil {
+				if err == nil || tc.err.Error() != err.Error() {
+					t.Fatalf("error want '%v' got '%v'", tc.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("want '%+v' got '%+v'", tc.want, got)
+			}
+
+			if tc.tfvars != got.TFvarsValue() {
+				t.Fatalf("tfvars want '%s' got '%s'", tc.tfvars, got.TFvarsValue())
+			}
+		})
+	}
+}
+
+func TestConfigRegionFor(t *testing.T) {
+	c := Config{
+		CustomSettings: Customs{
+			{Name: "secondary_region", Picker: PickerRegion},
+			{Name: "backup_region"},
+		},
+	}
+
+	tests := map[string]struct {
+		in   string
+		want string
+	}{
+		"matching":   {in: "secondary_zone", want: "secondary_region"},
+		"notapicker": {in: "backup_zone", want: "region"},
+		"nomatch":    {in: "primary_zone", want: "region"},
+		"plain":      {in: "zone", want: "region"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := c.RegionFor(tc.in)
+			if tc.want != got {
+				t.Fatalf("expected: %s, got: %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigLintServices(t *testing.T) {
+	c := Config{
+		Services: []string{
+			"sqladmin.googleapis.com",
+			"sqladmin",
+			"redis.googleapis.com",
+			"sqladmin.googleapis.com",
+		},
+	}
+
+	want := []string{
+		"services[1]: 'sqladmin' is not a service name like compute.googleapis.com",
+		"services[3]: service 'sqladmin.googleapis.com' is listed more than once",
+	}
+
+	got := []string{}
+	for _, v := range c.lintServices() {
+		got = append(got, v.String())
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want \n%v\ngot \n%v", want, got)
+	}
+}
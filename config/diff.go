@@ -0,0 +1,427 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Kinds of Change
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// Change is a single difference between two configs. Section is the config
+// key of the list or map the item belongs to, like custom_settings, and is
+// empty for top level fields like collect_region.
+type Change struct {
+	Kind    string
+	Section string
+	Name    string
+	From    string
+	To      string
+}
+
+// Key is the section and name of the thing that changed
+func (c Change) Key() string {
+	if c.Section == "" {
+		return c.Name
+	}
+	return fmt.Sprintf("%s.%s", c.Section, c.Name)
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %s", c.Key(), c.To)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %s", c.Key(), c.From)
+	}
+	return fmt.Sprintf("~ %s: %s -> %s", c.Key(), c.From, c.To)
+}
+
+// MergeRule decides which config wins when both set the same thing to
+// different values
+type MergeRule int
+
+const (
+	// KeepExisting keeps the value from the config being merged into
+	KeepExisting MergeRule = iota
+	// PreferIncoming takes the value from the config being merged in
+	PreferIncoming
+)
+
+// Diff lists what would have to change to turn c into other. Top level
+// fields come first in the order they are declared, followed by the hard
+// settings, author settings, custom settings, validations, projects,
+// products, services and profiles, matched up by name. Whether projects
+// allow duplicates is reported ahead of the projects themselves.
+func (c Config) Diff(other Config) []Change {
+	result := diffFields(c, other)
+
+	for _, v := range c.sections() {
+		if v.name == "projects" && c.Projects.AllowDuplicates != other.Projects.AllowDuplicates {
+			result = append(result, Change{
+				Kind:    ChangeChanged,
+				Section: "projects",
+				Name:    "allow_duplicates",
+				From:    fmt.Sprintf("%t", c.Projects.AllowDuplicates),
+				To:      fmt.Sprintf("%t", other.Projects.AllowDuplicates),
+			})
+		}
+		result = append(result, diffNamed(v.name, v.items, other.section(v.name))...)
+	}
+
+	return result
+}
+
+// Merge combines in into c. Fields and items that only one of them sets are
+// kept, and true wins for booleans. When both set the same field or item to
+// different values, rule decides which wins, and the difference is returned
+// as a conflict so callers can report it.
+func (c Config) Merge(in Config, rule MergeRule) (Config, []Change) {
+	out := c.Copy()
+	in = in.Copy()
+	conflicts := []Change{}
+
+	bv := reflect.ValueOf(&out).Elem()
+	iv := reflect.ValueOf(in)
+	for _, f := range scalarFields() {
+		bf, inf := bv.Field(f.index), iv.Field(f.index)
+		switch {
+		case inf.IsZero() || bf.Interface() == inf.Interface():
+		case bf.IsZero():
+			bf.Set(inf)
+		default:
+			conflicts = append(conflicts, Change{
+				Kind: ChangeChanged,
+				Name: f.name,
+				From: fmt.Sprintf("%v", bf.Interface()),
+				To:   fmt.Sprintf("%v", inf.Interface()),
+			})
+			if rule == PreferIncoming {
+				bf.Set(inf)
+			}
+		}
+	}
+
+	picks, cs := mergeNamed("hard_settings", named(c.HardSet), named(in.HardSet), rule)
+	conflicts = append(conflicts, cs...)
+	out.HardSet = nil
+	for _, p := range picks {
+		if out.HardSet == nil {
+			out.HardSet = map[string]string{}
+		}
+		if p.incoming {
+			out.HardSet[p.name] = in.HardSet[p.name]
+			continue
+		}
+		out.HardSet[p.name] = c.HardSet[p.name]
+	}
+
+	picks, cs = mergeNamed("author_settings", c.section("author_settings"), in.section("author_settings"), rule)
+	conflicts = append(conflicts, cs...)
+	out.AuthorSettings = nil
+	for _, p := range picks {
+		src := c.AuthorSettings
+		if p.incoming {
+			src = in.AuthorSettings
+		}
+		out.AuthorSettings = append(out.AuthorSettings, src[p.index].copy())
+	}
+
+	picks, cs = mergeNamed("custom_settings", c.section("custom_settings"), in.section("custom_settings"), rule)
+	conflicts = append(conflicts, cs...)
+	out.CustomSettings = nil
+	for _, p := range picks {
+		src := c.CustomSettings
+		if p.incoming {
+			src = in.CustomSettings
+		}
+		out.CustomSettings = append(out.CustomSettings, src[p.index].copy())
+	}
+
+	picks, cs = mergeNamed("validations", c.section("validations"), in.section("validations"), rule)
+	conflicts = append(conflicts, cs...)
+	out.Validations = nil
+	for _, p := range picks {
+		if out.Validations == nil {
+			out.Validations = map[string]Rule{}
+		}
+		if p.incoming {
+			out.Validations[p.name] = in.Validations[p.name].copy()
+			continue
+		}
+		out.Validations[p.name] = c.Validations[p.name].copy()
+	}
+
+	picks, cs = mergeNamed("projects", c.section("projects"), in.section("projects"), rule)
+	conflicts = append(conflicts, cs...)
+	out.Projects.Items = nil
+	for _, p := range picks {
+		src := c.Projects.Items
+		if p.incoming {
+			src = in.Projects.Items
+		}
+		out.Projects.Items = append(out.Projects.Items, src[p.index])
+	}
+	out.Projects.AllowDuplicates = c.Projects.AllowDuplicates || in.Projects.AllowDuplicates
+
+	picks, cs = mergeNamed("products", c.section("products"), in.section("products"), rule)
+	conflicts = append(conflicts, cs...)
+	out.Products = nil
+	for _, p := range picks {
+		src := c.Products
+		if p.incoming {
+			src = in.Products
+		}
+		out.Products = append(out.Products, src[p.index])
+	}
+
+	picks, cs = mergeNamed("services", c.section("services"), in.section("services"), rule)
+	conflicts = append(conflicts, cs...)
+	out.Services = nil
+	for _, p := range picks {
+		out.Services = append(out.Services, p.name)
+	}
+
+	picks, cs = mergeNamed("profiles", c.section("profiles"), in.section("profiles"), rule)
+	conflicts = append(conflicts, cs...)
+	out.Profiles = nil
+	for _, p := range picks {
+		src := c.Profiles
+		if p.incoming {
+			src = in.Profiles
+		}
+		out.Profiles = append(out.Profiles, src[p.index].copy())
+	}
+
+	return out, conflicts
+}
+
+// scalarField is a top level field of Config that holds a single value
+type scalarField struct {
+	index int
+	name  string
+}
+
+// scalarFields are the bool, string and int fields of Config, named by their
+// yaml key
+func scalarFields() []scalarField {
+	result := []scalarField{}
+
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		switch f.Type.Kind() {
+		case reflect.Bool, reflect.String, reflect.Int:
+			result = append(result, scalarField{index: i, name: name})
+		}
+	}
+
+	return result
+}
+
+func diffFields(from, to Config) []Change {
+	result := []Change{}
+
+	fv, tv := reflect.ValueOf(from), reflect.ValueOf(to)
+	for _, f := range scalarFields() {
+		a, b := fv.Field(f.index).Interface(), tv.Field(f.index).Interface()
+		if a == b {
+			continue
+		}
+
+		result = append(result, Change{
+			Kind: ChangeChanged,
+			Name: f.name,
+			From: fmt.Sprintf("%v", a),
+			To:   fmt.Sprintf("%v", b),
+		})
+	}
+
+	return result
+}
+
+// namedItem is an item in one of the lists or maps of a config, with its
+// value rendered so items can be compared
+type namedItem struct {
+	name  string
+	value string
+}
+
+type section struct {
+	name  string
+	items []namedItem
+}
+
+// sections are the lists and maps of a config whose items are matched up by
+// name when diffing and merging
+func (c Config) sections() []section {
+	result := []section{}
+	for _, v := range []string{"hard_settings", "author_settings", "custom_settings", "validations", "projects", "products", "services", "profiles"} {
+		result = append(result, section{name: v, items: c.section(v)})
+	}
+	return result
+}
+
+func (c Config) section(name string) []namedItem {
+	result := []namedItem{}
+
+	switch name {
+	case "hard_settings":
+		return named(c.HardSet)
+	case "author_settings":
+		for _, v := range c.AuthorSettings {
+			result = append(result, namedItem{v.Name, render(v)})
+		}
+	case "custom_settings":
+		for _, v := range c.CustomSettings {
+			result = append(result, namedItem{v.Name, render(v)})
+		}
+	case "validations":
+		keys := []string{}
+		for k := range c.Validations {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			result = append(result, namedItem{k, render(c.Validations[k])})
+		}
+	case "projects":
+		for _, v := range c.Projects.Items {
+			result = append(result, namedItem{v.Name, render(v)})
+		}
+	case "products":
+		for _, v := range c.Products {
+			result = append(result, namedItem{v.Product, render(v)})
+		}
+	case "services":
+		for _, v := range c.Services {
+			result = append(result, namedItem{v, v})
+		}
+	case "profiles":
+		for _, v := range c.Profiles {
+			result = append(result, namedItem{v.Name, render(v)})
+		}
+	}
+
+	return result
+}
+
+// named turns a map into items sorted by key
+func named(m map[string]string) []namedItem {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := []namedItem{}
+	for _, k := range keys {
+		result = append(result, namedItem{k, m[k]})
+	}
+	return result
+}
+
+func render(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func findNamed(items []namedItem, name string) int {
+	for i, v := range items {
+		if v.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func diffNamed(section string, from, to []namedItem) []Change {
+	result := []Change{}
+
+	for _, v := range from {
+		i := findNamed(to, v.name)
+		if i < 0 {
+			result = append(result, Change{Kind: ChangeRemoved, Section: section, Name: v.name, From: v.value})
+			continue
+		}
+		if to[i].value != v.value {
+			result = append(result, Change{Kind: ChangeChanged, Section: section, Name: v.name, From: v.value, To: to[i].value})
+		}
+	}
+
+	for _, v := range to {
+		if findNamed(from, v.name) < 0 {
+			result = append(result, Change{Kind: ChangeAdded, Section: section, Name: v.name, To: v.value})
+		}
+	}
+
+	return result
+}
+
+// pick is an item to keep when merging, from either the base or incoming
+// list
+type pick struct {
+	name     string
+	index    int
+	incoming bool
+}
+
+// mergeNamed lines up two lists of items. Items from base keep their place,
+// and items only in incoming are added after them.
+func mergeNamed(section string, base, incoming []namedItem, rule MergeRule) ([]pick, []Change) {
+	picks := []pick{}
+	conflicts := []Change{}
+
+	for i, v := range base {
+		j := findNamed(incoming, v.name)
+		if j < 0 || incoming[j].value == v.value {
+			picks = append(picks, pick{name: v.name, index: i})
+			continue
+		}
+
+		conflicts = append(conflicts, Change{Kind: ChangeChanged, Section: section, Name: v.name, From: v.value, To: incoming[j].value})
+		if rule == PreferIncoming {
+			picks = append(picks, pick{name: v.name, index: j, incoming: true})
+			continue
+		}
+		picks = append(picks, pick{name: v.name, index: i})
+	}
+
+	for j, v := range incoming {
+		if findNamed(base, v.name) < 0 {
+			picks = append(picks, pick{name: v.name, index: j, incoming: true})
+		}
+	}
+
+	return picks, conflicts
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfigDiff(t *testing.T) {
+	tests := map[string]struct {
+		from Config
+		to   Config
+		want []Change
+	}{
+		"same": {
+			from: Config{Title: "A", Region: true, Products: []Product{{Product: "Cloud Run"}}},
+			to:   Config{Title: "A", Region: true, Products: []Product{{Product: "Cloud Run"}}},
+			want: []Change{},
+		},
+		"fields": {
+			from: Config{Title: "A", RegionType: "compute"},
+			to:   Config{Title: "B", Region: true, RegionType: "compute"},
+			want: []Change{
+				{Kind: ChangeChanged, Name: "title", From: "A", To: "B"},
+				{Kind: ChangeChanged, Name: "collect_region", From: "false", To: "true"},
+			},
+		},
+		"sections": {
+			from: Config{
+				HardSet:        map[string]string{"zone": "us-central1-a"},
+				AuthorSettings: Settings{{Name: "basename", Value: "old", Type: "string"}},
+				CustomSettings: Customs{{Name: "nodes", Default: "3"}},
+				Products:       []Product{{Product: "Compute Engine"}},
+			},
+			to: Config{
+				HardSet:        map[string]string{"zone": "us-central1-a"},
+				AuthorSettings: Settings{{Name: "basename", Value: "new", Type: "string"}},
+				CustomSettings: Customs{{Name: "size"}},
+				Products:       []Product{{Product: "Compute Engine"}, {Product: "Cloud Run"}},
+			},
+			want: []Change{
+				{
+					Kind:    ChangeChanged,
+					Section: "author_settings",
+					Name:    "basename",
+					From:    `{"name":"basename","value":"old","type":"string","list":null,"map":null}`,
+					To:      `{"name":"basename","value":"new","type":"string","list":null,"map":null}`,
+				},
+				{
+					Kind:    ChangeRemoved,
+					Section: "custom_settings",
+					Name:    "nodes",
+					From:    `{"name":"nodes","description":"","default":"3","options":null,"prepend_project":false}`,
+				},
+				{
+					Kind:    ChangeAdded,
+					Section: "custom_settings",
+					Name:    "size",
+					To:      `{"name":"size","description":"","default":"","options":null,"prepend_project":false}`,
+				},
+				{
+					Kind:    ChangeAdded,
+					Section: "products",
+					Name:    "Cloud Run",
+					To:      `{"info":"","product":"Cloud Run"}`,
+				},
+			},
+		},
+		"services": {
+			from: Config{Services: []string{"sqladmin.googleapis.com", "redis.googleapis.com"}},
+			to:   Config{Services: []string{"redis.googleapis.com", "run.googleapis.com"}},
+			want: []Change{
+				{Kind: ChangeRemoved, Section: "services", Name: "sqladmin.googleapis.com", From: "sqladmin.googleapis.com"},
+				{Kind: ChangeAdded, Section: "services", Name: "run.googleapis.com", To: "run.googleapis.com"},
+			},
+		},
+		"allow duplicates": {
+			from: Config{Projects: Projects{Items: []Project{{Name: "project_id"}}}},
+			to:   Config{Projects: Projects{Items: []Project{{Name: "project_id"}}, AllowDuplicates: true}},
+			want: []Change{
+				{Kind: ChangeChanged, Section: "projects", Name: "allow_duplicates", From: "false", To: "true"},
+			},
+		},
+		"hardset": {
+			from: Config{HardSet: map[string]string{"a": "1", "b": "2"}},
+			to:   Config{HardSet: map[string]string{"b": "3", "c": "4"}},
+			want: []Change{
+				{Kind: ChangeRemoved, Section: "hard_settings", Name: "a", From: "1"},
+				{Kind: ChangeChanged, Section: "hard_settings", Name: "b", From: "2", To: "3"},
+				{Kind: ChangeAdded, Section: "hard_settings", Name: "c", To: "4"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tc.from.Diff(tc.to)
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	tests := map[string]struct {
+		in   Change
+		want string
+	}{
+		"added":   {in: Change{Kind: ChangeAdded, Section: "products", Name: "Cloud Run", To: "x"}, want: "+ products.Cloud Run: x"},
+		"removed": {in: Change{Kind: ChangeRemoved, Section: "hard_settings", Name: "a", From: "1"}, want: "- hard_settings.a: 1"},
+		"changed": {in: Change{Kind: ChangeChanged, Name: "collect_zone", From: "false", To: "true"}, want: "~ collect_zone: false -> true"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tc.in.String()
+			if tc.want != got {
+				t.Fatalf("expected: %s, got: %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigMerge(t *testing.T) {
+	base := Config{
+		Title:          "Existing",
+		RegionType:     "run",
+		Project:        true,
+		AuthorSettings: Settings{{Name: "basename", Value: "mine", Type: "string"}},
+		CustomSettings: Customs{{Name: "nodes", Default: "3"}},
+		Products:       []Product{{Product: "Compute Engine", Info: "The server"}},
+		Services:       []string{"sqladmin.googleapis.com"},
+	}
+	incoming := Config{
+		Title:          "Guess",
+		Name:           "guess",
+		RegionType:     "compute",
+		Region:         true,
+		AuthorSettings: Settings{{Name: "basename", Value: "theirs", Type: "string"}},
+		CustomSettings: Customs{{Name: "nodes"}, {Name: "size"}},
+		Products:       []Product{{Product: "Compute Engine"}, {Product: "Cloud Run"}},
+		Projects:       Projects{Items: []Project{{Name: "project_id_2"}}, AllowDuplicates: true},
+		Services:       []string{"redis.googleapis.com", "sqladmin.googleapis.com"},
+	}
+	conflicts := []Change{
+		{Kind: ChangeChanged, Name: "title", From: "Existing", To: "Guess"},
+		{Kind: ChangeChanged, Name: "region_type", From: "run", To: "compute"},
+		{
+			Kind:    ChangeChanged,
+			Section: "author_settings",
+			Name:    "basename",
+			From:    `{"name":"basename","value":"mine","type":"string","list":null,"map":null}`,
+			To:      `{"name":"basename","value":"theirs","type":"string","list":null,"map":null}`,
+		},
+		{
+			Kind:    ChangeChanged,
+			Section: "custom_settings",
+			Name:    "nodes",
+			From:    `{"name":"nodes","description":"","default":"3","options":null,"prepend_project":false}`,
+			To:      `{"name":"nodes","description":"","default":"","options":null,"prepend_project":false}`,
+		},
+		{
+			Kind:    ChangeChanged,
+			Section: "products",
+			Name:    "Compute Engine",
+			From:    `{"info":"The server","product":"Compute Engine"}`,
+			To:      `{"info":"","product":"Compute Engine"}`,
+		},
+	}
+
+	tests := map[string]struct {
+		rule      MergeRule
+		want      Config
+		conflicts []Change
+	}{
+		"keepexisting": {
+			rule: KeepExisting,
+			want: Config{
+				Title:          "Existing",
+				Name:           "guess",
+				RegionType:     "run",
+				Project:        true,
+				Region:         true,
+				AuthorSettings: Settings{{Name: "basename", Value: "mine", Type: "string"}},
+				CustomSettings: Customs{{Name: "nodes", Default: "3"}, {Name: "size"}},
+				Products:       []Product{{Product: "Compute Engine", Info: "The server"}, {Product: "Cloud Run"}},
+				Projects:       Projects{Items: []Project{{Name: "project_id_2"}}, AllowDuplicates: true},
+				Services:       []string{"sqladmin.googleapis.com", "redis.googleapis.com"},
+			},
+			conflicts: conflicts,
+		},
+		"preferincoming": {
+			rule: PreferIncoming,
+			want: Config{
+				Title:          "Guess",
+				Name:           "guess",
+				RegionType:     "compute",
+				Project:        true,
+				Region:         true,
+				AuthorSettings: Settings{{Name: "basename", Value: "theirs", Type: "string"}},
+				CustomSettings: Customs{{Name: "nodes"}, {Name: "size"}},
+				Products:       []Product{{Product: "Compute Engine"}, {Product: "Cloud Run"}},
+				Projects:       Projects{Items: []Project{{Name: "project_id_2"}}, AllowDuplicates: true},
+				Services:       []string{"sqladmin.googleapis.com", "redis.googleapis.com"},
+			},
+			conflicts: conflicts,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, conflicts := base.Merge(incoming, tc.rule)
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got)
+			}
+
+			if !reflect.DeepEqual(tc.conflicts, conflicts) {
+				t.Fatalf("conflicts: expected: %+v, got: %+v", tc.conflicts, conflicts)
+			}
+		})
+	}
+}
+
+func TestConfigMergeEmpty(t *testing.T) {
+	in := Config{
+		Title:          "Only",
+		BillingAccount: true,
+		HardSet:        map[string]string{"zone": "us-central1-a"},
+		Validations:    map[string]Rule{"name": {Pattern: "^[a-z]+$"}},
+		Projects:       Projects{AllowDuplicates: true},
+		Profiles:       Profiles{{Name: "small"}},
+	}
+
+	got, conflicts := Config{}.Merge(in, KeepExisting)
+
+	if !reflect.DeepEqual(in, got) {
+		t.Fatalf("expected: %+v, got: %+v", in, got)
+	}
+
+	if len(conflicts) > 0 {
+		t.Fatalf("expected no conflicts, got: %+v", conflicts)
+	}
+}
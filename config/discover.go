@@ -0,0 +1,227 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// IgnoreFile is the file at the root of a repo that lists paths discovery
+// should leave alone, in a subset of .gitignore syntax
+const IgnoreFile = ".deploystackignore"
+
+// DiscoverDepth is how many folders deep discovery goes below the root
+const DiscoverDepth = 8
+
+// skipDirs are folders that never hold a stack of their own, but can hold
+// enough files to make walking them take seconds. .terraform in particular
+// is full of downloaded modules with a main.tf of their own.
+var skipDirs = map[string]bool{
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	".terraform":   true,
+	"node_modules": true,
+	"vendor":       true,
+	".venv":        true,
+	"venv":         true,
+	"__pycache__":  true,
+	".idea":        true,
+	".vscode":      true,
+}
+
+// Discovery is everything a single walk of a repo turns up
+type Discovery struct {
+	Root string
+	// Configs are the paths of every deploystack.json and deploystack.yaml
+	// in walk order
+	Configs []string
+	// TerraformRoots are the folders holding a main.tf or main.tf.json,
+	// shallowest first
+	TerraformRoots []string
+}
+
+// Discover walks dir once, down to DiscoverDepth, skipping heavy folders and
+// anything listed in the IgnoreFile at its root, and reports every config
+// and Terraform root it finds
+func Discover(dir string) (Discovery, error) {
+	return discover(dir, DiscoverDepth)
+}
+
+func discover(dir string, depth int) (Discovery, error) {
+	result := Discovery{Root: dir}
+	roots := map[string]bool{}
+
+	rules, err := readIgnoreRules(filepath.Join(dir, IgnoreFile))
+	if err != nil {
+		return result, err
+	}
+
+	err = filepath.WalkDir(dir, func(walkpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// An unreadable folder below the root shouldn't sink the whole
+			// walk, there is nothing we could have used in there anyway
+			if walkpath != dir && d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, walkpath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if walkpath == dir {
+				return nil
+			}
+			if skipDirs[d.Name()] || rules.match(rel, true) {
+				return filepath.SkipDir
+			}
+			if strings.Count(rel, "/")+1 > depth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if rules.match(rel, false) {
+			return nil
+		}
+
+		switch d.Name() {
+		case "deploystack.json", "deploystack.yaml":
+			result.Configs = append(result.Configs, walkpath)
+		case "main.tf", "main.tf.json":
+			root := filepath.Dir(walkpath)
+			if !roots[root] {
+				roots[root] = true
+				result.TerraformRoots = append(result.TerraformRoots, root)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("could not discover the contents of %s: %w", dir, err)
+	}
+
+	sort.SliceStable(result.TerraformRoots, func(i, j int) bool {
+		a, b := result.TerraformRoots[i], result.TerraformRoots[j]
+		da, db := strings.Count(a, string(filepath.Separator)), strings.Count(b, string(filepath.Separator))
+		if da != db {
+			return da < db
+		}
+		return len(a) < len(b)
+	})
+
+	return result, nil
+}
+
+// ignoreRule is a single line of an IgnoreFile
+type ignoreRule struct {
+	pattern  string
+	dirOnly  bool
+	anchored bool
+}
+
+type ignoreRules []ignoreRule
+
+// readIgnoreRules reads an IgnoreFile. Blank lines and lines starting with #
+// are skipped. A trailing / only matches folders, and a leading / or any other
+// / in the pattern matches against the whole path from the root instead of
+// just the name. A missing file is no rules at all.
+func readIgnoreRules(filename string) (ignoreRules, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not read %s: %w", filename, err)
+	}
+
+	return parseIgnoreRules(content)
+}
+
+func parseIgnoreRules(content []byte) (ignoreRules, error) {
+	var result ignoreRules
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		r := ignoreRule{}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.HasPrefix(line, "/") {
+			line = strings.TrimLeft(line, "/")
+			r.anchored = true
+		}
+		if strings.Contains(line, "/") {
+			r.anchored = true
+		}
+		if line == "" {
+			continue
+		}
+
+		if _, err := path.Match(line, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern in %s: %s", IgnoreFile, scanner.Text())
+		}
+
+		r.pattern = line
+		result = append(result, r)
+	}
+
+	return result, scanner.Err()
+}
+
+// match reports whether rel, a slash separated path from the root, is ignored
+func (r ignoreRules) match(rel string, isDir bool) bool {
+	name := rel
+	if i := strings.LastIndex(rel, "/"); i >= 0 {
+		name = rel[i+1:]
+	}
+
+	for _, v := range r {
+		if v.dirOnly && !isDir {
+			continue
+		}
+
+		target := name
+		if v.anchored {
+			target = rel
+		}
+
+		if ok, _ := path.Match(v.pattern, target); ok {
+			return true
+		}
+	}
+
+	return false
+}
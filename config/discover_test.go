@@ -0,0 +1,291 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTree creates every file in files, relative to dir, with empty content
+// unless one is given
+func writeTree(t testing.TB, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("could not create %s: %s", path, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("could not write %s: %s", path, err)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	tests := map[string]struct {
+		files     map[string]string
+		depth     int
+		configs   []string
+		terraform []string
+	}{
+		"basic": {
+			files: map[string]string{
+				"main.tf":                             "",
+				".deploystack/deploystack.yaml":       "",
+				"modules/network/main.tf":             "",
+				"other/.deploystack/deploystack.json": "",
+			},
+			depth: DiscoverDepth,
+			configs: []string{
+				".deploystack/deploystack.yaml",
+				"other/.deploystack/deploystack.json",
+			},
+			terraform: []string{".", "modules/network"},
+		},
+		"heavy": {
+			files: map[string]string{
+				"terraform/main.tf":                              "",
+				"terraform/.terraform/modules/vpc/main.tf":       "",
+				".git/objects/main.tf":                           "",
+				"node_modules/pkg/.deploystack/deploystack.yaml": "",
+				"vendor/github.com/x/main.tf":                    "",
+				".deploystack/deploystack.yaml":                  "",
+			},
+			depth:     DiscoverDepth,
+			configs:   []string{".deploystack/deploystack.yaml"},
+			terraform: []string{"terraform"},
+		},
+		"json": {
+			files: map[string]string{
+				"main.tf":              "",
+				"main.tf.json":         "",
+				"json/main.tf.json":    "",
+				"json/variables.tf.js": "",
+			},
+			depth:     DiscoverDepth,
+			terraform: []string{".", "json"},
+		},
+		"shallowestfirst": {
+			files: map[string]string{
+				"a/b/c/main.tf":     "",
+				"terraform/main.tf": "",
+				"zz/main.tf":        "",
+			},
+			depth:     DiscoverDepth,
+			terraform: []string{"zz", "terraform", "a/b/c"},
+		},
+		"depth": {
+			files: map[string]string{
+				"one/main.tf":                    "",
+				"one/two/main.tf":                "",
+				"one/two/three/main.tf":          "",
+				"one/two/deploystack.yaml":       "",
+				"one/two/three/deploystack.json": "",
+			},
+			depth:     2,
+			configs:   []string{"one/two/deploystack.yaml"},
+			terraform: []string{"one", "one/two"},
+		},
+		"ignorefile": {
+			files: map[string]string{
+				IgnoreFile: strings.Join([]string{
+					"# examples are not stacks",
+					"examples/",
+					"/legacy",
+					"deploystack.json",
+					"nested/skip/",
+				}, "\n"),
+				"terraform/main.tf":             "",
+				"examples/simple/main.tf":       "",
+				"legacy/main.tf":                "",
+				"keep/legacy/main.tf":           "",
+				"old/deploystack.json":          "",
+				".deploystack/deploystack.yaml": "",
+				"nested/skip/main.tf":           "",
+				"other/nested/skip/main.tf":     "",
+			},
+			depth:     DiscoverDepth,
+			configs:   []string{".deploystack/deploystack.yaml"},
+			terraform: []string{"terraform", "keep/legacy", "other/nested/skip"},
+		},
+		"empty": {
+			files: map[string]string{"README.md": ""},
+			depth: DiscoverDepth,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTree(t, dir, tc.files)
+
+			got, err := discover(dir, tc.depth)
+			if err != nil {
+				t.Fatalf("expected no error, got %s", err)
+			}
+
+			if got.Root != dir {
+				t.Fatalf("root: expected: %s, got: %s", dir, got.Root)
+			}
+
+			configs := relPaths(t, dir, got.Configs)
+			if !reflect.DeepEqual(tc.configs, configs) {
+				t.Fatalf("configs: expected: %+v, got: %+v", tc.configs, configs)
+			}
+
+			terraform := relPaths(t, dir, got.TerraformRoots)
+			if !reflect.DeepEqual(tc.terraform, terraform) {
+				t.Fatalf("terraform: expected: %+v, got: %+v", tc.terraform, terraform)
+			}
+		})
+	}
+}
+
+func relPaths(t *testing.T, dir string, paths []string) []string {
+	t.Helper()
+	var result []string
+	for _, v := range paths {
+		rel, err := filepath.Rel(dir, v)
+		if err != nil {
+			t.Fatalf("could not make %s relative: %s", v, err)
+		}
+		result = append(result, filepath.ToSlash(rel))
+	}
+	return result
+}
+
+func TestDiscoverErrors(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+		dir   string
+	}{
+		"missing": {
+			dir: "doesnotexist",
+		},
+		"badpattern": {
+			files: map[string]string{IgnoreFile: "[unclosed"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTree(t, dir, tc.files)
+
+			if _, err := Discover(filepath.Join(dir, tc.dir)); err == nil {
+				t.Fatalf("expected an error, got none")
+			}
+		})
+	}
+}
+
+func TestIgnoreRulesMatch(t *testing.T) {
+	rules, err := parseIgnoreRules([]byte(strings.Join([]string{
+		"",
+		"# comment",
+		"build/",
+		"/top",
+		"docs/*.md",
+		"*.bak",
+	}, "\n")))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	tests := map[string]struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		"dironly dir":        {rel: "a/build", isDir: true, want: true},
+		"dironly file":       {rel: "a/build", isDir: false, want: false},
+		"anchored top":       {rel: "top", isDir: true, want: true},
+		"anchored nested":    {rel: "a/top", isDir: true, want: false},
+		"slash pattern":      {rel: "docs/readme.md", want: true},
+		"slash pattern deep": {rel: "a/docs/readme.md", want: false},
+		"name pattern":       {rel: "a/b/main.tf.bak", want: true},
+		"no match":           {rel: "a/b/main.tf", want: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := rules.match(tc.rel, tc.isDir)
+			if got != tc.want {
+				t.Fatalf("expected: %t, got: %t", tc.want, got)
+			}
+		})
+	}
+}
+
+// syntheticRepo lays out a monorepo with a handful of stacks, each with the
+// provider caches, git objects and node_modules that make walks slow
+func syntheticRepo(b *testing.B) string {
+	b.Helper()
+	dir := b.TempDir()
+	files := map[string]string{}
+
+	for i := 0; i < 100; i++ {
+		files[fmt.Sprintf(".git/objects/%02x/%038d", i, i)] = ""
+	}
+
+	for s := 0; s < 10; s++ {
+		stack := fmt.Sprintf("stacks/stack%d", s)
+		files[stack+"/.deploystack/deploystack.yaml"] = ""
+		files[stack+"/terraform/main.tf"] = ""
+		files[stack+"/terraform/variables.tf"] = ""
+
+		for m := 0; m < 20; m++ {
+			mod := fmt.Sprintf("%s/terraform/.terraform/modules/mod%d", stack, m)
+			files[mod+"/main.tf"] = ""
+			files[mod+"/variables.tf"] = ""
+			files[mod+"/outputs.tf"] = ""
+		}
+
+		for p := 0; p < 100; p++ {
+			pkg := fmt.Sprintf("%s/app/node_modules/pkg%d", stack, p)
+			files[pkg+"/package.json"] = ""
+			files[pkg+"/lib/index.js"] = ""
+			files[pkg+"/lib/util/index.js"] = ""
+		}
+	}
+
+	writeTree(b, dir, files)
+	return dir
+}
+
+func BenchmarkDiscover(b *testing.B) {
+	dir := syntheticRepo(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := Discover(dir); err != nil {
+			b.Fatalf("expected no error, got %s", err)
+		}
+	}
+}
+
+// BenchmarkDiscoverWalkAll is the full filepath.Walk discovery used to do,
+// kept as a baseline for BenchmarkDiscover
+func BenchmarkDiscoverWalkAll(b *testing.B) {
+	dir := syntheticRepo(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var configs, mains []string
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			switch info.Name() {
+			case "deploystack.json", "deploystack.yaml":
+				configs = append(configs, path)
+			case "main.tf":
+				mains = append(mains, filepath.Dir(path))
+			}
+			return nil
+		})
+		if err != nil {
+			b.Fatalf("expected no error, got %s", err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// SettingEnvPrefix is the prefix for environment variables that set any
+	// setting of a stack. DEPLOYSTACK_SETTING_REGION=us-east1 sets region.
+	SettingEnvPrefix = "DEPLOYSTACK_SETTING_"
+	// TFVarEnvPrefix is the prefix Terraform uses for variables in the
+	// environment. They only set settings the stack would ask for.
+	TFVarEnvPrefix = "TF_VAR_"
+)
+
+// Override sets settings from environment variables in env, which is
+// expected in the format of os.Environ. DEPLOYSTACK_SETTING_ variables win
+// over TF_VAR_ ones for the same setting, and both win over author settings.
+// Overridden settings have SourceEnv as their source, so nothing asks for
+// them, or replaces them, later on.
+func (s *Stack) Override(env []string) error {
+	values := map[string]envValue{}
+	names := []string{}
+
+	collect := func(prefix string, known bool) {
+		for _, v := range env {
+			if !strings.HasPrefix(v, prefix) {
+				continue
+			}
+
+			sl := strings.SplitN(strings.TrimPrefix(v, prefix), "=", 2)
+			if len(sl) != 2 || sl[0] == "" {
+				continue
+			}
+
+			name := strings.ToLower(sl[0])
+			if known && !s.Config.asks(name) {
+				continue
+			}
+
+			if _, ok := values[name]; !ok {
+				names = append(names, name)
+			}
+			values[name] = envValue{variable: prefix + sl[0], value: sl[1]}
+		}
+	}
+
+	collect(TFVarEnvPrefix, true)
+	collect(SettingEnvPrefix, false)
+
+	for _, name := range names {
+		v := values[name]
+		set, err := s.Config.envSetting(name, v.value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %s", v.variable, err)
+		}
+
+		set.Source = SourceEnv
+		s.AddSettingComplete(set)
+	}
+
+	if profile := s.Settings.Find(ProfileSetting); profile != nil && s.Overridden(ProfileSetting) && len(s.Config.Profiles) > 0 {
+		if err := s.ApplyProfile(profile.Value); err != nil {
+			return fmt.Errorf("invalid value for %s: %s", values[ProfileSetting].variable, err)
+		}
+	}
+
+	return nil
+}
+
+// envValue is a value from the environment and the variable it came from,
+// so problems with it can name the variable that was actually set
+type envValue struct {
+	variable string
+	value    string
+}
+
+// Overridden reports whether a setting was set from the environment
+func (s Stack) Overridden(name string) bool {
+	set := s.Settings.Find(name)
+	return set != nil && set.Source == SourceEnv
+}
+
+// envSetting types a value from the environment the way the setting it is
+// for is typed, and checks it the way an answer in the UI would be.
+func (c Config) envSetting(name, value string) (Setting, error) {
+	cu := c.CustomSettings.Get(name)
+	if cu.Name == "" {
+		kind := "string"
+		if set := c.AuthorSettings.Find(name); set != nil && set.Type != "" {
+			kind = set.Type
+		}
+		return NewSettingTyped(name, kind, value)
+	}
+
+	if err := c.checkAnswer(cu, value); err != nil {
+		return Setting{}, err
+	}
+
+	set, err := NewSettingTyped(name, cu.Type, value)
+	if err != nil {
+		return set, err
+	}
+	set.Secret = cu.Secret
+
+	return set, nil
+}
+
+// asks reports whether name is a setting the stack collects, rather than
+// some other variable that happens to be in the environment.
+func (c Config) asks(name string) bool {
+	if c.CustomSettings.Get(name).Name != "" {
+		return true
+	}
+
+	for _, v := range c.Projects.Items {
+		if v.Name == name {
+			return true
+		}
+	}
+
+	switch name {
+	case "project_id":
+		return c.Project
+	case "region":
+		return c.Region
+	case "zone":
+		return c.Zone
+	case "billing_account":
+		return c.BillingAccount
+	case "domain":
+		return c.Domain
+	case ProfileSetting:
+		return len(c.Profiles) > 0
+	}
+
+	return false
+}
@@ -0,0 +1,181 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestStackOverride(t *testing.T) {
+	tests := map[string]struct {
+		env       []string
+		want      Settings
+		overrides []string
+		region    string
+		err       string
+	}{
+		"settings": {
+			env: []string{
+				"DEPLOYSTACK_SETTING_REGION=europe-west1",
+				"DEPLOYSTACK_SETTING_NODES=5",
+				"DEPLOYSTACK_SETTING_ZONES=a,b",
+				"PATH=/bin",
+			},
+			want: Settings{
+				{Name: "basename", Value: "stack", Type: "string"},
+				{Name: "nodes", Value: "5", Type: "number", Source: SourceEnv},
+				{Name: "region", Value: "europe-west1", Type: "string", Source: SourceEnv},
+				{Name: "zones", Value: "a,b", List: []string{"a", "b"}, Type: "list", Source: SourceEnv},
+			},
+			overrides: []string{"nodes", "region", "zones"},
+		},
+		"tf vars only for what is asked": {
+			env: []string{
+				"TF_VAR_region=europe-west1",
+				"TF_VAR_secret_thing=hunter2",
+				"TF_VAR_nodes=2",
+				"DEPLOYSTACK_SETTING_NODES=4",
+			},
+			want: Settings{
+				{Name: "basename", Value: "stack", Type: "string"},
+				{Name: "nodes", Value: "4", Type: "number", Source: SourceEnv},
+				{Name: "region", Value: "europe-west1", Type: "string", Source: SourceEnv},
+			},
+			overrides: []string{"nodes", "region"},
+		},
+		"author setting": {
+			env: []string{"DEPLOYSTACK_SETTING_BASENAME=other"},
+			want: Settings{
+				{Name: "basename", Value: "other", Type: "string", Source: SourceEnv},
+			},
+			overrides: []string{"basename"},
+		},
+		"profile": {
+			env: []string{"DEPLOYSTACK_SETTING_PROFILE=prod"},
+			want: Settings{
+				{Name: "basename", Value: "stack", Type: "string"},
+				{Name: "profile", Value: "prod", Type: "string", Source: SourceEnv},
+			},
+			overrides: []string{"profile"},
+			region:    "us-east1",
+		},
+		"unknown profile": {
+			env: []string{"DEPLOYSTACK_SETTING_PROFILE=qa"},
+			err: "invalid value for DEPLOYSTACK_SETTING_PROFILE",
+		},
+		"invalid number": {
+			env: []string{"DEPLOYSTACK_SETTING_NODES=five"},
+			err: "invalid value for DEPLOYSTACK_SETTING_NODES",
+		},
+		"invalid tf var": {
+			env: []string{"TF_VAR_nodes=five"},
+			err: "invalid value for TF_VAR_nodes",
+		},
+		"not an option": {
+			env: []string{"DEPLOYSTACK_SETTING_TIER=db-n1-standard-1"},
+			err: "invalid value for DEPLOYSTACK_SETTING_TIER",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewStack()
+			s.Config = Config{
+				Region:         true,
+				RegionDefault:  "us-central1",
+				AuthorSettings: Settings{{Name: "basename", Value: "stack", Type: "string"}},
+				CustomSettings: Customs{
+					{Name: "nodes", Type: "number"},
+					{Name: "zones", Type: "list(string)"},
+					{Name: "tier", Options: []string{"db-f1-micro", "db-g1-small"}},
+				},
+				Profiles: Profiles{
+					{Name: "dev"},
+					{Name: "prod", RegionDefault: "us-east1"},
+				},
+			}
+			s.Settings = Settings{{Name: "basename", Value: "stack", Type: "string"}}
+
+			err := s.Override(tc.env)
+			if tc.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tc.err) {
+					t.Fatalf("expected error starting %q, got: %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			s.Settings.Sort()
+			if !reflect.DeepEqual(tc.want, s.Settings) {
+				t.Fatalf("compare failed: %v", deep.Equal(tc.want, s.Settings))
+			}
+
+			for _, v := range tc.overrides {
+				if !s.Overridden(v) {
+					t.Errorf("expected %s to be recorded as overridden", v)
+				}
+			}
+			overridden := 0
+			for _, v := range s.Settings {
+				if s.Overridden(v.Name) {
+					overridden++
+				}
+			}
+			if len(tc.overrides) != overridden {
+				t.Errorf("want overrides %v got %d", tc.overrides, overridden)
+			}
+
+			if tc.region != "" && s.Config.RegionDefault != tc.region {
+				t.Errorf("profile was not applied, region default: %s", s.Config.RegionDefault)
+			}
+		})
+	}
+}
+
+func TestStackOverrideSecret(t *testing.T) {
+	tests := map[string]struct {
+		env []string
+	}{
+		"tf var":  {env: []string{"TF_VAR_db_password=hunter2"}},
+		"setting": {env: []string{"DEPLOYSTACK_SETTING_DB_PASSWORD=hunter2"}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewStack()
+			s.Config = Config{
+				CustomSettings: Customs{{Name: "db_password", Secret: true}},
+			}
+
+			if err := s.Override(tc.env); err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			if env := s.TerraformEnv(); !strings.Contains(env, "TF_VAR_db_password='hunter2'") {
+				t.Fatalf("expected the secret in the environment, got: %s", env)
+			}
+
+			if tfvars := s.Terraform(); strings.Contains(tfvars, "hunter2") {
+				t.Fatalf("expected the secret to be kept out of tfvars, got: %s", tfvars)
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Issue is a single problem found while linting a config file
+type Issue struct {
+	File       string `json:"file" yaml:"file"`
+	Line       int    `json:"line" yaml:"line"`
+	Column     int    `json:"column" yaml:"column"`
+	Path       string `json:"path" yaml:"path"`
+	Key        string `json:"key" yaml:"key"`
+	Message    string `json:"message" yaml:"message"`
+	Suggestion string `json:"suggestion" yaml:"suggestion"`
+}
+
+// String renders the issue in the file:line:column format editors and CI
+// systems understand
+func (i Issue) String() string {
+	sb := strings.Builder{}
+
+	if i.File != "" {
+		sb.WriteString(fmt.Sprintf("%s:", i.File))
+	}
+
+	if i.Line > 0 {
+		sb.WriteString(fmt.Sprintf("%d:%d:", i.Line, i.Column))
+	}
+
+	if sb.Len() > 0 {
+		sb.WriteString(" ")
+	}
+	sb.WriteString(i.Message)
+
+	if i.Suggestion != "" {
+		sb.WriteString(fmt.Sprintf(", did you mean '%s'?", i.Suggestion))
+	}
+
+	return sb.String()
+}
+
+// Issues is a collection of Issue. It satisfies error so that strict decoding
+// can hand back every problem at once
+type Issues []Issue
+
+// Error returns all of the issues one per line
+func (is Issues) Error() string {
+	sl := []string{}
+	for _, v := range is {
+		sl = append(sl, v.String())
+	}
+	return strings.Join(sl, "\n")
+}
+
+// Sort orders the issues by file and then by position
+func (is *Issues) Sort() {
+	sort.SliceStable(*is, func(i, j int) bool {
+		if (*is)[i].File != (*is)[j].File {
+			return (*is)[i].File < (*is)[j].File
+		}
+		if (*is)[i].Line != (*is)[j].Line {
+			return (*is)[i].Line < (*is)[j].Line
+		}
+		return (*is)[i].Column < (*is)[j].Column
+	})
+}
+
+func (is *Issues) setFile(file string) {
+	for i := range *is {
+		(*is)[i].File = file
+	}
+}
+
+// NewConfigJSONStrict returns a Config object from a file read, but unlike
+// NewConfigJSON it will fail with Issues if the content contains keys that
+// DeployStack does not know about.
+func NewConfigJSONStrict(content []byte) (Config, error) {
+	result, err := NewConfigJSON(content)
+	if err != nil {
+		return result, err
+	}
+
+	if issues := findUnknownKeys(content, "json"); len(issues) > 0 {
+		return result, issues
+	}
+
+	return result, nil
+}
+
+// NewConfigYAMLStrict returns a Config object from a file read, but unlike
+// NewConfigYAML it will fail with Issues if the content contains keys that
+// DeployStack does not know about.
+func NewConfigYAMLStrict(content []byte) (Config, error) {
+	result, err := NewConfigYAML(content)
+	if err != nil {
+		return result, err
+	}
+
+	if issues := findUnknownKeys(content, "yaml"); len(issues) > 0 {
+		return result, issues
+	}
+
+	return result, nil
+}
+
+// LintFile strictly decodes a single config file and reports everything wrong
+// with it.
+func LintFile(file string) (Issues, error) {
+	dat, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %s", err)
+	}
+
+	var c Config
+	switch filepath.Ext(file) {
+	case ".json":
+		c, err = NewConfigJSONStrict(dat)
+	default:
+		c, err = NewConfigYAMLStrict(dat)
+	}
+
+	if err == nil {
+		issues := append(c.lintCustoms(), c.lintProfiles()...)
+		issues = append(issues, c.lintServices()...)
+		issues.setFile(file)
+		return issues, nil
+	}
+
+	issues, ok := err.(Issues)
+	if !ok {
+		// A file that will not even decode is still a lint failure, not a
+		// reason to stop linting the rest of the repo.
+		issues = Issues{Issue{Message: err.Error()}}
+	}
+
+	issues.setFile(file)
+	issues.Sort()
+
+	return issues, nil
+}
+
+// Lint walks a directory, finds all of the DeployStack configs in it and
+// reports everything wrong with them. It is meant to be run by authors and
+// CI before a stack is shipped.
+func Lint(dir string) (Issues, error) {
+	result := Issues{}
+
+	files, err := findConfigFiles(dir)
+	if err != nil {
+		return result, fmt.Errorf("could not find configs to lint: %s", err)
+	}
+
+	if len(files) == 0 {
+		return result, ErrConfigNotExist
+	}
+
+	for _, v := range files {
+		issues, err := LintFile(v)
+		if err != nil {
+			return result, fmt.Errorf("could not lint (%s): %s", v, err)
+		}
+		result = append(result, issues...)
+	}
+
+	return result, nil
+}
+
+func findUnknownKeys(content []byte, format string) Issues {
+	node := yamlv3.Node{}
+	if err := yamlv3.Unmarshal(content, &node); err != nil {
+		return nil
+	}
+
+	if len(node.Content) == 0 {
+		return nil
+	}
+
+	issues := Issues{}
+	walkNode(node.Content[0], reflect.TypeOf(Config{}), format, "", &issues)
+	issues.Sort()
+
+	return issues
+}
+
+func walkNode(n *yamlv3.Node, t reflect.Type, format, path string, issues *Issues) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch n.Kind {
+	case yamlv3.AliasNode:
+		return
+	case yamlv3.MappingNode:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := knownFields(t, format)
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key := n.Content[i]
+				val := n.Content[i+1]
+				child := joinPath(path, key.Value)
+
+				ft, ok := fields[key.Value]
+				if !ok {
+					names := []string{}
+					for k := range fields {
+						names = append(names, k)
+					}
+
+					*issues = append(*issues, Issue{
+						Line:       key.Line,
+						Column:     key.Column,
+						Path:       child,
+						Key:        key.Value,
+						Message:    fmt.Sprintf("unknown key '%s'", child),
+						Suggestion: suggest(key.Value, names),
+					})
+					continue
+				}
+				walkNode(val, ft, format, child, issues)
+			}
+		case reflect.Map:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				child := joinPath(path, n.Content[i].Value)
+				walkNode(n.Content[i+1], t.Elem(), format, child, issues)
+			}
+		}
+	case yamlv3.SequenceNode:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		for i, v := range n.Content {
+			walkNode(v, t.Elem(), format, fmt.Sprintf("%s[%d]", path, i), issues)
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return fmt.Sprintf("%s.%s", path, key)
+}
+
+// knownFields returns the serialized names of the fields of a struct mapped to
+// their type, honoring the tags for the requested format.
+func knownFields(t reflect.Type, format string) map[string]reflect.Type {
+	result := map[string]reflect.Type{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := tagName(f, format)
+		if name == "" {
+			continue
+		}
+		result[name] = f.Type
+	}
+
+	return result
+}
+
+// tagName returns the key a struct field is serialized as, or an empty string
+// if it is not serialized at all.
+func tagName(f reflect.StructField, format string) string {
+	if f.PkgPath != "" {
+		return ""
+	}
+
+	tag := f.Tag.Get(format)
+	if tag == "-" {
+		return ""
+	}
+
+	name := strings.Split(tag, ",")[0]
+	if name == "" {
+		name = strings.ToLower(f.Name)
+	}
+
+	return name
+}
+
+// suggest returns the candidate closest to input, as long as it is close
+// enough to plausibly be a typo.
+func suggest(input string, candidates []string) string {
+	sort.Strings(candidates)
+
+	best := ""
+	bestDistance := -1
+	in := strings.ToLower(input)
+
+	for _, v := range candidates {
+		d := levenshtein(in, strings.ToLower(v))
+		if bestDistance == -1 || d < bestDistance {
+			best = v
+			bestDistance = d
+		}
+	}
+
+	threshold := len(input)/3 + 1
+	if bestDistance < 0 || bestDistance > threshold {
+		return ""
+	}
+
+	return best
+}
+
+func levenshtein(a, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func minInt(v ...int) int {
+	result := v[0]
+	for _, i := range v[1:] {
+		if i < result {
+			result = i
+		}
+	}
+	return result
+}
@@ -0,0 +1,208 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestNewConfigYAMLStrict(t *testing.T) {
+	tests := map[string]struct {
+		in   string
+		want Issues
+	}{
+		"clean": {
+			in: `title: A Stack
+collect_region: true
+custom_settings:
+- name: nodes
+  default: 3
+`,
+			want: nil,
+		},
+		"typo top level": {
+			in: `title: A Stack
+colect_region: true
+`,
+			want: Issues{
+				{
+					Line:       2,
+					Column:     1,
+					Path:       "colect_region",
+					Key:        "colect_region",
+					Message:    "unknown key 'colect_region'",
+					Suggestion: "collect_region",
+				},
+			},
+		},
+		"typo plural": {
+			in: `title: A Stack
+custom_setting:
+- name: nodes
+`,
+			want: Issues{
+				{
+					Line:       2,
+					Column:     1,
+					Path:       "custom_setting",
+					Key:        "custom_setting",
+					Message:    "unknown key 'custom_setting'",
+					Suggestion: "custom_settings",
+				},
+			},
+		},
+		"nested": {
+			in: `title: A Stack
+custom_settings:
+- name: nodes
+  descripton: How many nodes
+projects:
+  items:
+  - variable_name: project_id
+    nonsense: true
+`,
+			want: Issues{
+				{
+					Line:       4,
+					Column:     3,
+					Path:       "custom_settings[0].descripton",
+					Key:        "descripton",
+					Message:    "unknown key 'custom_settings[0].descripton'",
+					Suggestion: "description",
+				},
+				{
+					Line:    8,
+					Column:  5,
+					Path:    "projects.items[0].nonsense",
+					Key:     "nonsense",
+					Message: "unknown key 'projects.items[0].nonsense'",
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewConfigYAMLStrict([]byte(tc.in))
+
+			if tc.want == nil {
+				if err != nil {
+					t.Fatalf("expected no error, got: %s", err)
+				}
+				return
+			}
+
+			got, ok := err.(Issues)
+			if !ok {
+				t.Fatalf("expected Issues, got: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("compare failed: %v", deep.Equal(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestNewConfigJSONStrict(t *testing.T) {
+	in := "{\n\t\"title\": \"A Stack\",\n\t\"colect_zone\": true\n}"
+
+	_, err := NewConfigJSONStrict([]byte(in))
+
+	got, ok := err.(Issues)
+	if !ok {
+		t.Fatalf("expected Issues, got: %v", err)
+	}
+
+	want := "3:2: unknown key 'colect_zone', did you mean 'collect_zone'?"
+	if got.Error() != want {
+		t.Fatalf("expected: %s, got: %s", want, got.Error())
+	}
+}
+
+func TestLint(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+		want  []string
+		err   error
+	}{
+		"clean": {
+			files: map[string]string{
+				".deploystack/deploystack.yaml": "title: A Stack\n",
+			},
+			want: []string{},
+		},
+		"multiple": {
+			files: map[string]string{
+				"one/.deploystack/deploystack.yaml": "title: A Stack\nregion_defualt: us-central1\n",
+				"two/.deploystack/deploystack.json": "{\"title\": \"A Stack\", \"durration\": 4}",
+			},
+			want: []string{
+				"one/.deploystack/deploystack.yaml:2:1: unknown key 'region_defualt', did you mean 'region_default'?",
+				"two/.deploystack/deploystack.json:1:22: unknown key 'durration', did you mean 'duration'?",
+			},
+		},
+		"bad file": {
+			files: map[string]string{
+				".deploystack/deploystack.yaml": "title: [\n",
+			},
+			want: []string{
+				".deploystack/deploystack.yaml: unable to convert content to Config: yaml: line 1: did not find expected node content",
+			},
+		},
+		"no config": {
+			files: map[string]string{"main.tf": ""},
+			err:   ErrConfigNotExist,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for file, content := range tc.files {
+				path := filepath.Join(dir, file)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatalf("could not create test dir: %s", err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatalf("could not create test file: %s", err)
+				}
+			}
+
+			issues, err := Lint(dir)
+			if err != tc.err {
+				t.Fatalf("expected error: %v, got: %v", tc.err, err)
+			}
+			if err != nil {
+				return
+			}
+
+			got := []string{}
+			for _, v := range issues {
+				v.File, _ = filepath.Rel(dir, v.File)
+				got = append(got, v.String())
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("compare failed: %v", deep.Equal(tc.want, got))
+			}
+		})
+	}
+}
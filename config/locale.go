@@ -0,0 +1,146 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LocaleEnv is the environment variable that picks the locale ahead of the
+// usual LC_ALL, LC_MESSAGES and LANG.
+const LocaleEnv = "DEPLOYSTACK_LOCALE"
+
+const catalogFile = "catalog.yaml"
+
+// LocaleFromEnv picks the locale from env, which is expected in the format of
+// os.Environ, the same way gettext does, after checking DEPLOYSTACK_LOCALE.
+func LocaleFromEnv(env []string) string {
+	vars := map[string]string{}
+	for _, v := range env {
+		sl := strings.SplitN(v, "=", 2)
+		if len(sl) == 2 {
+			vars[sl[0]] = sl[1]
+		}
+	}
+
+	for _, k := range []string{LocaleEnv, "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := NormalizeLocale(vars[k]); locale != "" {
+			return locale
+		}
+	}
+
+	return ""
+}
+
+// NormalizeLocale turns a POSIX locale like pt_BR.UTF-8 into the pt-BR form
+// used for message folders and translations. The C and POSIX locales mean no
+// locale in particular, and come back empty.
+func NormalizeLocale(locale string) string {
+	locale = strings.TrimSpace(locale)
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return ""
+	}
+
+	sl := strings.SplitN(strings.ReplaceAll(locale, "_", "-"), "-", 2)
+	if len(sl) == 1 {
+		return strings.ToLower(sl[0])
+	}
+
+	return fmt.Sprintf("%s-%s", strings.ToLower(sl[0]), strings.ToUpper(sl[1]))
+}
+
+// LocaleCandidates lists the names to look for a locale's text under, most
+// specific first, so that pt-BR falls back to pt.
+func LocaleCandidates(locale string) []string {
+	locale = NormalizeLocale(locale)
+	if locale == "" {
+		return []string{}
+	}
+
+	result := []string{locale}
+	if strings.Contains(locale, "-") {
+		result = append(result, strings.ReplaceAll(locale, "-", "_"))
+		result = append(result, strings.SplitN(locale, "-", 2)[0])
+	}
+
+	return result
+}
+
+// LocalDescription returns the description of the custom setting translated
+// for the locale, or the description itself if there isn't a translation.
+func (c Custom) LocalDescription(locale string) string {
+	for _, v := range LocaleCandidates(locale) {
+		if desc, ok := c.Translations[v]; ok && desc != "" {
+			return desc
+		}
+	}
+
+	return c.Description
+}
+
+// Localize has the stack show the text it has for a locale, as picked by
+// LocaleFromEnv or given by a flag. The description and a catalog of
+// replacements for DeployStack's own text are read from
+// messages/<locale>/description.txt and messages/<locale>/catalog.yaml if
+// they exist. It can be called again to switch locales.
+func (s *Stack) Localize(locale string) error {
+	if s.description == nil {
+		desc := s.Config.Description
+		s.description = &desc
+	}
+
+	s.Locale = NormalizeLocale(locale)
+	s.Config.Description = *s.description
+	s.Messages = nil
+
+	if s.Locale == "" || s.Config.PathMessages == "" {
+		return nil
+	}
+
+	dir := filepath.Join(s.Config.Getwd(), s.Config.PathMessages)
+
+	for _, v := range LocaleCandidates(s.Locale) {
+		localeDir := filepath.Join(dir, v)
+		if _, err := os.Stat(localeDir); err != nil {
+			continue
+		}
+
+		if desc, err := os.ReadFile(filepath.Join(localeDir, "description.txt")); err == nil {
+			s.Config.Description = string(desc)
+		}
+
+		content, err := os.ReadFile(filepath.Join(localeDir, catalogFile))
+		if err == nil {
+			messages := map[string]string{}
+			if err := yaml.Unmarshal(content, &messages); err != nil {
+				return fmt.Errorf("could not read %s: %s", filepath.Join(v, catalogFile), err)
+			}
+			s.Messages = messages
+		}
+
+		return nil
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLocaleFromEnv(t *testing.T) {
+	tests := map[string]struct {
+		env  []string
+		want string
+	}{
+		"lang":       {env: []string{"LANG=es_ES.UTF-8"}, want: "es-ES"},
+		"lc_all":     {env: []string{"LANG=es_ES.UTF-8", "LC_ALL=fr_FR"}, want: "fr-FR"},
+		"override":   {env: []string{"LC_ALL=fr_FR", "DEPLOYSTACK_LOCALE=pt-br"}, want: "pt-BR"},
+		"posix":      {env: []string{"LC_ALL=C", "LANG=de_DE@euro"}, want: "de-DE"},
+		"language":   {env: []string{"LANG=ja"}, want: "ja"},
+		"none":       {env: []string{"PATH=/bin"}, want: ""},
+		"only posix": {env: []string{"LANG=C.UTF-8"}, want: ""},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := LocaleFromEnv(tc.env); got != tc.want {
+				t.Fatalf("want '%s' got '%s'", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLocaleCandidates(t *testing.T) {
+	tests := map[string]struct {
+		in   string
+		want []string
+	}{
+		"region":   {in: "pt_BR.UTF-8", want: []string{"pt-BR", "pt_BR", "pt"}},
+		"language": {in: "es", want: []string{"es"}},
+		"none":     {in: "", want: []string{}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := LocaleCandidates(tc.in); !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("want %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCustomLocalDescription(t *testing.T) {
+	c := Custom{
+		Name:         "nodes",
+		Description:  "How many nodes?",
+		Translations: map[string]string{"es": "¿Cuántos nodos?", "pt-BR": "Quantos nós?"},
+	}
+
+	tests := map[string]struct {
+		locale string
+		want   string
+	}{
+		"exact":    {locale: "pt-BR", want: "Quantos nós?"},
+		"language": {locale: "es-MX", want: "¿Cuántos nodos?"},
+		"missing":  {locale: "fr", want: "How many nodes?"},
+		"none":     {locale: "", want: "How many nodes?"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := c.LocalDescription(tc.locale); got != tc.want {
+				t.Fatalf("want '%s' got '%s'", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestStackLocalize(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"messages/es/description.txt":    "Una pila de prueba.",
+		"messages/es/catalog.yaml":       "continue: ¿Continuar?\n",
+		"messages/pt_BR/description.txt": "Uma pilha de teste.",
+		"messages/de/catalog.yaml":       "continue: [broken\n",
+	}
+	for k, v := range files {
+		path := filepath.Join(dir, k)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("could not create %s: %s", path, err)
+		}
+		if err := os.WriteFile(path, []byte(v), 0o644); err != nil {
+			t.Fatalf("could not create %s: %s", path, err)
+		}
+	}
+
+	tests := map[string]struct {
+		locale      string
+		description string
+		messages    map[string]string
+		err         bool
+	}{
+		"language":      {locale: "es_MX.UTF-8", description: "Una pila de prueba.", messages: map[string]string{"continue": "¿Continuar?"}},
+		"posix folder":  {locale: "pt-BR", description: "Uma pilha de teste."},
+		"missing":       {locale: "fr", description: "A test stack."},
+		"none":          {locale: "", description: "A test stack."},
+		"broken":        {locale: "de", err: true},
+		"switched back": {locale: "en", description: "A test stack."},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewStack()
+			s.Config.Description = "A test stack."
+			s.Config.PathMessages = "messages"
+			s.Config.Setwd(dir)
+
+			// Localizing more than once starts from the original each time
+			if err := s.Localize("es"); err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			err := s.Localize(tc.locale)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			if s.Config.Description != tc.description {
+				t.Fatalf("description - want '%s' got '%s'", tc.description, s.Config.Description)
+			}
+			if !reflect.DeepEqual(tc.messages, s.Messages) {
+				t.Fatalf("messages - want %v got %v", tc.messages, s.Messages)
+			}
+		})
+	}
+}
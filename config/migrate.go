@@ -0,0 +1,284 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// CurrentSchemaVersion is the version of the config format this version of
+// DeployStack writes. Configs without a schema_version are version 0.
+const CurrentSchemaVersion = 1
+
+// preferredConfig is where configs live once they have been migrated
+const preferredConfig = ".deploystack/deploystack.yaml"
+
+// configCandidates are the places a config can be, in order of preference
+var configCandidates = []string{
+	preferredConfig,
+	".deploystack/deploystack.json",
+	"deploystack.json",
+}
+
+// migration upgrades a config from the version before it to version to. It
+// returns a warning for every deprecated thing it had to change so authors
+// know to update their files.
+type migration struct {
+	to    int
+	apply func(c *Config) []string
+}
+
+// migrations must stay in order. Add a new one, and bump
+// CurrentSchemaVersion, whenever the format changes in a way older configs
+// need converting for.
+var migrations = []migration{
+	{to: 1, apply: migrateHardSettings},
+}
+
+// migrateHardSettings folds hard_settings into author_settings, which
+// replaced them.
+func migrateHardSettings(c *Config) []string {
+	warnings := []string{}
+
+	if len(c.HardSet) > 0 {
+		warnings = append(warnings, "hard_settings is deprecated, its values have been moved to author_settings")
+	}
+
+	c.convertHardset()
+	c.defaultAuthorSettings()
+
+	return warnings
+}
+
+// Migrate upgrades a config to CurrentSchemaVersion in memory, one version
+// at a time, and returns warnings for anything deprecated it found.
+func (c *Config) Migrate() []string {
+	warnings := []string{}
+
+	if c.SchemaVersion > CurrentSchemaVersion {
+		warnings = append(warnings, fmt.Sprintf("schema_version %d is newer than this version of DeployStack understands (%d), some settings may be ignored", c.SchemaVersion, CurrentSchemaVersion))
+		return warnings
+	}
+
+	for _, v := range migrations {
+		if v.to <= c.SchemaVersion {
+			continue
+		}
+		warnings = append(warnings, v.apply(c)...)
+		c.SchemaVersion = v.to
+	}
+
+	return warnings
+}
+
+// findConfigFile returns the path of the config DeployStack would use in
+// a directory, or an empty string if there is none.
+func findConfigFile(dir string) string {
+	for _, v := range configCandidates {
+		candidate := filepath.Join(dir, v)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// Rewrite migrates the config in a directory to the latest format and writes
+// it back as .deploystack/deploystack.yaml, removing a JSON config it
+// replaced. It returns the path written and any migration warnings, so that
+// stacks can be modernised mechanically.
+func Rewrite(dir string) (string, []string, error) {
+	source := findConfigFile(dir)
+	if source == "" {
+		return "", nil, ErrConfigNotExist
+	}
+
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to find or read config (%s) file: %s", source, err)
+	}
+
+	var c Config
+	header := ""
+	switch filepath.Ext(source) {
+	case ".yaml":
+		c, err = NewConfigYAML(content)
+		header = leadingComments(content)
+	default:
+		c, err = NewConfigJSON(content)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to parse config file: %s", err)
+	}
+
+	warnings := c.Migrate()
+
+	out, err := c.marshalMinimal()
+	if err != nil {
+		return "", warnings, err
+	}
+
+	target := filepath.Join(dir, preferredConfig)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", warnings, fmt.Errorf("could not create config folder: %s", err)
+	}
+
+	if err := os.WriteFile(target, append([]byte(header), out...), 0644); err != nil {
+		return "", warnings, fmt.Errorf("could not write config file: %s", err)
+	}
+
+	if source != target {
+		if err := os.Remove(source); err != nil {
+			return target, warnings, fmt.Errorf("could not remove old config file: %s", err)
+		}
+		rel, _ := filepath.Rel(dir, source)
+		warnings = append(warnings, fmt.Sprintf("%s has been converted to %s", rel, preferredConfig))
+	}
+
+	return target, warnings, nil
+}
+
+// marshalMinimal renders the config as yaml in field order, leaving out
+// anything that is unset so rewritten files stay as short as the originals.
+func (c Config) marshalMinimal() ([]byte, error) {
+	node := yamlv3.Node{}
+	if err := node.Encode(&c); err != nil {
+		return nil, fmt.Errorf("cannot export config: %s", err)
+	}
+
+	pruneNode(&node, reflect.ValueOf(c))
+
+	buf := bytes.Buffer{}
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, fmt.Errorf("cannot export config: %s", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// pruneNode removes the struct fields of v from the node it was encoded to
+// when they are unset: zero values, empty lists and maps, and nil pointers.
+// Pointers that are set are kept even if they point at zero, as are the
+// entries of maps, because there an empty value means something.
+func pruneNode(n *yamlv3.Node, v reflect.Value) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if n.Kind != yamlv3.MappingNode {
+			return
+		}
+		fields := yamlFields(v)
+		content := []*yamlv3.Node{}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			f, ok := fields[n.Content[i].Value]
+			if ok && unset(f) {
+				continue
+			}
+			if ok {
+				pruneNode(n.Content[i+1], f)
+				// A struct with nothing set reads back the same as no struct
+				if f.Kind() == reflect.Struct && len(n.Content[i+1].Content) == 0 {
+					continue
+				}
+			}
+			content = append(content, n.Content[i], n.Content[i+1])
+		}
+		n.Content = content
+	case reflect.Slice, reflect.Array:
+		if n.Kind != yamlv3.SequenceNode {
+			return
+		}
+		for i, item := range n.Content {
+			if i < v.Len() {
+				pruneNode(item, v.Index(i))
+			}
+		}
+	case reflect.Map:
+		if n.Kind != yamlv3.MappingNode || v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			item := v.MapIndex(reflect.ValueOf(n.Content[i].Value).Convert(v.Type().Key()))
+			if item.IsValid() {
+				pruneNode(n.Content[i+1], item)
+			}
+		}
+	}
+}
+
+// unset reports whether a field can be left out of the yaml without changing
+// what is read back in
+func unset(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// yamlFields maps the yaml keys of a struct to its fields
+func yamlFields(v reflect.Value) map[string]reflect.Value {
+	result := map[string]reflect.Value{}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = strings.ToLower(f.Name)
+		}
+		result[name] = v.Field(i)
+	}
+
+	return result
+}
+
+// leadingComments returns the comment block at the top of a yaml file, like
+// a license header, so that a rewrite does not drop it.
+func leadingComments(content []byte) string {
+	sb := strings.Builder{}
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
@@ -0,0 +1,221 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kylelemons/godebug/diff"
+)
+
+func TestConfigMigrate(t *testing.T) {
+	tests := map[string]struct {
+		in       Config
+		want     Config
+		warnings []string
+	}{
+		"unversioned": {
+			in: Config{
+				Title:   "A Stack",
+				HardSet: map[string]string{"basename": "stack"},
+			},
+			want: Config{
+				SchemaVersion:  1,
+				Title:          "A Stack",
+				AuthorSettings: Settings{{Name: "basename", Value: "stack", Type: "string"}},
+			},
+			warnings: []string{
+				"hard_settings is deprecated, its values have been moved to author_settings",
+			},
+		},
+		"unversioned clean": {
+			in: Config{
+				Title:          "A Stack",
+				AuthorSettings: Settings{{Name: "basename", Value: "stack"}},
+			},
+			want: Config{
+				SchemaVersion:  1,
+				Title:          "A Stack",
+				AuthorSettings: Settings{{Name: "basename", Value: "stack", Type: "string"}},
+			},
+			warnings: []string{},
+		},
+		"current": {
+			in:       Config{SchemaVersion: CurrentSchemaVersion, Title: "A Stack"},
+			want:     Config{SchemaVersion: CurrentSchemaVersion, Title: "A Stack"},
+			warnings: []string{},
+		},
+		"future": {
+			in:   Config{SchemaVersion: 99, Title: "A Stack"},
+			want: Config{SchemaVersion: 99, Title: "A Stack"},
+			warnings: []string{
+				"schema_version 99 is newer than this version of DeployStack understands (1), some settings may be ignored",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tc.in
+			warnings := got.Migrate()
+
+			if !reflect.DeepEqual(tc.warnings, warnings) {
+				t.Fatalf("warnings: expected: %v, got: %v", tc.warnings, warnings)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	tests := map[string]struct {
+		file     string
+		content  string
+		want     string
+		warnings []string
+		err      error
+	}{
+		"json at root": {
+			file:    "deploystack.json",
+			content: `{"title": "A Stack", "duration": 5, "collect_project": true, "collect_zone": false, "hard_settings": {"basename": "stack"}}`,
+			want: `schema_version: 1
+title: A Stack
+duration: 5
+collect_project: true
+author_settings:
+  - name: basename
+    value: stack
+    type: string
+`,
+			warnings: []string{
+				"hard_settings is deprecated, its values have been moved to author_settings",
+				"deploystack.json has been converted to .deploystack/deploystack.yaml",
+			},
+		},
+		"json in folder": {
+			file:    ".deploystack/deploystack.json",
+			content: `{"title": "A Stack", "custom_settings": [{"name": "nodes", "default": "3", "type": "number"}]}`,
+			want: `schema_version: 1
+title: A Stack
+custom_settings:
+  - name: nodes
+    default: "3"
+    type: number
+`,
+			warnings: []string{
+				".deploystack/deploystack.json has been converted to .deploystack/deploystack.yaml",
+			},
+		},
+		"yaml keeps header": {
+			file:    ".deploystack/deploystack.yaml",
+			content: "# Copyright 2023 Google LLC\n\ntitle: A Stack\ncollect_region: true\nregion_default: \"false\"\n",
+			want: `# Copyright 2023 Google LLC
+
+schema_version: 1
+title: A Stack
+collect_region: true
+region_default: "false"
+`,
+			warnings: []string{},
+		},
+		"keeps meaningful zeros": {
+			file: ".deploystack/deploystack.yaml",
+			content: `title: A Stack
+custom_settings:
+  - name: nodes
+    default: "1"
+    rule:
+      min: 0
+      max: 5
+profiles:
+  - name: blank
+    defaults:
+      label: ""
+`,
+			want: `schema_version: 1
+title: A Stack
+custom_settings:
+  - name: nodes
+    default: "1"
+    rule:
+      min: 0
+      max: 5
+profiles:
+  - name: blank
+    defaults:
+      label: ""
+`,
+			warnings: []string{},
+		},
+		"no config": {
+			file:    "main.tf",
+			content: "",
+			err:     ErrConfigNotExist,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			source := filepath.Join(dir, tc.file)
+			if err := os.MkdirAll(filepath.Dir(source), 0755); err != nil {
+				t.Fatalf("could not create test folder: %s", err)
+			}
+			if err := os.WriteFile(source, []byte(tc.content), 0644); err != nil {
+				t.Fatalf("could not write test config: %s", err)
+			}
+
+			target, warnings, err := Rewrite(dir)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error: %v, got: %v", tc.err, err)
+			}
+			if tc.err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(tc.warnings, warnings) {
+				t.Fatalf("warnings: expected: %v, got: %v", tc.warnings, warnings)
+			}
+
+			dat, err := os.ReadFile(target)
+			if err != nil {
+				t.Fatalf("could not read rewritten config: %s", err)
+			}
+
+			if tc.want != string(dat) {
+				t.Fatalf("content: %s", diff.Diff(tc.want, string(dat)))
+			}
+
+			if target != source {
+				if _, err := os.Stat(source); !os.IsNotExist(err) {
+					t.Fatalf("expected %s to be removed", source)
+				}
+			}
+
+			// The rewritten file must read back to the same stack.
+			s := NewStack()
+			if _, err := s.findAndReadConfig(dir); err != nil {
+				t.Fatalf("could not read rewritten config: %s", err)
+			}
+		})
+	}
+}
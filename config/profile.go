@@ -0,0 +1,176 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProfileSetting is the name of the setting the chosen profile is recorded
+// in, so Terraform can see which environment it is building.
+const ProfileSetting = "profile"
+
+// ErrProfileNotExist is returned when asked for a profile the config does
+// not define.
+var ErrProfileNotExist = fmt.Errorf("profile does not exist")
+
+// Profile is a named environment, like dev or prod, that overlays the rest
+// of the config when it is chosen.
+type Profile struct {
+	Name           string              `json:"name" yaml:"name"`
+	Description    string              `json:"description,omitempty" yaml:"description,omitempty"`
+	RegionDefault  string              `json:"region_default,omitempty" yaml:"region_default,omitempty"`
+	AuthorSettings Settings            `json:"author_settings,omitempty" yaml:"author_settings,omitempty"`
+	Defaults       map[string]string   `json:"defaults,omitempty" yaml:"defaults,omitempty"`
+	Options        map[string][]string `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+func (p Profile) copy() Profile {
+	settings := p.AuthorSettings
+	p.AuthorSettings = nil
+	for _, v := range settings {
+		p.AuthorSettings = append(p.AuthorSettings, v.copy())
+	}
+	p.Defaults = copyStringMap(p.Defaults)
+	if p.Options != nil {
+		options := p.Options
+		p.Options = make(map[string][]string, len(options))
+		for k, v := range options {
+			p.Options[k] = copyStrings(v)
+		}
+	}
+	return p
+}
+
+// Profiles is a list of Profile
+type Profiles []Profile
+
+// Find returns the profile with the given name, or nil if there isn't one
+func (p Profiles) Find(name string) *Profile {
+	for i, v := range p {
+		if v.Name == name {
+			return &p[i]
+		}
+	}
+	return nil
+}
+
+// Names returns the names of all of the profiles in order
+func (p Profiles) Names() []string {
+	result := []string{}
+	for _, v := range p {
+		result = append(result, v.Name)
+	}
+	return result
+}
+
+// WithProfile returns a copy of the config with the named profile laid over
+// it. Profile author settings replace author settings of the same name,
+// defaults and options replace those of the custom settings they name, and
+// the profile name itself is added as the author setting "profile". The
+// original config is left untouched so a different profile can be chosen
+// later.
+func (c Config) WithProfile(name string) (Config, error) {
+	p := c.Profiles.Find(name)
+	if p == nil {
+		return c, fmt.Errorf("%w: '%s', choose from: %s", ErrProfileNotExist, name, strings.Join(c.Profiles.Names(), ", "))
+	}
+
+	if p.RegionDefault != "" {
+		c.RegionDefault = p.RegionDefault
+	}
+
+	authors := Settings{}
+	for _, v := range c.AuthorSettings {
+		authors.AddComplete(v)
+	}
+	for _, v := range p.AuthorSettings {
+		if v.Type == "" {
+			v.Type = "string"
+		}
+		authors.AddComplete(v)
+	}
+	authors.AddComplete(Setting{Name: ProfileSetting, Value: p.Name, Type: "string"})
+	c.AuthorSettings = authors
+
+	customs := Customs{}
+	for _, v := range c.CustomSettings {
+		if d, ok := p.Defaults[v.Name]; ok {
+			v.Default = d
+		}
+		if o, ok := p.Options[v.Name]; ok {
+			v.Options = o
+		}
+		customs = append(customs, v)
+	}
+	c.CustomSettings = customs
+
+	return c, nil
+}
+
+// lintProfiles reports profiles that refer to custom settings that do not
+// exist, which would otherwise silently do nothing.
+func (c Config) lintProfiles() Issues {
+	issues := Issues{}
+
+	customNames := []string{}
+	for _, v := range c.CustomSettings {
+		customNames = append(customNames, v.Name)
+	}
+
+	seen := map[string]bool{}
+	for i, p := range c.Profiles {
+		path := fmt.Sprintf("profiles[%d]", i)
+
+		if p.Name == "" {
+			issues = append(issues, Issue{Path: path + ".name", Key: "name", Message: fmt.Sprintf("%s.name: profiles must have a name", path)})
+		}
+		if seen[p.Name] {
+			issues = append(issues, Issue{Path: path + ".name", Key: "name", Message: fmt.Sprintf("%s.name: profile '%s' is defined more than once", path, p.Name)})
+		}
+		seen[p.Name] = true
+
+		refs := map[string][]string{"defaults": {}, "options": {}}
+		for k := range p.Defaults {
+			refs["defaults"] = append(refs["defaults"], k)
+		}
+		for k := range p.Options {
+			refs["options"] = append(refs["options"], k)
+		}
+
+		for _, field := range []string{"defaults", "options"} {
+			sort.Strings(refs[field])
+			for _, name := range refs[field] {
+				if c.CustomSettings.Get(name).Name != "" {
+					continue
+				}
+				issues = append(issues, Issue{
+					Path:       joinPath(path+"."+field, name),
+					Key:        name,
+					Message:    fmt.Sprintf("%s.%s: unknown custom setting '%s'", path, field, name),
+					Suggestion: suggest(name, customNames),
+				})
+			}
+		}
+	}
+
+	if c.ProfileDefault != "" && c.Profiles.Find(c.ProfileDefault) == nil {
+		issues = append(issues, Issue{Path: "profile_default", Key: "profile_default", Message: fmt.Sprintf("profile_default: unknown profile '%s'", c.ProfileDefault)})
+	}
+
+	return issues
+}
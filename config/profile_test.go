@@ -0,0 +1,185 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func testProfileConfig() Config {
+	return Config{
+		Title:         "A Stack",
+		RegionDefault: "us-central1",
+		AuthorSettings: Settings{
+			{Name: "basename", Value: "stack", Type: "string"},
+			{Name: "machine_type", Value: "e2-small", Type: "string"},
+		},
+		CustomSettings: Customs{
+			{Name: "nodes", Default: "1"},
+			{Name: "tier", Options: []string{"db-f1-micro", "db-g1-small"}},
+		},
+		Profiles: Profiles{
+			{Name: "dev", Description: "Small and cheap"},
+			{
+				Name:          "prod",
+				RegionDefault: "us-east1",
+				AuthorSettings: Settings{
+					{Name: "machine_type", Value: "n2-standard-4"},
+					{Name: "enable_backups", Value: "true", Type: "bool"},
+				},
+				Defaults: map[string]string{"nodes": "5"},
+				Options:  map[string][]string{"tier": {"db-custom-4-15360"}},
+			},
+		},
+	}
+}
+
+func TestConfigWithProfile(t *testing.T) {
+	tests := map[string]struct {
+		profile string
+		want    func(c Config) Config
+		err     error
+	}{
+		"dev": {
+			profile: "dev",
+			want: func(c Config) Config {
+				c.AuthorSettings = Settings{
+					{Name: "basename", Value: "stack", Type: "string"},
+					{Name: "machine_type", Value: "e2-small", Type: "string"},
+					{Name: "profile", Value: "dev", Type: "string"},
+				}
+				return c
+			},
+		},
+		"prod": {
+			profile: "prod",
+			want: func(c Config) Config {
+				c.RegionDefault = "us-east1"
+				c.AuthorSettings = Settings{
+					{Name: "basename", Value: "stack", Type: "string"},
+					{Name: "machine_type", Value: "n2-standard-4", Type: "string"},
+					{Name: "enable_backups", Value: "true", Type: "bool"},
+					{Name: "profile", Value: "prod", Type: "string"},
+				}
+				c.CustomSettings = Customs{
+					{Name: "nodes", Default: "5"},
+					{Name: "tier", Options: []string{"db-custom-4-15360"}},
+				}
+				return c
+			},
+		},
+		"unknown": {
+			profile: "qa",
+			err:     ErrProfileNotExist,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := testProfileConfig()
+
+			got, err := c.WithProfile(tc.profile)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error: %v, got: %v", tc.err, err)
+			}
+			if tc.err != nil {
+				return
+			}
+
+			want := tc.want(testProfileConfig())
+			if !reflect.DeepEqual(want, got) {
+				t.Fatalf("compare failed: %v", deep.Equal(want, got))
+			}
+
+			if !reflect.DeepEqual(testProfileConfig(), c) {
+				t.Fatalf("original config was changed: %v", deep.Equal(testProfileConfig(), c))
+			}
+		})
+	}
+}
+
+func TestStackApplyProfile(t *testing.T) {
+	s := NewStack()
+	s.Config = testProfileConfig()
+
+	if err := s.ApplyProfile("prod"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	if got := s.Config.AuthorSettings.Find(ProfileSetting); got == nil || got.Value != "prod" {
+		t.Fatalf("expected the profile to be recorded as an author setting, got: %v", got)
+	}
+
+	if err := s.ApplyProfile("qa"); !errors.Is(err, ErrProfileNotExist) {
+		t.Fatalf("expected error: %v, got: %v", ErrProfileNotExist, err)
+	}
+}
+
+func TestStackApplyProfileReplaces(t *testing.T) {
+	s := NewStack()
+	s.Config = testProfileConfig()
+	s.Settings = Settings{{Name: ProfileSetting, Value: "prod", Type: "string", Source: SourceEnv}}
+
+	if err := s.ApplyProfile("prod"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	if err := s.ApplyProfile("dev"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	want, err := testProfileConfig().WithProfile("dev")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(want, s.Config) {
+		t.Fatalf("expected only the last profile to apply: %v", deep.Equal(want, s.Config))
+	}
+
+	if s.Overridden(ProfileSetting) {
+		t.Fatalf("expected the profile from the environment to be replaced")
+	}
+}
+
+func TestLintProfiles(t *testing.T) {
+	c := testProfileConfig()
+	c.ProfileDefault = "staging"
+	c.Profiles = append(c.Profiles,
+		Profile{Name: "dev"},
+		Profile{Defaults: map[string]string{"node": "2"}, Options: map[string][]string{"tiers": {"a"}}},
+	)
+
+	want := []string{
+		"profiles[2].name: profile 'dev' is defined more than once",
+		"profiles[3].name: profiles must have a name",
+		"profiles[3].defaults: unknown custom setting 'node', did you mean 'nodes'?",
+		"profiles[3].options: unknown custom setting 'tiers', did you mean 'tier'?",
+		"profile_default: unknown profile 'staging'",
+	}
+
+	got := []string{}
+	for _, v := range c.lintProfiles() {
+		got = append(got, v.String())
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want \n%v\ngot \n%v", want, got)
+	}
+}
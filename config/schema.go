@@ -0,0 +1,98 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// SchemaID is the identifier of the published JSON Schema for DeployStack
+// configs
+const SchemaID = "https://raw.githubusercontent.com/GoogleCloudPlatform/deploystack/main/config/deploystack.schema.json"
+
+//go:embed deploystack.schema.json
+var publishedSchema []byte
+
+// PublishedSchema returns the JSON Schema that ships with this package. It
+// should always match what Schema generates.
+func PublishedSchema() []byte {
+	return publishedSchema
+}
+
+// Schema generates a JSON Schema for Config from the struct tags of the
+// config types, so that editors and CI can validate deploystack configs.
+func Schema() ([]byte, error) {
+	root := schemaFor(reflect.TypeOf(Config{}))
+	root["$schema"] = "http://json-schema.org/draft-07/schema#"
+	root["$id"] = SchemaID
+	root["title"] = "DeployStack config"
+
+	out, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate schema: %s", err)
+	}
+
+	return append(out, '\n'), nil
+}
+
+func schemaFor(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": schemaFor(t.Elem()),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": schemaFor(t.Elem()),
+		}
+	case reflect.Struct:
+		props := map[string]interface{}{}
+
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := tagName(f, "json")
+			if name == "" {
+				continue
+			}
+			props[name] = schemaFor(f.Type)
+		}
+
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           props,
+			"additionalProperties": false,
+		}
+	}
+
+	return map[string]interface{}{}
+}
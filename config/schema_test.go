@@ -0,0 +1,88 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/kylelemons/godebug/diff"
+)
+
+var updateSchema = flag.Bool("update-schema", false, "rewrite deploystack.schema.json from the Config struct")
+
+func TestSchemaPublished(t *testing.T) {
+	got, err := Schema()
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	if *updateSchema {
+		if err := os.WriteFile("deploystack.schema.json", got, 0o644); err != nil {
+			t.Fatalf("could not write schema: %s", err)
+		}
+		return
+	}
+
+	if string(got) != string(PublishedSchema()) {
+		t.Log(diff.Diff(string(PublishedSchema()), string(got)))
+		t.Fatalf("deploystack.schema.json is out of date, run: go test ./config -run TestSchemaPublished -update-schema")
+	}
+}
+
+func TestSchema(t *testing.T) {
+	tests := map[string]struct {
+		path []string
+		want string
+	}{
+		"string":       {path: []string{"title"}, want: "string"},
+		"bool":         {path: []string{"collect_project"}, want: "boolean"},
+		"int":          {path: []string{"duration"}, want: "integer"},
+		"map":          {path: []string{"hard_settings"}, want: "object"},
+		"slice":        {path: []string{"custom_settings"}, want: "array"},
+		"nested":       {path: []string{"projects", "items"}, want: "array"},
+		"nested field": {path: []string{"projects", "allow_duplicates"}, want: "boolean"},
+	}
+
+	raw, err := Schema()
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	schema := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		t.Fatalf("schema is not valid json: %s", err)
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			current := schema
+			for _, v := range tc.path {
+				props := current["properties"].(map[string]interface{})
+				current = props[v].(map[string]interface{})
+			}
+
+			if current["type"] != tc.want {
+				t.Fatalf("expected: %s, got: %v", tc.want, current["type"])
+			}
+		})
+	}
+
+	if schema["additionalProperties"] != false {
+		t.Fatalf("expected schema to forbid unknown keys")
+	}
+}
@@ -15,11 +15,11 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 )
 
@@ -27,6 +27,22 @@ import (
 type Stack struct {
 	Settings Settings
 	Config   Config
+	// Warnings are raised when reading a config that uses deprecated
+	// features, so authors know to migrate it.
+	Warnings []string
+	// TFvarsFormat is the format settings are written out for Terraform in,
+	// either TFvarsHCL, the default, or TFvarsJSON.
+	TFvarsFormat string
+	// Locale is the locale text is shown in, set by Localize
+	Locale string
+	// Messages replace DeployStack's own text for the locale, keyed by
+	// message id, as read from the stack's catalog.yaml.
+	Messages map[string]string
+
+	// description is the stack description before it was localized
+	description *string
+	// profileBase is the config before any profile was applied
+	profileBase *Config
 }
 
 // NewStack returns an initialized Stack
@@ -39,22 +55,7 @@ func NewStack() Stack {
 func (s *Stack) findAndReadConfig(path string) (Config, error) {
 	config := Config{}
 
-	candidates := []string{
-		".deploystack/deploystack.yaml",
-		".deploystack/deploystack.json",
-		"deploystack.json",
-	}
-
-	configPath := ""
-	for _, v := range candidates {
-		candidate := filepath.Join(path, v)
-		if _, err := os.Stat(candidate); err == nil {
-			configPath = candidate
-			break
-		}
-
-	}
-
+	configPath := findConfigFile(path)
 	if configPath == "" {
 		return config, ErrConfigNotExist
 	}
@@ -117,33 +118,14 @@ func (s *Stack) findTFFolder(path string) (string, error) {
 		return s.Config.PathTerraform, nil
 	}
 
-	mains := []string{}
-
-	err := filepath.Walk(path, func(walkpath string, info os.FileInfo, err error) error {
-
-		if info == nil {
-			return fmt.Errorf("info is nil: walkpath: %s err: %s", walkpath, err)
-		}
-
-		if info.Name() == "main.tf" {
-			dir := filepath.Dir(walkpath)
-			mains = append(mains, dir)
-			return err
-		}
-		return nil
-	})
-
+	d, err := Discover(path)
 	if err != nil {
 		return "", fmt.Errorf("findTFFolder: could not find a terraform folder:, %s", err)
 	}
 
-	// I want the top most main file here. And that should be the shortest
-	sort.Slice(mains, func(i, j int) bool {
-		return len(mains[i]) < len(mains[j])
-	})
-
-	if len(mains) > 0 {
-		return filepath.Rel(path, mains[0])
+	// I want the top most main file here. Discover puts the shallowest first
+	if len(d.TerraformRoots) > 0 {
+		return filepath.Rel(path, d.TerraformRoots[0])
 	}
 
 	return "", nil
@@ -177,7 +159,10 @@ func (s *Stack) FindAndRead(path string, required bool) error {
 		errs = append(errs, err)
 	}
 
-	s.Config.convertHardset()
+	s.Warnings = s.Config.Migrate()
+	if s.Config.TFvarsFormat != "" {
+		s.TFvarsFormat = s.Config.TFvarsFormat
+	}
 	s.Config.defaultAuthorSettings()
 
 	if required && len(errs) > 0 {
@@ -187,6 +172,39 @@ func (s *Stack) FindAndRead(path string, required bool) error {
 	return nil
 }
 
+// ApplyProfile lays the named profile over the stack's config. It is how a
+// profile chosen ahead of time, by flag for instance, is applied. Profiles
+// are always laid over the config as it was before any profile, so a
+// profile given by flag replaces one from the environment rather than
+// inheriting its settings.
+func (s *Stack) ApplyProfile(name string) error {
+	base := s.Config
+	if s.profileBase != nil {
+		base = *s.profileBase
+	}
+
+	c, err := base.WithProfile(name)
+	if err != nil {
+		return err
+	}
+
+	if s.profileBase == nil {
+		b := s.Config.Copy()
+		s.profileBase = &b
+	}
+
+	// The description is localized on its own, and may have been since
+	c.Description = s.Config.Description
+	s.Config = c
+
+	// A profile from the environment would otherwise win over this one
+	if set := s.Settings.Find(ProfileSetting); set != nil && set.Value != name {
+		s.DeleteSetting(ProfileSetting)
+	}
+
+	return nil
+}
+
 // FindAndReadRequired finds and reads in a Config from a json file.
 func (s *Stack) FindAndReadRequired(path string) error {
 	return s.FindAndRead(path, true)
@@ -197,6 +215,22 @@ func (s *Stack) AddSetting(key, value string) {
 	s.Settings.Add(key, value)
 }
 
+// AddSettingSource stores a setting key/value pair, recording where the value
+// came from.
+func (s *Stack) AddSettingSource(key, value, source string) {
+	s.Settings.AddSource(key, value, source)
+}
+
+// SetSettingSource records where the value of an existing setting came from
+func (s *Stack) SetSettingSource(key, source string) {
+	set := s.Settings.Find(key)
+	if set == nil {
+		return
+	}
+	set.Source = source
+	s.Settings.Replace(*set)
+}
+
 // AddSettingComplete passes a completely intact setting to the underlying
 // setting structure
 func (s *Stack) AddSettingComplete(set Setting) {
@@ -228,44 +262,137 @@ func (s *Stack) DeleteSetting(key string) {
 func (s Stack) Terraform() string {
 	result := strings.Builder{}
 
-	s.Settings.Sort()
+	for _, v := range s.tfvarsSettings() {
+		result.WriteString(v.TFVars())
+	}
 
-	for _, v := range s.Settings {
-		if v.Name == "" {
-			continue
-		}
-		label := v.TFvarsName()
+	return result.String()
+}
 
-		if label == "project_name" {
-			continue
-		}
+// TerraformFile exports the settings to a file Terraform can read. Files
+// ending in .json are written in the terraform.tfvars.json format, everything
+// else as HCL.
+func (s Stack) TerraformFile(filename string) error {
+	content := s.Terraform()
 
-		if label == "stack_name" {
-			continue
+	if strings.HasSuffix(filename, ".json") {
+		var err error
+		if content, err = s.TerraformJSON(); err != nil {
+			return err
 		}
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err = f.WriteString(content); err != nil {
+		return err
+	}
+
+	return nil
+}
 
-		if len(v.Value) == 0 && len(v.List) == 0 && v.Map == nil {
+const (
+	// SecretStoreEnv delivers secrets to Terraform as TF_VAR_ environment
+	// variables
+	SecretStoreEnv = "env"
+	// SecretStoreSecretManager stores secrets in Secret Manager and passes
+	// Terraform the name of the secret version instead of the value
+	SecretStoreSecretManager = "secret_manager"
+)
+
+// TerraformEnv returns the secret settings as shell exports of TF_VAR_
+// environment variables, which Terraform reads in place of tfvars entries.
+func (s Stack) TerraformEnv() string {
+	result := strings.Builder{}
+
+	sets := make(Settings, len(s.Settings))
+	copy(sets, s.Settings)
+	sets.Sort()
+
+	for _, v := range sets {
+		if !v.Secret {
 			continue
 		}
 
-		result.WriteString(v.TFVars())
+		value := v.Value
+		if v.Type != "string" && v.Type != "" {
+			value = v.TFvarsValue()
+		}
 
+		quoted := strings.ReplaceAll(value, "'", `'\''`)
+		result.WriteString(fmt.Sprintf("export TF_VAR_%s='%s'\n", v.TFvarsName(), quoted))
 	}
 
 	return result.String()
 }
 
-// TerraformFile exports TFVars format to input file.
-func (s Stack) TerraformFile(filename string) error {
-	f, err := os.Create(filename)
-	if err != nil {
+// TerraformEnvFile exports the secret settings to a file that can be sourced
+// by a shell before running Terraform. The file is only readable by the
+// current user, and is removed if there are no secrets to write.
+func (s Stack) TerraformEnvFile(filename string) error {
+	env := s.TerraformEnv()
+
+	// Remove whatever is there so that permissions from an older file are not
+	// carried over
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
 		return err
 	}
-	defer f.Close()
 
-	if _, err = f.WriteString(s.Terraform()); err != nil {
+	if env == "" {
+		return nil
+	}
+
+	return os.WriteFile(filename, []byte(env), 0o600)
+}
+
+// SettingSummary describes a setting in the summary file
+type SettingSummary struct {
+	Name   string      `json:"name"`
+	Value  interface{} `json:"value"`
+	Type   string      `json:"type,omitempty"`
+	Source string      `json:"source,omitempty"`
+	Secret bool        `json:"secret,omitempty"`
+}
+
+const redactedValue = "********"
+
+// Summary describes every setting the stack collected, including the ones not
+// written for Terraform, along with where its value came from. Secret values
+// are redacted.
+func (s Stack) Summary() []SettingSummary {
+	sets := make(Settings, len(s.Settings))
+	copy(sets, s.Settings)
+	sets.Sort()
+
+	result := []SettingSummary{}
+	for _, v := range sets {
+		summary := SettingSummary{
+			Name:   v.Name,
+			Value:  v.Native(),
+			Type:   v.Type,
+			Source: v.Source,
+			Secret: v.Secret,
+		}
+		if v.Secret {
+			summary.Value = redactedValue
+		}
+		result = append(result, summary)
+	}
+
+	return result
+}
+
+// SummaryFile writes the Summary to a json file, so that where each value
+// came from can be checked after the fact.
+func (s Stack) SummaryFile(filename string) error {
+	dat, err := json.MarshalIndent(s.Summary(), "", "  ")
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return os.WriteFile(filename, append(dat, '\n'), 0o644)
 }
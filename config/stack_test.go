@@ -130,23 +130,27 @@ func TestFindAndReadRequired(t *testing.T) {
 		terraform string
 		scripts   string
 		messages  string
+		warnings  int
 	}{
 		"Original": {
 			pwd:       "original",
 			terraform: ".",
 			scripts:   "scripts",
-			messages:  "messages"},
+			messages:  "messages",
+			warnings:  1},
 
 		"Perferred": {
 			pwd:       "preferred",
 			terraform: "terraform",
 			scripts:   ".deploystack/scripts",
-			messages:  ".deploystack/messages"},
+			messages:  ".deploystack/messages",
+			warnings:  1},
 		"PerferredYAML": {
 			pwd:       "preferredyaml",
 			terraform: "terraform",
 			scripts:   ".deploystack/scripts",
-			messages:  ".deploystack/messages"},
+			messages:  ".deploystack/messages",
+			warnings:  1},
 	}
 
 	for name, tc := range tests {
@@ -170,6 +174,10 @@ func TestFindAndReadRequired(t *testing.T) {
 			if !reflect.DeepEqual(tc.messages, s.Config.PathMessages) {
 				t.Errorf("expected: %s, got: %s", tc.messages, s.Config.PathMessages)
 			}
+
+			if len(s.Warnings) != tc.warnings {
+				t.Errorf("expected %d warnings, got: %v", tc.warnings, s.Warnings)
+			}
 		})
 	}
 }
@@ -183,7 +191,7 @@ func TestStackTFvars(t *testing.T) {
 			in: Settings{
 				Setting{Name: "project", Value: "testproject", Type: "string"},
 				Setting{Name: "boolean", Value: "true", Type: "string"},
-				Setting{Name: "set", Value: "[item1,item2]", Type: "string"},
+				Setting{Name: "set", Value: "[item1,item2]"},
 			},
 			want: `boolean="true"
 project="testproject"
@@ -195,7 +203,7 @@ set=["item1","item2"]
 				Setting{Name: "project", Value: "testproject", Type: "string"},
 				Setting{Name: "boolean", Value: "true", Type: "boolean"},
 				Setting{Name: "number", Value: "3", Type: "number"},
-				Setting{Name: "set", Value: "[item1,item2]", Type: "string"},
+				Setting{Name: "set", Value: "[item1,item2]"},
 			},
 			want: `boolean=true
 number=3
@@ -216,6 +224,14 @@ number=3
 object={email="item2@example.com",nickname="item2"}
 project="testproject"
 set=["item1","item2"]
+`,
+		},
+		"secrets": {
+			in: Settings{
+				Setting{Name: "project", Value: "testproject", Type: "string"},
+				Setting{Name: "db_password", Value: "hunter2", Type: "string", Secret: true},
+			},
+			want: `project="testproject"
 `,
 		},
 		"ingnore fields": {
@@ -288,6 +304,73 @@ func TestTerraformFile(t *testing.T) {
 	}
 }
 
+func TestStackTerraformEnv(t *testing.T) {
+	tests := map[string]struct {
+		in   Settings
+		want string
+	}{
+		"none": {
+			in: Settings{
+				Setting{Name: "project", Value: "testproject", Type: "string"},
+			},
+			want: "",
+		},
+		"secrets": {
+			in: Settings{
+				Setting{Name: "project", Value: "testproject", Type: "string"},
+				Setting{Name: "db_password", Value: "it's a secret", Type: "string", Secret: true},
+				Setting{Name: "api_keys", List: []string{"a", "b"}, Type: "list", Secret: true},
+			},
+			want: `export TF_VAR_api_keys='["a","b"]'
+export TF_VAR_db_password='it'\''s a secret'
+`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewStack()
+			s.Settings = tc.in
+			before := append(Settings{}, tc.in...)
+
+			got := s.TerraformEnv()
+			if got != tc.want {
+				fmt.Println(diff.Diff(got, tc.want))
+				t.Fatalf("Output Text different than expected")
+			}
+
+			if !reflect.DeepEqual(before, s.Settings) {
+				t.Fatalf("expected the settings to keep their order, got: %v", s.Settings)
+			}
+
+			testfile := filepath.Join(t.TempDir(), "terraform.secrets.env")
+			if err := os.WriteFile(testfile, []byte("stale"), 0o644); err != nil {
+				t.Fatalf("could not write test file: %s", err)
+			}
+
+			if err := s.TerraformEnvFile(testfile); err != nil {
+				t.Fatalf("expected no error got: %s", err)
+			}
+
+			info, err := os.Stat(testfile)
+			if tc.want == "" {
+				if !os.IsNotExist(err) {
+					t.Fatalf("expected file to be removed, got: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected file to exist, got: %s", err)
+			}
+
+			if info.Mode().Perm() != 0o600 {
+				t.Fatalf("permissions want '%o' got '%o'", 0o600, info.Mode().Perm())
+			}
+		})
+	}
+}
+
 func TestStackAddSettings(t *testing.T) {
 	tests := map[string]struct {
 		in []struct {
@@ -395,3 +478,82 @@ func TestStackGetSettings(t *testing.T) {
 		})
 	}
 }
+
+func TestStackAddSettingSource(t *testing.T) {
+	s := NewStack()
+	s.AddSettingSource("region", "us-east1", SourceDefault)
+	s.AddSettingSource("region", "europe-west1", SourceUser)
+	s.AddSetting("nodes", "3")
+	s.SetSettingSource("nodes", SourceAnswers)
+	s.SetSettingSource("missing", SourceAnswers)
+
+	want := Settings{
+		{Name: "region", Value: "europe-west1", Type: "string", Source: SourceUser},
+		{Name: "nodes", Value: "3", Type: "string", Source: SourceAnswers},
+	}
+
+	if !reflect.DeepEqual(want, s.Settings) {
+		t.Fatalf("expected: %+v, got: %+v", want, s.Settings)
+	}
+}
+
+func TestStackSummaryFile(t *testing.T) {
+	s := NewStack()
+	s.Settings = Settings{
+		{Name: "region", Value: "us-east1", Type: "string", Source: SourceUser},
+		{Name: "nodes", Value: "3", Type: "number", Source: SourceDefault},
+		{Name: "db_password", Value: "hunter2", Type: "string", Secret: true, Source: SourceEnv},
+		{Name: "zones", List: []string{"a", "b"}, Type: "list", Source: SourcePrevious},
+	}
+
+	want := `[
+  {
+    "name": "db_password",
+    "value": "********",
+    "type": "string",
+    "source": "env",
+    "secret": true
+  },
+  {
+    "name": "nodes",
+    "value": 3,
+    "type": "number",
+    "source": "default"
+  },
+  {
+    "name": "region",
+    "value": "us-east1",
+    "type": "string",
+    "source": "user"
+  },
+  {
+    "name": "zones",
+    "value": [
+      "a",
+      "b"
+    ],
+    "type": "list",
+    "source": "previous"
+  }
+]
+`
+
+	testfile := filepath.Join(t.TempDir(), "deploystack.settings.json")
+	if err := s.SummaryFile(testfile); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	dat, err := os.ReadFile(testfile)
+	if err != nil {
+		t.Fatalf("could not read %s: %s", testfile, err)
+	}
+
+	if want != string(dat) {
+		fmt.Println(diff.Diff(want, string(dat)))
+		t.Fatalf("summary file wasn't the same")
+	}
+
+	if s.Settings[0].Name != "region" {
+		t.Fatalf("writing the summary reordered the stack's settings")
+	}
+}
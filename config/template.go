@@ -0,0 +1,159 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const successFile = "success.txt"
+
+const randomChars = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+var templateFuncs = template.FuncMap{
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"trim":    strings.TrimSpace,
+	"replace": templateReplace,
+	"trunc":   templateTrunc,
+	"random":  templateRandom,
+}
+
+// IsTemplate reports whether a default value needs to be rendered with
+// RenderDefault before it is used
+func IsTemplate(text string) bool {
+	return strings.Contains(text, "{{")
+}
+
+// RenderDefault renders a templated default value against the settings
+// collected so far. Settings are referred to by name, like
+// {{ .project_id }}-assets, and missing settings render as empty strings.
+// The functions lower, upper, trim, replace, trunc and random are available:
+//
+//	{{ .basename | lower | trunc 20 }}-{{ random 5 }}
+func RenderDefault(text string, s Settings) (string, error) {
+	if !IsTemplate(text) {
+		return text, nil
+	}
+
+	tmpl, err := parseDefault(text)
+	if err != nil {
+		return "", err
+	}
+
+	data := map[string]string{}
+	for _, v := range s {
+		data[v.Name] = v.Value
+	}
+
+	sb := strings.Builder{}
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("could not render default (%s): %s", text, err)
+	}
+
+	return sb.String(), nil
+}
+
+func parseDefault(text string) (*template.Template, error) {
+	tmpl, err := template.New("default").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse default (%s): %s", text, err)
+	}
+	return tmpl, nil
+}
+
+// RenderSuccess renders the message shown once a stack is installed. Like
+// defaults, settings are referred to by name, and the outputs of the
+// Terraform are available through the output function:
+//
+//	Your app is running at {{ output "url" }} in {{ .project_id }}
+//
+// Missing settings and outputs render as empty strings.
+func RenderSuccess(text string, s Settings, outputs map[string]string) (string, error) {
+	output := func(name string) string {
+		return outputs[name]
+	}
+
+	tmpl, err := template.New("success").Funcs(templateFuncs).Funcs(template.FuncMap{"output": output}).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("could not parse success message: %s", err)
+	}
+
+	data := map[string]string{}
+	for _, v := range s {
+		data[v.Name] = v.Answer()
+	}
+
+	sb := strings.Builder{}
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("could not render success message: %s", err)
+	}
+
+	return sb.String(), nil
+}
+
+// SuccessMessage renders the stack's messages/success.txt, or the one for
+// its locale if there is one, against its settings and the outputs given.
+// Stacks without one get an empty message.
+func (s Stack) SuccessMessage(outputs map[string]string) (string, error) {
+	if s.Config.PathMessages == "" {
+		return "", nil
+	}
+
+	dir := filepath.Join(s.Config.Getwd(), s.Config.PathMessages)
+
+	candidates := []string{}
+	if s.Locale != "" {
+		for _, v := range LocaleCandidates(s.Locale) {
+			candidates = append(candidates, filepath.Join(dir, v, successFile))
+		}
+	}
+	candidates = append(candidates, filepath.Join(dir, successFile))
+
+	for _, v := range candidates {
+		content, err := os.ReadFile(v)
+		if err != nil {
+			continue
+		}
+		return RenderSuccess(string(content), s.Settings, outputs)
+	}
+
+	return "", nil
+}
+
+func templateReplace(old, new, s string) string {
+	return strings.ReplaceAll(s, old, new)
+}
+
+func templateTrunc(n int, s string) string {
+	r := []rune(s)
+	if n < 0 || len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+func templateRandom(n int) string {
+	sb := strings.Builder{}
+	for i := 0; i < n; i++ {
+		sb.WriteByte(randomChars[rand.Intn(len(randomChars))])
+	}
+	return sb.String()
+}
@@ -0,0 +1,169 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestRenderDefault(t *testing.T) {
+	settings := Settings{
+		Setting{Name: "project_id", Value: "ds-tester"},
+		Setting{Name: "region", Value: "us-central1"},
+		Setting{Name: "basename", Value: "MyApplicationWithALongName"},
+	}
+
+	tests := map[string]struct {
+		in      string
+		want    string
+		pattern string
+		err     bool
+	}{
+		"static":    {in: "assets", want: "assets"},
+		"setting":   {in: "{{ .project_id }}-assets", want: "ds-tester-assets"},
+		"two":       {in: "{{ .basename }}-{{ .region }}", want: "MyApplicationWithALongName-us-central1"},
+		"missing":   {in: "{{ .zone }}-disk", want: "-disk"},
+		"lower":     {in: "{{ .basename | lower }}", want: "myapplicationwithalongname"},
+		"upper":     {in: "{{ upper .region }}", want: "US-CENTRAL1"},
+		"trunc":     {in: "{{ .basename | lower | trunc 5 }}", want: "myapp"},
+		"trunc big": {in: "{{ .region | trunc 50 }}", want: "us-central1"},
+		"replace":   {in: `{{ .region | replace "-" "_" }}`, want: "us_central1"},
+		"random":    {in: "{{ .project_id }}-{{ random 5 }}", pattern: "^ds-tester-[a-z0-9]{5}$"},
+		"bad":       {in: "{{ .project_id }", err: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := RenderDefault(tc.in, settings)
+
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			if tc.pattern != "" {
+				if !regexp.MustCompile(tc.pattern).MatchString(got) {
+					t.Fatalf("want match for '%s' got '%s'", tc.pattern, got)
+				}
+				return
+			}
+
+			if tc.want != got {
+				t.Fatalf("want '%s' got '%s'", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRenderSuccess(t *testing.T) {
+	settings := Settings{
+		Setting{Name: "project_id", Value: "ds-tester"},
+		Setting{Name: "regions", Type: "list", List: []string{"us-central1", "us-east1"}},
+	}
+	outputs := map[string]string{"url": "https://app.example.com"}
+
+	tests := map[string]struct {
+		in   string
+		want string
+		err  bool
+	}{
+		"static":   {in: "All done.", want: "All done."},
+		"output":   {in: `Visit {{ output "url" }}`, want: "Visit https://app.example.com"},
+		"setting":  {in: `{{ .project_id }}: {{ output "url" }}`, want: "ds-tester: https://app.example.com"},
+		"list":     {in: "{{ .regions }}", want: "us-central1,us-east1"},
+		"missing":  {in: `[{{ output "password" }}{{ .zone }}]`, want: "[]"},
+		"function": {in: `{{ output "url" | upper }}`, want: "HTTPS://APP.EXAMPLE.COM"},
+		"bad":      {in: `{{ output "url" }`, err: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := RenderSuccess(tc.in, settings, outputs)
+
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			if tc.want != got {
+				t.Fatalf("want '%s' got '%s'", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestStackSuccessMessage(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"messages/success.txt":    `Your app is at {{ output "url" }}`,
+		"messages/es/success.txt": `Tu aplicación está en {{ output "url" }}`,
+	}
+	for k, v := range files {
+		path := filepath.Join(dir, k)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("could not create %s: %s", path, err)
+		}
+		if err := os.WriteFile(path, []byte(v), 0o644); err != nil {
+			t.Fatalf("could not create %s: %s", path, err)
+		}
+	}
+
+	outputs := map[string]string{"url": "https://app.example.com"}
+
+	tests := map[string]struct {
+		messages string
+		locale   string
+		want     string
+	}{
+		"default":  {messages: "messages", want: "Your app is at https://app.example.com"},
+		"locale":   {messages: "messages", locale: "es-MX", want: "Tu aplicación está en https://app.example.com"},
+		"fallback": {messages: "messages", locale: "fr", want: "Your app is at https://app.example.com"},
+		"none":     {messages: "other", want: ""},
+		"unset":    {want: ""},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewStack()
+			s.Config.PathMessages = tc.messages
+			s.Config.Setwd(dir)
+			s.Locale = NormalizeLocale(tc.locale)
+
+			got, err := s.SuccessMessage(outputs)
+			if err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			if tc.want != got {
+				t.Fatalf("want '%s' got '%s'", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,516 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+const (
+	// TFvarsHCL writes settings for Terraform as terraform.tfvars
+	TFvarsHCL = "hcl"
+	// TFvarsJSON writes settings for Terraform as terraform.tfvars.json
+	TFvarsJSON = "json"
+)
+
+// Native returns the value of the setting as the Go value Terraform should
+// see: a string, a json.Number, a bool, a []interface{} or a
+// map[string]interface{}. Lists and maps can be nested by giving them a JSON
+// value. Numbers and bools that don't parse are left as strings so they are
+// still written safely. Strings stay strings even if they look like a list;
+// only untyped settings from before types existed are guessed at.
+func (s Setting) Native() interface{} {
+	kind := s.Type
+
+	switch {
+	case len(s.List) > 0 && tfvarsBaseType(kind) != "list":
+		kind = "list"
+	case strings.TrimSpace(kind) == "" && isBracketed(s.Value, "[", "]"):
+		// Untyped settings used a bracketed string as a workaround for lists
+		kind = "list"
+	}
+
+	switch tfvarsBaseType(kind) {
+	case "list":
+		if len(s.List) == 0 {
+			return nativeValue(kind, s.Value)
+		}
+		result := []interface{}{}
+		for _, v := range s.List {
+			result = append(result, nativeValue(elementType(kind), v))
+		}
+		return result
+	case "map":
+		if len(s.Map) == 0 {
+			return nativeValue(kind, s.Value)
+		}
+		result := map[string]interface{}{}
+		for k, v := range s.Map {
+			result[k] = nativeValue(elementType(kind), v)
+		}
+		return result
+	}
+
+	return nativeValue(kind, s.Value)
+}
+
+// nativeValue converts a single raw value of a Terraform type to a Go value
+func nativeValue(kind, raw string) interface{} {
+	switch tfvarsBaseType(kind) {
+	case "number":
+		n := strings.TrimSpace(raw)
+		if _, err := strconv.ParseFloat(n, 64); err == nil {
+			return json.Number(n)
+		}
+	case "bool":
+		if b, err := parseBool(raw); err == nil {
+			return b
+		}
+	case "list":
+		if v, ok := decodeJSON(raw); ok {
+			if l, ok := v.([]interface{}); ok {
+				return l
+			}
+		}
+		result := []interface{}{}
+		for _, v := range splitList(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(raw), "["), "]")) {
+			result = append(result, nativeValue(elementType(kind), v))
+		}
+		return result
+	case "map":
+		if v, ok := decodeJSON(raw); ok {
+			if m, ok := v.(map[string]interface{}); ok {
+				return m
+			}
+		}
+		result := map[string]interface{}{}
+		for _, v := range splitList(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(raw), "{"), "}")) {
+			kv := strings.SplitN(v, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			result[strings.TrimSpace(kv[0])] = nativeValue(elementType(kind), strings.Trim(strings.TrimSpace(kv[1]), "\""))
+		}
+		return result
+	}
+
+	return raw
+}
+
+// tfvarsBaseType is BaseType, but also accepts boolean, which settings have
+// always been allowed to use for bool.
+func tfvarsBaseType(kind string) string {
+	if strings.ToLower(strings.TrimSpace(kind)) == "boolean" {
+		return "bool"
+	}
+	return BaseType(kind)
+}
+
+// elementType returns the type of the elements of a collection type, like
+// string for list(string). Collections without one hold strings.
+func elementType(kind string) string {
+	start := strings.Index(kind, "(")
+	end := strings.LastIndex(kind, ")")
+	if start < 0 || end < start {
+		return "string"
+	}
+	return strings.TrimSpace(kind[start+1 : end])
+}
+
+func isBracketed(value, open, close string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, open) && strings.HasSuffix(value, close)
+}
+
+// decodeJSON decodes a JSON array or object, keeping numbers as json.Number
+// so they are written back exactly as they were given.
+func decodeJSON(raw string) (interface{}, bool) {
+	if !isBracketed(raw, "[", "]") && !isBracketed(raw, "{", "}") {
+		return nil, false
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return nil, false
+	}
+	return v, true
+}
+
+func stringSlice(l []interface{}) ([]string, bool) {
+	result := []string{}
+	for _, v := range l {
+		str, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		result = append(result, str)
+	}
+	return result, true
+}
+
+func stringMap(m map[string]interface{}) (map[string]string, bool) {
+	result := map[string]string{}
+	for k, v := range m {
+		str, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		result[k] = str
+	}
+	return result, true
+}
+
+// hclValue writes a native value as an HCL expression. Strings are escaped by
+// hclwrite, so quotes, backslashes, newlines and template sequences like ${
+// come through as literal text.
+func hclValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(val)
+	case json.Number:
+		return val.String()
+	case []interface{}:
+		sl := []string{}
+		for _, item := range val {
+			sl = append(sl, hclValue(item))
+		}
+		return fmt.Sprintf("[%s]", strings.Join(sl, ","))
+	case map[string]interface{}:
+		keys := []string{}
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		sl := []string{}
+		for _, k := range keys {
+			key := k
+			if !hclsyntax.ValidIdentifier(k) {
+				key = hclValue(k)
+			}
+			sl = append(sl, fmt.Sprintf("%s=%s", key, hclValue(val[k])))
+		}
+		return fmt.Sprintf("{%s}", strings.Join(sl, ","))
+	}
+
+	return string(hclwrite.TokensForValue(cty.StringVal(fmt.Sprintf("%v", v))).Bytes())
+}
+
+// tfvarsSettings returns the settings that are handed to Terraform in the
+// tfvars file, in name order.
+func (s Stack) tfvarsSettings() Settings {
+	result := Settings{}
+
+	sorted := Settings{}
+	sorted = append(sorted, s.Settings...)
+	sorted.Sort()
+
+	for _, v := range sorted {
+		if v.Name == "" {
+			continue
+		}
+		label := v.TFvarsName()
+
+		if label == "project_name" {
+			continue
+		}
+
+		if label == "stack_name" {
+			continue
+		}
+
+		if len(v.Value) == 0 && len(v.List) == 0 && v.Map == nil {
+			continue
+		}
+
+		if v.Secret {
+			continue
+		}
+
+		result = append(result, v)
+	}
+
+	return result
+}
+
+// TerraformJSON returns the settings in the terraform.tfvars.json format
+func (s Stack) TerraformJSON() (string, error) {
+	values := map[string]interface{}{}
+	for _, v := range s.tfvarsSettings() {
+		values[v.TFvarsName()] = v.Native()
+	}
+
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(values); err != nil {
+		return "", fmt.Errorf("could not convert settings to json: %s", err)
+	}
+
+	return buf.String(), nil
+}
+
+// TFvarsFilename returns the name of the file Terraform settings should be
+// written to, based on TFvarsFormat.
+func (s Stack) TFvarsFilename() string {
+	if s.TFvarsFormat == TFvarsJSON {
+		return "terraform.tfvars.json"
+	}
+	return "terraform.tfvars"
+}
+
+// ParseTFvars reads the content of a terraform.tfvars file, or a
+// terraform.tfvars.json file if filename ends in .json, back into Settings
+// typed the way Terraform sees them.
+func ParseTFvars(content []byte, filename string) (Settings, error) {
+	var file *hcl.File
+	var diags hcl.Diagnostics
+
+	if strings.HasSuffix(filename, ".json") {
+		file, diags = hcljson.Parse(content, filename)
+	} else {
+		file, diags = hclsyntax.ParseConfig(content, filename, hcl.InitialPos)
+	}
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("could not parse %s: %s", filename, diags)
+	}
+
+	attrs, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("could not parse %s: %s", filename, diags)
+	}
+
+	result := Settings{}
+	for name, attr := range attrs {
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("could not read %s from %s: %s", name, filename, diags)
+		}
+
+		set, err := settingFromValue(name, val)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s from %s: %s", name, filename, err)
+		}
+		if set == nil {
+			continue
+		}
+		result = append(result, *set)
+	}
+	result.Sort()
+
+	return result, nil
+}
+
+// ReadTFvars reads a terraform.tfvars or terraform.tfvars.json file into
+// Settings
+func ReadTFvars(filename string) (Settings, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %s", filename, err)
+	}
+	return ParseTFvars(content, filename)
+}
+
+func settingFromValue(name string, val cty.Value) (*Setting, error) {
+	if val.IsNull() || !val.IsKnown() {
+		return nil, nil
+	}
+
+	set := Setting{Name: strings.ToLower(name)}
+	t := val.Type()
+
+	switch {
+	case t == cty.String:
+		set.Type = "string"
+		set.Value = val.AsString()
+	case t == cty.Number:
+		set.Type = "number"
+		set.Value = val.AsBigFloat().Text('f', -1)
+	case t == cty.Bool:
+		set.Type = "bool"
+		set.Value = strconv.FormatBool(val.True())
+	case t.IsListType() || t.IsSetType() || t.IsTupleType():
+		set.Type = "list"
+		set.List = []string{}
+		for it := val.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			if v.IsNull() || v.Type() != cty.String {
+				set.List = nil
+				break
+			}
+			set.List = append(set.List, v.AsString())
+		}
+	case t.IsMapType() || t.IsObjectType():
+		set.Type = "map"
+		set.Map = map[string]string{}
+		for it := val.ElementIterator(); it.Next(); {
+			k, v := it.Element()
+			if v.IsNull() || v.Type() != cty.String {
+				set.Map = nil
+				break
+			}
+			set.Map[k.AsString()] = v.AsString()
+		}
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t.FriendlyName())
+	}
+
+	// Collections of anything but strings are kept as JSON, which is how
+	// nested values are given to a Setting.
+	if (set.Type == "list" && set.List == nil) || (set.Type == "map" && set.Map == nil) {
+		dat, err := ctyjson.SimpleJSONValue{Value: val}.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		set.Value = string(dat)
+	}
+
+	return &set, nil
+}
+
+// Answer returns the setting the way a user would have typed it in: lists as
+// comma separated values and maps as comma separated key=value pairs.
+func (s Setting) Answer() string {
+	if len(s.List) > 0 {
+		return strings.Join(s.List, ",")
+	}
+
+	if len(s.Map) > 0 {
+		sl := []string{}
+		for k, v := range s.Map {
+			sl = append(sl, fmt.Sprintf("%s=%s", k, v))
+		}
+		sort.Strings(sl)
+		return strings.Join(sl, ",")
+	}
+
+	return s.Value
+}
+
+// FindTFvars returns the tfvars file left by an earlier run of the stack, or
+// an empty string if there isn't one. It looks in the working directory and
+// then in the terraform folder, where the install script moves it.
+func (s Stack) FindTFvars() string {
+	names := []string{"terraform.tfvars", "terraform.tfvars.json"}
+	if s.TFvarsFormat == TFvarsJSON {
+		names = []string{"terraform.tfvars.json", "terraform.tfvars"}
+	}
+
+	dirs := []string{s.Config.Getwd()}
+	if s.Config.PathTerraform != "" {
+		dirs = append(dirs, filepath.Join(s.Config.Getwd(), s.Config.PathTerraform))
+	}
+
+	for _, dir := range dirs {
+		for _, name := range names {
+			candidate := filepath.Join(dir, name)
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate
+			}
+		}
+	}
+
+	return ""
+}
+
+// Prefill checks settings from an earlier run against the current config. It
+// returns the ones that can be used as answers, and a reason for each one
+// that can't, like a value that no longer passes validation or is no longer
+// one of the options, so that question can be asked again.
+func (s Stack) Prefill(prior Settings) (Settings, []string) {
+	result := Settings{}
+	rejected := []string{}
+
+	for _, v := range prior {
+		if v.Name == "stack_name" || v.Name == "project_name" || s.Overridden(v.Name) {
+			continue
+		}
+
+		c := s.Config.CustomSettings.Get(v.Name)
+		if c.Name == "" {
+			result = append(result, v)
+			continue
+		}
+
+		if err := s.Config.checkAnswer(c, v.Answer()); err != nil {
+			rejected = append(rejected, fmt.Sprintf("%s: %s", v.Name, err))
+			continue
+		}
+
+		result = append(result, v)
+	}
+
+	return result, rejected
+}
+
+// checkAnswer makes sure an answer given to a custom setting outside of the
+// UI is one the UI would have accepted.
+func (c Config) checkAnswer(cu Custom, answer string) error {
+	typed, err := NewSettingTyped(cu.Name, cu.Type, answer)
+	if BaseType(cu.Type) != "string" && err != nil {
+		return fmt.Errorf("Your answer %s", err)
+	}
+
+	if len(cu.Options) > 0 {
+		found := false
+		for _, opt := range cu.Options {
+			if strings.SplitN(opt, "|", 2)[0] == typed.Value {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("'%s' is no longer one of the choices", answer)
+		}
+	}
+
+	values := []string{answer}
+	switch BaseType(cu.Type) {
+	case "list":
+		values = typed.List
+	case "map":
+		values = []string{}
+		for _, v := range typed.Map {
+			values = append(values, v)
+		}
+		sort.Strings(values)
+	}
+
+	for _, v := range values {
+		if err := c.Validate(cu, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,448 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+var trickySettings = Settings{
+	{Name: "quotes", Value: `say "hi"`, Type: "string"},
+	{Name: "backslash", Value: `C:\temp\new`, Type: "string"},
+	{Name: "newline", Value: "line one\nline two\ttabbed", Type: "string"},
+	{Name: "interpolation", Value: "${file(\"/etc/passwd\")} and %{ if true }x%{ endif }", Type: "string"},
+	{Name: "dollars", Value: "cost: $$5 $${", Type: "string"},
+	{Name: "unicode", Value: "héllo ✓", Type: "string"},
+	{Name: "not_a_list", Value: "[WARNING] careful", Type: "string"},
+	{Name: "draft", Value: "[draft]", Type: "string"},
+	{Name: "count", Value: "3", Type: "number"},
+	{Name: "ratio", Value: "0.25", Type: "number"},
+	{Name: "bad_number", Value: "three", Type: "number"},
+	{Name: "enabled", Value: "yes", Type: "bool"},
+	{Name: "tags", List: []string{`a"b`, "${c}"}, Type: "list"},
+	{Name: "nested", Value: `[["a","b"],[1,2],{"k":true}]`, Type: "list"},
+	{Name: "labels", Map: map[string]string{"env": "prod\n", "my.key": `x\y`}, Type: "map"},
+	{Name: "nested_map", Value: `{"zones":["a","b"],"size":{"min":1,"max":3}}`, Type: "map"},
+}
+
+func TestSettingTFvarsValue(t *testing.T) {
+	tests := map[string]struct {
+		in   Setting
+		want string
+	}{
+		"string":        {in: Setting{Value: "plain", Type: "string"}, want: `"plain"`},
+		"quotes":        {in: Setting{Value: `say "hi"`, Type: "string"}, want: `"say \"hi\""`},
+		"backslash":     {in: Setting{Value: `C:\temp`, Type: "string"}, want: `"C:\\temp"`},
+		"newline":       {in: Setting{Value: "a\nb", Type: "string"}, want: `"a\nb"`},
+		"interpolation": {in: Setting{Value: "${var.x}", Type: "string"}, want: `"$${var.x}"`},
+		"directive":     {in: Setting{Value: "%{ if x }", Type: "string"}, want: `"%%{ if x }"`},
+		"legacy list":   {in: Setting{Value: "[a,b]"}, want: `["a","b"]`},
+		"brackets":      {in: Setting{Value: "[draft]", Type: "string"}, want: `"[draft]"`},
+		"list values":   {in: Setting{List: []string{"a", "b"}, Type: "string"}, want: `["a","b"]`},
+		"number":        {in: Setting{Value: " 42 ", Type: "number"}, want: `42`},
+		"bad number":    {in: Setting{Value: "4 2", Type: "number"}, want: `"4 2"`},
+		"bool":          {in: Setting{Value: "y", Type: "bool"}, want: `true`},
+		"boolean":       {in: Setting{Value: "false", Type: "boolean"}, want: `false`},
+		"bad bool":      {in: Setting{Value: "maybe", Type: "bool"}, want: `"maybe"`},
+		"list numbers":  {in: Setting{List: []string{"1", "2"}, Type: "list(number)"}, want: `[1,2]`},
+		"list json":     {in: Setting{Value: `[["a"],[1]]`, Type: "list"}, want: `[["a"],[1]]`},
+		"map keys":      {in: Setting{Map: map[string]string{"b": "2", "a-b": "1", "a b": "3"}, Type: "map"}, want: `{"a b"="3",a-b="1",b="2"}`},
+		"map json":      {in: Setting{Value: `{"x":{"y":[true]}}`, Type: "map"}, want: `{x={y=[true]}}`},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tc.in.TFvarsValue()
+			if tc.want != got {
+				t.Fatalf("want '%s' got '%s'", tc.want, got)
+			}
+		})
+	}
+}
+
+// TestStackTerraformRoundTrip parses what is written with Terraform's own
+// parser and checks it reads back as the same values the json format holds.
+func TestStackTerraformRoundTrip(t *testing.T) {
+	s := NewStack()
+	s.Settings = trickySettings
+
+	file, diags := hclsyntax.ParseConfig([]byte(s.Terraform()), "terraform.tfvars", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("tfvars did not parse: %s\n%s", diags, s.Terraform())
+	}
+
+	attrs, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		t.Fatalf("tfvars did not parse: %s", diags)
+	}
+
+	fromHCL := map[string]interface{}{}
+	for name, attr := range attrs {
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			t.Fatalf("could not evaluate %s: %s", name, diags)
+		}
+
+		dat, err := ctyjson.SimpleJSONValue{Value: val}.MarshalJSON()
+		if err != nil {
+			t.Fatalf("could not convert %s: %s", name, err)
+		}
+
+		var v interface{}
+		if err := json.Unmarshal(dat, &v); err != nil {
+			t.Fatalf("could not convert %s: %s", name, err)
+		}
+		fromHCL[name] = v
+	}
+
+	content, err := s.TerraformJSON()
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	fromJSON := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(content), &fromJSON); err != nil {
+		t.Fatalf("tfvars.json did not parse: %s", err)
+	}
+
+	if !reflect.DeepEqual(fromJSON, fromHCL) {
+		t.Fatalf("hcl and json disagree: %v", deep.Equal(fromJSON, fromHCL))
+	}
+
+	for _, v := range trickySettings {
+		if v.Type != "string" {
+			continue
+		}
+		if fromHCL[v.Name] != v.Value {
+			t.Errorf("%s: want '%s' got '%v'", v.Name, v.Value, fromHCL[v.Name])
+		}
+	}
+
+	want := map[string]interface{}{
+		"count":      3.0,
+		"ratio":      0.25,
+		"bad_number": "three",
+		"enabled":    true,
+		"tags":       []interface{}{`a"b`, "${c}"},
+		"nested": []interface{}{
+			[]interface{}{"a", "b"},
+			[]interface{}{1.0, 2.0},
+			map[string]interface{}{"k": true},
+		},
+		"labels": map[string]interface{}{"env": "prod\n", "my.key": `x\y`},
+		"nested_map": map[string]interface{}{
+			"zones": []interface{}{"a", "b"},
+			"size":  map[string]interface{}{"min": 1.0, "max": 3.0},
+		},
+	}
+
+	for k, v := range want {
+		if !reflect.DeepEqual(v, fromHCL[k]) {
+			t.Errorf("%s: %v", k, deep.Equal(v, fromHCL[k]))
+		}
+	}
+}
+
+func TestStackTerraformFile(t *testing.T) {
+	tests := map[string]struct {
+		format   string
+		filename string
+		want     string
+	}{
+		"hcl": {
+			format:   TFvarsHCL,
+			filename: "terraform.tfvars",
+			want:     "nodes=3\nproject_id=\"ds-tester\"\ntags=[\"a\",\"b\"]\n",
+		},
+		"default": {
+			filename: "terraform.tfvars",
+			want:     "nodes=3\nproject_id=\"ds-tester\"\ntags=[\"a\",\"b\"]\n",
+		},
+		"json": {
+			format:   TFvarsJSON,
+			filename: "terraform.tfvars.json",
+			want:     "{\n  \"nodes\": 3,\n  \"project_id\": \"ds-tester\",\n  \"tags\": [\n    \"a\",\n    \"b\"\n  ]\n}\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewStack()
+			s.TFvarsFormat = tc.format
+			s.Settings = Settings{
+				{Name: "project_id", Value: "ds-tester", Type: "string"},
+				{Name: "nodes", Value: "3", Type: "number"},
+				{Name: "tags", List: []string{"a", "b"}, Type: "list"},
+				{Name: "stack_name", Value: "ignored", Type: "string"},
+			}
+
+			if got := s.TFvarsFilename(); got != tc.filename {
+				t.Fatalf("filename want '%s' got '%s'", tc.filename, got)
+			}
+
+			testfile := filepath.Join(t.TempDir(), s.TFvarsFilename())
+			if err := s.TerraformFile(testfile); err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			dat, err := os.ReadFile(testfile)
+			if err != nil {
+				t.Fatalf("could not read %s: %s", testfile, err)
+			}
+
+			if tc.want != string(dat) {
+				t.Fatalf("want \n%s\ngot \n%s", tc.want, string(dat))
+			}
+		})
+	}
+}
+
+func TestParseTFvars(t *testing.T) {
+	want := Settings{
+		{Name: "count", Value: "3", Type: "number"},
+		{Name: "enabled", Value: "true", Type: "bool"},
+		{Name: "labels", Map: map[string]string{"env": "prod\n", "my.key": `x\y`}, Type: "map"},
+		{Name: "nested", Value: `[["a","b"],[1,2],{"k":true}]`, Type: "list"},
+		{Name: "quotes", Value: `say "hi" ${x}`, Type: "string"},
+		{Name: "ratio", Value: "0.25", Type: "number"},
+		{Name: "tags", List: []string{"a", "b"}, Type: "list"},
+	}
+
+	tests := map[string]struct {
+		filename string
+		content  string
+		want     Settings
+		err      bool
+	}{
+		"hcl": {
+			filename: "terraform.tfvars",
+			content: `count=3
+enabled=true
+labels={env="prod\n","my.key"="x\\y"}
+nested=[["a","b"],[1,2],{k=true}]
+quotes="say \"hi\" $${x}"
+ratio=0.25
+tags=["a","b"]
+`,
+			want: want,
+		},
+		"json": {
+			filename: "terraform.tfvars.json",
+			content: `{
+  "count": 3,
+  "enabled": true,
+  "labels": {"env": "prod\n", "my.key": "x\\y"},
+  "nested": [["a","b"],[1,2],{"k":true}],
+  "quotes": "say \"hi\" ${x}",
+  "ratio": 0.25,
+  "tags": ["a","b"]
+}`,
+			want: want,
+		},
+		"upper case names": {
+			filename: "terraform.tfvars",
+			content:  "Region=\"us-east1\"\n",
+			want:     Settings{{Name: "region", Value: "us-east1", Type: "string"}},
+		},
+		"broken": {
+			filename: "terraform.tfvars",
+			content:  "region=\"us-east1\n",
+			err:      true,
+		},
+		"not static": {
+			filename: "terraform.tfvars",
+			content:  "region=var.region\n",
+			err:      true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseTFvars([]byte(tc.content), tc.filename)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("compare failed: %v", deep.Equal(tc.want, got))
+			}
+		})
+	}
+}
+
+// TestStackTerraformReadBack checks what is written is read back with the
+// same values, in both formats.
+func TestStackTerraformReadBack(t *testing.T) {
+	for _, format := range []string{TFvarsHCL, TFvarsJSON} {
+		t.Run(format, func(t *testing.T) {
+			s := NewStack()
+			s.TFvarsFormat = format
+			s.Settings = trickySettings
+
+			testfile := filepath.Join(t.TempDir(), s.TFvarsFilename())
+			if err := s.TerraformFile(testfile); err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			got, err := ReadTFvars(testfile)
+			if err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			again := NewStack()
+			again.TFvarsFormat = format
+			again.Settings = got
+
+			if s.Terraform() != again.Terraform() {
+				t.Fatalf("want \n%s\ngot \n%s", s.Terraform(), again.Terraform())
+			}
+
+			for _, v := range trickySettings {
+				if v.Type != "string" {
+					continue
+				}
+				if got := again.GetSetting(v.Name); got != v.Value {
+					t.Errorf("%s: want '%s' got '%s'", v.Name, v.Value, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSettingAnswer(t *testing.T) {
+	tests := map[string]struct {
+		in   Setting
+		want string
+	}{
+		"string": {in: Setting{Value: "us-east1", Type: "string"}, want: "us-east1"},
+		"list":   {in: Setting{List: []string{"a", "b"}, Type: "list"}, want: "a,b"},
+		"map":    {in: Setting{Map: map[string]string{"b": "2", "a": "1"}, Type: "map"}, want: "a=1,b=2"},
+		"json":   {in: Setting{Value: `[[1]]`, Type: "list"}, want: `[[1]]`},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tc.in.Answer(); got != tc.want {
+				t.Fatalf("want '%s' got '%s'", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestStackFindTFvars(t *testing.T) {
+	tests := map[string]struct {
+		files  []string
+		format string
+		want   string
+	}{
+		"none":      {want: ""},
+		"wd":        {files: []string{"terraform.tfvars"}, want: "terraform.tfvars"},
+		"terraform": {files: []string{"terraform/terraform.tfvars"}, want: "terraform/terraform.tfvars"},
+		"json":      {files: []string{"terraform.tfvars.json"}, want: "terraform.tfvars.json"},
+		"prefer format": {
+			files:  []string{"terraform.tfvars", "terraform.tfvars.json"},
+			format: TFvarsJSON,
+			want:   "terraform.tfvars.json",
+		},
+		"prefer wd": {
+			files: []string{"terraform.tfvars", "terraform/terraform.tfvars"},
+			want:  "terraform.tfvars",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, v := range tc.files {
+				path := filepath.Join(dir, v)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatalf("could not create %s: %s", path, err)
+				}
+				if err := os.WriteFile(path, []byte(""), 0o644); err != nil {
+					t.Fatalf("could not create %s: %s", path, err)
+				}
+			}
+
+			s := NewStack()
+			s.TFvarsFormat = tc.format
+			s.Config.PathTerraform = "terraform"
+			s.Config.Setwd(dir)
+
+			want := ""
+			if tc.want != "" {
+				want = filepath.Join(dir, tc.want)
+			}
+
+			if got := s.FindTFvars(); got != want {
+				t.Fatalf("want '%s' got '%s'", want, got)
+			}
+		})
+	}
+}
+
+func TestStackPrefill(t *testing.T) {
+	s := NewStack()
+	s.Config.CustomSettings = Customs{
+		{Name: "tier", Options: []string{"db-f1-micro|Micro", "db-g1-small|Small"}},
+		{Name: "nodes", Type: "number"},
+		{Name: "zones", Type: "list(string)", Validation: "integer"},
+		{Name: "bucket", Rule: &Rule{Pattern: "^[a-z-]+$"}},
+	}
+
+	prior := Settings{
+		{Name: "stack_name", Value: "old", Type: "string"},
+		{Name: "project_id", Value: "ds-tester", Type: "string"},
+		{Name: "region", Value: "us-east1", Type: "string"},
+		{Name: "tier", Value: "db-n1-standard-1", Type: "string"},
+		{Name: "nodes", Value: "3", Type: "number"},
+		{Name: "zones", List: []string{"1", "b"}, Type: "list"},
+		{Name: "bucket", Value: "my-bucket", Type: "string"},
+	}
+
+	wantSets := Settings{
+		{Name: "project_id", Value: "ds-tester", Type: "string"},
+		{Name: "region", Value: "us-east1", Type: "string"},
+		{Name: "nodes", Value: "3", Type: "number"},
+		{Name: "bucket", Value: "my-bucket", Type: "string"},
+	}
+
+	got, rejected := s.Prefill(prior)
+	if !reflect.DeepEqual(wantSets, got) {
+		t.Fatalf("compare failed: %v", deep.Equal(wantSets, got))
+	}
+
+	if len(rejected) != 2 {
+		t.Fatalf("expected 2 rejected answers, got: %v", rejected)
+	}
+	if !strings.HasPrefix(rejected[0], "tier: ") || !strings.HasPrefix(rejected[1], "zones: ") {
+		t.Fatalf("rejected answers are not the expected ones: %v", rejected)
+	}
+}
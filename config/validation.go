@@ -0,0 +1,424 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// ErrUnknownValidation is returned when a custom setting refers to a
+// validation that is neither built in nor defined in the config.
+var ErrUnknownValidation = fmt.Errorf("unknown validation")
+
+// Validator checks a single answer. The error it returns should finish the
+// sentence "Your answer 'x' ...", like "is not a valid email address".
+type Validator func(value string) error
+
+var (
+	validatorsMu sync.RWMutex
+	validators   = map[string]Validator{
+		"integer":         validateInteger,
+		"number":          validateNumber,
+		"yesorno":         validateYesOrNo,
+		"phonenumber":     validatePhoneNumber,
+		"email":           validateEmail,
+		"url":             validateURL,
+		"cidr":            validateCIDR,
+		"dns_label":       validateDNSLabel,
+		"gcs_bucket":      validateGCSBucket,
+		"service_account": validateServiceAccount,
+	}
+)
+
+// RegisterValidator adds a named validator that configs can refer to as a
+// validation or as the format of a Rule. Registering an existing name
+// replaces it.
+func RegisterValidator(name string, v Validator) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	validators[name] = v
+}
+
+// Validators returns the names of all of the registered validators
+func Validators() []string {
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+
+	result := []string{}
+	for k := range validators {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func getValidator(name string) (Validator, bool) {
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+	v, ok := validators[name]
+	return v, ok
+}
+
+// Rule is an author defined validation for a custom setting. Every part that
+// is set has to pass. Rules can be defined once under validations and
+// referred to by name, or inline on a custom setting.
+type Rule struct {
+	Format    string   `json:"format,omitempty" yaml:"format,omitempty"`
+	Pattern   string   `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	MinLength int      `json:"min_length,omitempty" yaml:"min_length,omitempty"`
+	MaxLength int      `json:"max_length,omitempty" yaml:"max_length,omitempty"`
+	Min       *float64 `json:"min,omitempty" yaml:"min,omitempty"`
+	Max       *float64 `json:"max,omitempty" yaml:"max,omitempty"`
+	Enum      []string `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Message   string   `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+func (r Rule) copy() Rule {
+	r.Enum = copyStrings(r.Enum)
+	if r.Min != nil {
+		min := *r.Min
+		r.Min = &min
+	}
+	if r.Max != nil {
+		max := *r.Max
+		r.Max = &max
+	}
+	return r
+}
+
+// ValidationError is returned when an answer fails a Rule. Message is the
+// author's explanation, if they provided one.
+type ValidationError struct {
+	Value   string
+	Reason  string
+	Message string
+}
+
+func (v ValidationError) Error() string {
+	return fmt.Sprintf("Your answer '%s' %s", v.Value, v.Reason)
+}
+
+// Validate checks value against every part of the rule that is set
+func (r Rule) Validate(value string) error {
+	fail := func(format string, a ...interface{}) error {
+		return ValidationError{Value: value, Reason: fmt.Sprintf(format, a...), Message: r.Message}
+	}
+
+	if r.Format != "" {
+		v, ok := getValidator(r.Format)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownValidation, r.Format)
+		}
+		if err := v(value); err != nil {
+			return fail("%s", err)
+		}
+	}
+
+	if len(r.Enum) > 0 {
+		found := false
+		for _, v := range r.Enum {
+			if v == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fail("is not one of: %s", strings.Join(r.Enum, ", "))
+		}
+	}
+
+	length := utf8.RuneCountInString(value)
+	if r.MinLength > 0 && length < r.MinLength {
+		return fail("is shorter than %d characters", r.MinLength)
+	}
+
+	if r.MaxLength > 0 && length > r.MaxLength {
+		return fail("is longer than %d characters", r.MaxLength)
+	}
+
+	if r.Pattern != "" {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid validation pattern (%s): %s", r.Pattern, err)
+		}
+		if !re.MatchString(value) {
+			return fail("does not match the pattern %s", r.Pattern)
+		}
+	}
+
+	if r.Min != nil || r.Max != nil {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fail("is not a number")
+		}
+
+		if r.Min != nil && f < *r.Min {
+			return fail("is less than %s", strconv.FormatFloat(*r.Min, 'f', -1, 64))
+		}
+
+		if r.Max != nil && f > *r.Max {
+			return fail("is greater than %s", strconv.FormatFloat(*r.Max, 'f', -1, 64))
+		}
+	}
+
+	return nil
+}
+
+// check reports problems with the rule itself, rather than with an answer
+func (r Rule) check() []string {
+	result := []string{}
+
+	if r.Format != "" {
+		if _, ok := getValidator(r.Format); !ok {
+			result = append(result, fmt.Sprintf("unknown format '%s'", r.Format))
+		}
+	}
+
+	if r.Pattern != "" {
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			result = append(result, fmt.Sprintf("invalid pattern: %s", err))
+		}
+	}
+
+	if r.MaxLength > 0 && r.MinLength > r.MaxLength {
+		result = append(result, "min_length is greater than max_length")
+	}
+
+	if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+		result = append(result, "min is greater than max")
+	}
+
+	return result
+}
+
+// Rules returns the rules an answer to the custom setting has to pass: the
+// named validation, if there is one, followed by the inline rule.
+func (c Config) Rules(cu Custom) ([]Rule, error) {
+	result := []Rule{}
+
+	if cu.Validation != "" {
+		if r, ok := c.Validations[cu.Validation]; ok {
+			result = append(result, r)
+		} else if _, ok := getValidator(cu.Validation); ok {
+			result = append(result, Rule{Format: cu.Validation})
+		} else {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownValidation, cu.Validation)
+		}
+	}
+
+	if cu.Rule != nil {
+		result = append(result, *cu.Rule)
+	}
+
+	return result, nil
+}
+
+// Validate checks an answer to a custom setting against its rules
+func (c Config) Validate(cu Custom, value string) error {
+	rules, err := c.Rules(cu)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rules {
+		if err := r.Validate(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lintCustoms reports problems with validations and custom settings that
+// would only otherwise show up when someone runs the stack
+func (c Config) lintCustoms() Issues {
+	issues := Issues{}
+
+	names := []string{}
+	for k := range c.Validations {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, k := range names {
+		for _, msg := range c.Validations[k].check() {
+			path := joinPath("validations", k)
+			issues = append(issues, Issue{Path: path, Key: k, Message: fmt.Sprintf("%s: %s", path, msg)})
+		}
+	}
+
+	for i, v := range c.CustomSettings {
+		path := fmt.Sprintf("custom_settings[%d]", i)
+
+		if _, err := c.Rules(v); err != nil {
+			candidates := append(Validators(), names...)
+			issues = append(issues, Issue{
+				Path:       path + ".validation",
+				Key:        v.Validation,
+				Message:    fmt.Sprintf("%s.validation: unknown validation '%s'", path, v.Validation),
+				Suggestion: suggest(v.Validation, candidates),
+			})
+		}
+
+		known := v.Picker == ""
+		for _, p := range Pickers() {
+			known = known || p == v.Picker
+		}
+
+		if !known {
+			issues = append(issues, Issue{
+				Path:       path + ".picker",
+				Key:        v.Picker,
+				Message:    fmt.Sprintf("%s.picker: unknown picker '%s'", path, v.Picker),
+				Suggestion: suggest(v.Picker, Pickers()),
+			})
+		}
+
+		if IsTemplate(v.Default) {
+			if _, err := parseDefault(v.Default); err != nil {
+				issues = append(issues, Issue{Path: path + ".default", Key: "default", Message: fmt.Sprintf("%s.default: %s", path, err)})
+			}
+		}
+
+		if v.PrependProject && BaseType(v.Type) != "string" {
+			issues = append(issues, Issue{
+				Path:    path + ".prepend_project",
+				Key:     "prepend_project",
+				Message: fmt.Sprintf("%s.prepend_project: only works for string settings, not %s", path, BaseType(v.Type)),
+			})
+		}
+
+		if v.Rule == nil {
+			continue
+		}
+
+		for _, msg := range v.Rule.check() {
+			issues = append(issues, Issue{Path: path + ".rule", Key: "rule", Message: fmt.Sprintf("%s.rule: %s", path, msg)})
+		}
+	}
+
+	return issues
+}
+
+func validateInteger(value string) error {
+	if _, err := strconv.Atoi(value); err != nil {
+		return fmt.Errorf("not a valid integer")
+	}
+	return nil
+}
+
+func validateNumber(value string) error {
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return fmt.Errorf("is not a valid number")
+	}
+	return nil
+}
+
+func validateYesOrNo(value string) error {
+	switch strings.TrimSpace(strings.ToLower(value)) {
+	case "y", "yes", "n", "no":
+		return nil
+	}
+	return fmt.Errorf("is neither 'yes' nor 'no'")
+}
+
+func validatePhoneNumber(value string) error {
+	if _, err := phonenumbers.Parse(value, "US"); err != nil {
+		return fmt.Errorf("is not a valid phone number")
+	}
+	return nil
+}
+
+func validateEmail(value string) error {
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value {
+		return fmt.Errorf("is not a valid email address")
+	}
+	return nil
+}
+
+func validateURL(value string) error {
+	u, err := url.ParseRequestURI(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("is not a valid URL")
+	}
+	return nil
+}
+
+func validateCIDR(value string) error {
+	if _, _, err := net.ParseCIDR(value); err != nil {
+		return fmt.Errorf("is not a valid CIDR range")
+	}
+	return nil
+}
+
+var (
+	dnsLabelRE       = regexp.MustCompile(`^[a-z]([-a-z0-9]{0,61}[a-z0-9])?$`)
+	gcsBucketRE      = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*[a-z0-9]$`)
+	serviceAccountRE = regexp.MustCompile(`^([a-z][a-z0-9-]{4,28}[a-z0-9]@[a-z0-9.:-]+\.iam|[0-9]+-compute@developer|[a-z0-9.:-]+@appspot)\.gserviceaccount\.com$`)
+)
+
+func validateDNSLabel(value string) error {
+	if !dnsLabelRE.MatchString(value) {
+		return fmt.Errorf("is not a valid DNS label: lowercase letters, numbers and hyphens, starting with a letter, at most 63 characters")
+	}
+	return nil
+}
+
+func validateGCSBucket(value string) error {
+	invalid := fmt.Errorf("is not a valid Cloud Storage bucket name")
+
+	if !gcsBucketRE.MatchString(value) || len(value) < 3 {
+		return invalid
+	}
+
+	if !strings.Contains(value, ".") && len(value) > 63 {
+		return invalid
+	}
+
+	if len(value) > 222 || strings.Contains(value, "..") || net.ParseIP(value) != nil {
+		return invalid
+	}
+
+	for _, part := range strings.Split(value, ".") {
+		if len(part) > 63 {
+			return invalid
+		}
+	}
+
+	if strings.HasPrefix(value, "goog") || strings.Contains(value, "google") {
+		return invalid
+	}
+
+	return nil
+}
+
+func validateServiceAccount(value string) error {
+	if !serviceAccountRE.MatchString(value) {
+		return fmt.Errorf("is not a valid service account email")
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestRuleValidate(t *testing.T) {
+	tests := map[string]struct {
+		rule  Rule
+		value string
+		want  error
+	}{
+		"empty": {rule: Rule{}, value: "anything", want: nil},
+		"pattern": {
+			rule:  Rule{Pattern: "^[a-z]+$"},
+			value: "abc",
+			want:  nil,
+		},
+		"pattern fail": {
+			rule:  Rule{Pattern: "^[a-z]+$", Message: "Only lowercase letters please"},
+			value: "ABC",
+			want:  ValidationError{Value: "ABC", Reason: "does not match the pattern ^[a-z]+$", Message: "Only lowercase letters please"},
+		},
+		"min length": {
+			rule:  Rule{MinLength: 4},
+			value: "abc",
+			want:  ValidationError{Value: "abc", Reason: "is shorter than 4 characters"},
+		},
+		"max length": {
+			rule:  Rule{MaxLength: 2},
+			value: "abc",
+			want:  ValidationError{Value: "abc", Reason: "is longer than 2 characters"},
+		},
+		"range": {
+			rule:  Rule{Min: floatPtr(0), Max: floatPtr(10)},
+			value: "10",
+			want:  nil,
+		},
+		"range under": {
+			rule:  Rule{Min: floatPtr(0), Max: floatPtr(10)},
+			value: "-1",
+			want:  ValidationError{Value: "-1", Reason: "is less than 0"},
+		},
+		"range over": {
+			rule:  Rule{Min: floatPtr(0), Max: floatPtr(10.5)},
+			value: "11",
+			want:  ValidationError{Value: "11", Reason: "is greater than 10.5"},
+		},
+		"range not a number": {
+			rule:  Rule{Max: floatPtr(10)},
+			value: "ten",
+			want:  ValidationError{Value: "ten", Reason: "is not a number"},
+		},
+		"enum": {
+			rule:  Rule{Enum: []string{"small", "large"}},
+			value: "large",
+			want:  nil,
+		},
+		"enum fail": {
+			rule:  Rule{Enum: []string{"small", "large"}},
+			value: "medium",
+			want:  ValidationError{Value: "medium", Reason: "is not one of: small, large"},
+		},
+		"format": {
+			rule:  Rule{Format: "email"},
+			value: "nobody",
+			want:  ValidationError{Value: "nobody", Reason: "is not a valid email address"},
+		},
+		"unknown format": {
+			rule:  Rule{Format: "nope"},
+			value: "anything",
+			want:  fmt.Errorf("%w: nope", ErrUnknownValidation),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tc.rule.Validate(tc.value)
+
+			if tc.want == nil || got == nil {
+				if tc.want != got {
+					t.Fatalf("want '%v' got '%v'", tc.want, got)
+				}
+				return
+			}
+
+			if tc.want.Error() != got.Error() {
+				t.Fatalf("want '%s' got '%s'", tc.want, got)
+			}
+
+			var want, verr ValidationError
+			if errors.As(tc.want, &want) && errors.As(got, &verr) && !reflect.DeepEqual(want, verr) {
+				t.Fatalf("want '%+v' got '%+v'", want, verr)
+			}
+		})
+	}
+}
+
+func TestValidators(t *testing.T) {
+	tests := map[string]struct {
+		format string
+		good   []string
+		bad    []string
+	}{
+		"integer": {
+			format: "integer",
+			good:   []string{"5", "-3"},
+			bad:    []string{"five", "5.5"},
+		},
+		"number": {
+			format: "number",
+			good:   []string{"5", "5.5"},
+			bad:    []string{"five"},
+		},
+		"yesorno": {
+			format: "yesorno",
+			good:   []string{"y", "YES", "n", "No"},
+			bad:    []string{"maybe"},
+		},
+		"phonenumber": {
+			format: "phonenumber",
+			good:   []string{"800 555 1234"},
+			bad:    []string{"dghdhdfuejfhfhfhrghfhfhdhgreh"},
+		},
+		"email": {
+			format: "email",
+			good:   []string{"person@example.com"},
+			bad:    []string{"person", "Person <person@example.com>"},
+		},
+		"url": {
+			format: "url",
+			good:   []string{"https://example.com/path"},
+			bad:    []string{"example.com", "/path"},
+		},
+		"cidr": {
+			format: "cidr",
+			good:   []string{"10.0.0.0/24", "2001:db8::/32"},
+			bad:    []string{"10.0.0.0", "10.0.0.0/33"},
+		},
+		"dns_label": {
+			format: "dns_label",
+			good:   []string{"my-instance", "a"},
+			bad:    []string{"My-Instance", "1instance", "instance-", "a-very-long-label-that-goes-on-and-on-and-on-past-sixty-three-chars"},
+		},
+		"gcs_bucket": {
+			format: "gcs_bucket",
+			good:   []string{"my-bucket", "my.bucket.example.com", "a_b"},
+			bad:    []string{"ab", "My-Bucket", "-bucket", "goog-bucket", "my-google-bucket", "192.168.5.4", "my..bucket"},
+		},
+		"service_account": {
+			format: "service_account",
+			good: []string{
+				"deployer@my-project.iam.gserviceaccount.com",
+				"123456789-compute@developer.gserviceaccount.com",
+				"my-project@appspot.gserviceaccount.com",
+			},
+			bad: []string{"deployer@example.com", "deployer"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := Rule{Format: tc.format}
+
+			for _, v := range tc.good {
+				if err := r.Validate(v); err != nil {
+					t.Errorf("'%s' should be valid, got: %s", v, err)
+				}
+			}
+
+			for _, v := range tc.bad {
+				if err := r.Validate(v); err == nil {
+					t.Errorf("'%s' should not be valid", v)
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterValidator(t *testing.T) {
+	RegisterValidator("even", func(value string) error {
+		if len(value)%2 != 0 {
+			return fmt.Errorf("does not have an even number of characters")
+		}
+		return nil
+	})
+
+	c := Config{}
+	cu := Custom{Name: "test", Validation: "even"}
+
+	if err := c.Validate(cu, "ab"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	want := "Your answer 'abc' does not have an even number of characters"
+	if err := c.Validate(cu, "abc"); err == nil || err.Error() != want {
+		t.Fatalf("want '%s' got '%v'", want, err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	c := Config{
+		Validations: map[string]Rule{
+			"bucket_name": {Format: "gcs_bucket", MaxLength: 20, Message: "Bucket names are short and lowercase"},
+		},
+	}
+
+	tests := map[string]struct {
+		custom Custom
+		value  string
+		want   error
+	}{
+		"none": {
+			custom: Custom{Name: "test"},
+			value:  "anything",
+			want:   nil,
+		},
+		"builtin": {
+			custom: Custom{Name: "test", Validation: "integer"},
+			value:  "five",
+			want:   ValidationError{Value: "five", Reason: "not a valid integer"},
+		},
+		"named": {
+			custom: Custom{Name: "test", Validation: "bucket_name"},
+			value:  "a-bucket-name-that-is-too-long",
+			want:   ValidationError{Value: "a-bucket-name-that-is-too-long", Reason: "is longer than 20 characters", Message: "Bucket names are short and lowercase"},
+		},
+		"named and inline": {
+			custom: Custom{Name: "test", Validation: "bucket_name", Rule: &Rule{Pattern: "-assets$"}},
+			value:  "my-bucket",
+			want:   ValidationError{Value: "my-bucket", Reason: "does not match the pattern -assets$"},
+		},
+		"inline": {
+			custom: Custom{Name: "test", Rule: &Rule{Enum: []string{"a", "b"}, Message: "Pick a or b"}},
+			value:  "b",
+			want:   nil,
+		},
+		"unknown": {
+			custom: Custom{Name: "test", Validation: "bucketname"},
+			value:  "anything",
+			want:   fmt.Errorf("%w: bucketname", ErrUnknownValidation),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := c.Validate(tc.custom, tc.value)
+
+			if tc.want == nil || got == nil {
+				if tc.want != got {
+					t.Fatalf("want '%v' got '%v'", tc.want, got)
+				}
+				return
+			}
+
+			if tc.want.Error() != got.Error() {
+				t.Fatalf("want '%s' got '%s'", tc.want, got)
+			}
+
+			if verr, ok := got.(ValidationError); ok && verr.Message != tc.want.(ValidationError).Message {
+				t.Fatalf("message - want '%s' got '%s'", tc.want.(ValidationError).Message, verr.Message)
+			}
+		})
+	}
+}
+
+func TestLintCustoms(t *testing.T) {
+	c := Config{
+		Validations: map[string]Rule{
+			"broken": {Pattern: "[a-z", Min: floatPtr(5), Max: floatPtr(1)},
+		},
+		CustomSettings: Customs{
+			{Name: "nodes", Validation: "integr"},
+			{Name: "tier", Rule: &Rule{Format: "nope", MinLength: 5, MaxLength: 2}},
+			{Name: "bucket", Default: "{{ .project_id }-assets"},
+			{Name: "secondary_region", Picker: "regoin"},
+			{Name: "secondary_zone", Picker: PickerZone},
+			{Name: "nodes_prefixed", Type: "number", PrependProject: true},
+			{Name: "bucket_secret", Secret: true, PrependProject: true},
+		},
+	}
+
+	want := []string{
+		"validations.broken: invalid pattern: error parsing regexp: missing closing ]: `[a-z`",
+		"validations.broken: min is greater than max",
+		"custom_settings[0].validation: unknown validation 'integr', did you mean 'integer'?",
+		"custom_settings[1].rule: unknown format 'nope'",
+		"custom_settings[1].rule: min_length is greater than max_length",
+		"custom_settings[2].default: could not parse default ({{ .project_id }-assets): template: default:1: unexpected \"}\" in operand",
+		"custom_settings[3].picker: unknown picker 'regoin', did you mean 'region'?",
+		"custom_settings[5].prepend_project: only works for string settings, not number",
+	}
+
+	got := []string{}
+	for _, v := range c.lintCustoms() {
+		got = append(got, v.String())
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want \n%v\ngot \n%v", want, got)
+	}
+}
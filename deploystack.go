@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/GoogleCloudPlatform/deploystack/config"
@@ -33,6 +34,15 @@ import (
 	"google.golang.org/api/option"
 )
 
+var (
+	// Variables that hold other projects, like host_project_id or
+	// network_project
+	projectVariable = regexp.MustCompile(`(^|_)project(_id)?$`)
+	// Variants like primary_region or zone_secondary
+	regionVariable = regexp.MustCompile(`(^|_)region($|_)`)
+	zoneVariable   = regexp.MustCompile(`(^|_)zone($|_)`)
+)
+
 var (
 	opts             = option.WithCredentialsFile("")
 	credspath        = ""
@@ -52,10 +62,18 @@ func Init(path string) (*config.Stack, error) {
 		if err := s.Config.ComputeName(path); err != nil {
 			return &s, fmt.Errorf("could not retrieve name of stack: %s \nDeployStack author: fix this by adding a 'name' key and value to the deploystack config", err)
 		}
-		s.AddSetting("stack_name", s.Config.Name)
+		s.AddSettingSource("stack_name", s.Config.Name, config.SourceComputed)
 	}
 	s.Config.Setwd(path)
 
+	if err := s.Localize(config.LocaleFromEnv(os.Environ())); err != nil {
+		return &s, fmt.Errorf("could not read messages for locale: %s", err)
+	}
+
+	if err := s.Override(os.Environ()); err != nil {
+		return &s, fmt.Errorf("could not read settings from the environment: %s", err)
+	}
+
 	return &s, nil
 }
 
@@ -171,7 +189,22 @@ func (m Meta) ShortNameUnderscore() string {
 // Suggest will provide it's best guess of what the deploystack config should
 // be based on the contents of the repo, including an existing deploystack config
 func (m Meta) Suggest() (config.Config, error) {
-	out := m.DeployStack.Copy()
+	suggested, err := m.suggestFromTerraform()
+	if err != nil {
+		return suggested, err
+	}
+
+	// Anything the existing config already says wins over a guess
+	out, _ := m.DeployStack.Merge(suggested, config.KeepExisting)
+	out.SchemaVersion = config.CurrentSchemaVersion
+
+	return out, nil
+}
+
+// suggestFromTerraform guesses a config from the repo name and the Terraform
+// files alone
+func (m Meta) suggestFromTerraform() (config.Config, error) {
+	out := config.Config{}
 
 	name := filepath.Base(m.Github.URL())
 	name = strings.ReplaceAll(name, "deploystack-", "")
@@ -179,35 +212,46 @@ func (m Meta) Suggest() (config.Config, error) {
 	caser := cases.Title(language.AmericanEnglish)
 	title = caser.String(title)
 
-	if m.DeployStack.Name == "" {
-		out.Name = name
-	}
-
-	if m.DeployStack.Title == "" {
-		out.Title = title
-	}
+	out.Name = name
+	out.Title = title
 
 	if len(m.Terraform) == 0 {
 		return out, errors.New("suggest: terraform was empty")
 	}
 
-	if m.DeployStack.PathTerraform == "" {
-		out.PathTerraform = filepath.Dir(m.Terraform[0].File)
-	}
+	out.PathTerraform = filepath.Dir(m.Terraform[0].File)
 
 	resources, err := terraform.NewGCPResources()
 	if err != nil {
 		return out, fmt.Errorf("could not get terraform resource meta data: %w", err)
 	}
 
+	// Variables that are used as the project, region or zone of something
+	// are those, whatever they are called
+	used := terraform.Blocks{}
+	for _, v := range m.Terraform {
+		if v.IsVariable() || !v.InRoot() {
+			continue
+		}
+		used = append(used, v)
+	}
+	projects := stringSet(used.VariableRefs("project", "project_id"))
+	regions := stringSet(used.VariableRefs("region"))
+	zones := stringSet(used.VariableRefs("zone"))
+
 	for _, v := range m.Terraform {
 		switch v.Kind {
 		case "variable":
-			// For now if there are default values, don't bother capturing
-			if !v.NoDefault() {
+			// Variables of modules are filled in by the stack, not the user
+			if !v.InRoot() {
 				continue
 			}
 
+			tv, err := v.Variable()
+			if err != nil {
+				tv = terraform.Variable{Name: v.Name, Type: v.Type}
+			}
+
 			switch v.Name {
 			case "project_id":
 				out.Project = true
@@ -217,30 +261,57 @@ func (m Meta) Suggest() (config.Config, error) {
 				out.BillingAccount = true
 			case "region":
 				out.RegionDefault = "us-central1"
-				out.Region = true
-				out.RegionType = "compute"
-
-				if r := m.Terraform.Search("google_cloud_run", "type"); len(r) > 0 {
-					out.RegionType = "run"
+				if tv.Default != "" {
+					out.RegionDefault = tv.Default
 				}
-
-				if r := m.Terraform.Search("google_cloudfunctions", "type"); len(r) > 0 {
-					out.RegionType = "functions"
-				}
-
+				out.Region = true
+				out.RegionType = suggestRegionType(m.Terraform)
 			case "zone":
 				out.Zone = true
 			default:
 				checkCustom := out.CustomSettings.Get(v.Name)
-				checkAuthor := out.AuthorSettings.Find(v.Name)
+				checkAuthor := m.DeployStack.AuthorSettings.Find(v.Name)
+
+				if checkCustom.Name != "" || checkAuthor != nil {
+					continue
+				}
+
+				single := config.BaseType(v.Type) == "string"
+
+				if single && (projectVariable.MatchString(v.Name) || projects[v.Name]) {
+					// Already asked for some other way by the existing config
+					if m.DeployStack.CustomSettings.Get(v.Name).Name != "" {
+						continue
+					}
+
+					p := config.Project{Name: v.Name, UserPrompt: tv.Description}
+					if p.UserPrompt == "" {
+						label := strings.ReplaceAll(strings.TrimSuffix(v.Name, "_id"), "_", " ")
+						p.UserPrompt = fmt.Sprintf("Choose the %s to use for this application.", label)
+					}
+					out.Projects.Items = append(out.Projects.Items, p)
+					continue
+				}
 
-				if checkCustom.Name == "" && checkAuthor == nil {
-					cust := config.Custom{}
-					cust.Name = v.Name
-					cust.Type = v.Type
-					out.CustomSettings = append(out.CustomSettings, cust)
+				cust := config.Custom{}
+				cust.Name = v.Name
+				cust.Type = v.Type
+				cust.Description = tv.Description
+				cust.Default = tv.Default
+				cust.Secret = tv.Sensitive
+				cust.Options = tv.Options
+
+				switch {
+				case single && (regionVariable.MatchString(v.Name) || regions[v.Name]):
+					cust.Picker = config.PickerRegion
+					if out.RegionType == "" {
+						out.RegionType = suggestRegionType(m.Terraform)
+					}
+				case single && (zoneVariable.MatchString(v.Name) || zones[v.Name]):
+					cust.Picker = config.PickerZone
 				}
 
+				out.CustomSettings = append(out.CustomSettings, cust)
 			}
 		case "managed":
 			product := resources.GetProduct(v.Type)
@@ -268,6 +339,30 @@ func (m Meta) Suggest() (config.Config, error) {
 	return out, nil
 }
 
+func stringSet(list []string) map[string]bool {
+	result := map[string]bool{}
+	for _, v := range list {
+		result[v] = true
+	}
+	return result
+}
+
+// suggestRegionType picks the kind of region list to offer from the products
+// the Terraform uses
+func suggestRegionType(blocks terraform.Blocks) string {
+	result := "compute"
+
+	if r := blocks.Search("google_cloud_run", "type"); len(r) > 0 {
+		result = "run"
+	}
+
+	if r := blocks.Search("google_cloudfunctions", "type"); len(r) > 0 {
+		result = "functions"
+	}
+
+	return result
+}
+
 // DownloadRepo takes a name of a GoogleCloudPlatform repo or a
 // GoogleCloudPlatform/deploystack-[name] repo, and downloads it into a unique
 // folder name, and outputs that name
@@ -313,16 +408,8 @@ func AttemptRepo(name, wd string) (string, github.Repo, error) {
 	return dir, gh, err
 }
 
-// WriteConfig will drop a .deploystack folder with deploystack.yaml file for
-// repos that do not have one.
-func WriteConfig(dir string, gh github.Repo) error {
-	m, err := NewMeta(dir)
-	if err != nil {
-		tui.Fatal(err)
-	}
-
-	m.Github = gh
-
+// suggestedDescription explains to users that the config was guessed
+func suggestedDescription(gh github.Repo) string {
 	sb := strings.Builder{}
 	sb.WriteString("This DeployStack is running automatically based on our best guess\n")
 	sb.WriteString("of what the Terraform files present in the github repo you chose \n")
@@ -330,11 +417,50 @@ func WriteConfig(dir string, gh github.Repo) error {
 	sb.WriteString("\n\n")
 	sb.WriteString("If you would like to see proper information, please file an issue at \n")
 	sb.WriteString(fmt.Sprintf("%s/issues", gh.URL()))
-	m.DeployStack.Description = sb.String()
+	return sb.String()
+}
+
+// suggestConfig returns the config already in dir and the config suggested
+// for it. The suggestion only fills in the description when there is none.
+func suggestConfig(dir string, gh github.Repo) (config.Config, config.Config, error) {
+	m, err := NewMeta(dir)
+	if err != nil {
+		return config.Config{}, config.Config{}, err
+	}
+
+	loaded := m.DeployStack.Copy()
+
+	m.Github = gh
+	if m.DeployStack.Description == "" {
+		m.DeployStack.Description = suggestedDescription(gh)
+	}
+
+	suggested, err := m.Suggest()
+	if err != nil {
+		return config.Config{}, config.Config{}, fmt.Errorf("could not make suggestion based on repo: %s", err)
+	}
+
+	return loaded, suggested, nil
+}
 
-	config, err := m.Suggest()
+// SuggestDiff reports what a suggested config would change about the config
+// already in dir, without writing anything. The diff is against the config as
+// loaded, so a description the suggestion fills in shows up as a change.
+func SuggestDiff(dir string, gh github.Repo) ([]config.Change, error) {
+	loaded, suggested, err := suggestConfig(dir, gh)
 	if err != nil {
-		return fmt.Errorf("could not make suggestion based on repo: %s", err)
+		return nil, err
+	}
+
+	return loaded.Diff(suggested), nil
+}
+
+// WriteConfig will drop a .deploystack folder with deploystack.yaml file for
+// repos that do not have one.
+func WriteConfig(dir string, gh github.Repo) error {
+	_, config, err := suggestConfig(dir, gh)
+	if err != nil {
+		return err
 	}
 
 	configyaml, err := config.Marshal("yaml")
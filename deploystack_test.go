@@ -921,6 +921,7 @@ func TestSuggest(t *testing.T) {
 		"nosql-client-server": {
 			in: nosqlMeta,
 			want: config.Config{
+				SchemaVersion: config.CurrentSchemaVersion,
 				Title:         "NoSQL Client Server",
 				Name:          "nosql-client-server",
 				Duration:      5,
@@ -942,12 +943,200 @@ func TestSuggest(t *testing.T) {
 					},
 				},
 				Description: "",
+				CustomSettings: config.Customs{
+					{
+						Name:        "gcp_service_list",
+						Description: "The list of apis necessary for the project",
+						Default:     "compute.googleapis.com",
+						Type:        "list(string)",
+					},
+				},
 				Products: []config.Product{
 					{Product: "Compute Engine", Info: "Server - which will run mongodb"},
 					{Product: "Compute Engine", Info: "Client - which will run a custom go application"},
 				},
 			},
 		},
+		"multi-project": {
+			in: Meta{
+				Github: github.Repo{Name: "deploystack-shared-vpc", Owner: "GoogleCloudPlatform"},
+				Terraform: terraform.Blocks{
+					{Name: "project_id", Kind: "variable", Type: "string", File: "terraform/variables.tf", Start: 1, Text: `variable "project_id" {
+  type = string
+}`},
+					{Name: "host_project_id", Kind: "variable", Type: "string", File: "terraform/variables.tf", Start: 5, Text: `variable "host_project_id" {
+  description = "The project that owns the shared network"
+  type        = string
+}`},
+					{Name: "service_project", Kind: "variable", Type: "string", File: "terraform/variables.tf", Start: 10, Text: `variable "service_project" {
+  type = string
+}`},
+					{Name: "net", Kind: "variable", Type: "string", File: "terraform/variables.tf", Start: 14, Text: `variable "net" {
+  type = string
+}`},
+					{Name: "project_ids", Kind: "variable", Type: "list(string)", File: "terraform/variables.tf", Start: 18, Text: `variable "project_ids" {
+  type = list(string)
+}`},
+					{Name: "primary_region", Kind: "variable", Type: "string", File: "terraform/variables.tf", Start: 22, Text: `variable "primary_region" {
+  default = "us-central1"
+}`},
+					{Name: "secondary_region", Kind: "variable", Type: "string", File: "terraform/variables.tf", Start: 26, Text: `variable "secondary_region" {
+  description = "Where the replica runs"
+  type        = string
+}`},
+					{Name: "secondary_zone", Kind: "variable", Type: "string", File: "terraform/variables.tf", Start: 31, Text: `variable "secondary_zone" {
+  type = string
+}`},
+					{Name: "where", Kind: "variable", Type: "string", File: "terraform/variables.tf", Start: 35, Text: `variable "where" {
+  type = string
+}`},
+					{Name: "vpc", Kind: "managed", Type: "google_compute_network", File: "terraform/main.tf", Start: 1, Text: `resource "google_compute_network" "vpc" {
+  name    = "vpc"
+  project = var.net
+}`, Attr: map[string]string{"name": `"vpc"`, "project": "var.net"}},
+					{Name: "app", Kind: "managed", Type: "google_cloud_run_service", File: "terraform/main.tf", Start: 6, Text: `resource "google_cloud_run_service" "app" {
+  name     = "app"
+  project  = var.project_id
+  location = var.primary_region
+}`, Attr: map[string]string{"name": `"app"`, "project": "var.project_id", "location": "var.primary_region"}},
+					{Name: "subnet", Kind: "managed", Type: "google_compute_subnetwork", File: "terraform/main.tf", Start: 12, Text: `resource "google_compute_subnetwork" "subnet" {
+  name   = "subnet"
+  region = var.where
+}`, Attr: map[string]string{"name": `"subnet"`, "region": "var.where"}},
+				},
+			},
+			want: config.Config{
+				SchemaVersion: config.CurrentSchemaVersion,
+				Title:         "Shared Vpc",
+				Name:          "shared-vpc",
+				Project:       true,
+				RegionType:    "run",
+				PathTerraform: "terraform",
+				Projects: config.Projects{
+					Items: []config.Project{
+						{Name: "host_project_id", UserPrompt: "The project that owns the shared network"},
+						{Name: "service_project", UserPrompt: "Choose the service project to use for this application."},
+						{Name: "net", UserPrompt: "Choose the net to use for this application."},
+					},
+				},
+				CustomSettings: config.Customs{
+					{Name: "project_ids", Type: "list(string)"},
+					{Name: "primary_region", Type: "string", Default: "us-central1", Picker: config.PickerRegion},
+					{Name: "secondary_region", Type: "string", Description: "Where the replica runs", Picker: config.PickerRegion},
+					{Name: "secondary_zone", Type: "string", Picker: config.PickerZone},
+					{Name: "where", Type: "string", Picker: config.PickerRegion},
+				},
+				Products: []config.Product{
+					{Product: "Compute Engine"},
+					{Product: "Cloud Run"},
+				},
+			},
+		},
+		"modules": {
+			in: Meta{
+				Github: github.Repo{Name: "deploystack-modules-test", Owner: "GoogleCloudPlatform"},
+				Terraform: terraform.Blocks{
+					{Name: "project_id", Kind: "variable", Type: "string", File: "variables.tf", Start: 1, Text: `variable "project_id" {
+  type = string
+}`},
+					{Name: "database", Kind: "module", Type: "./modules/database", File: "main.tf", Start: 1, Text: `module "database" {
+  source     = "./modules/database"
+  db_project = var.project_id
+}`, Attr: map[string]string{"source": `"./modules/database"`, "db_project": "var.project_id"}},
+					{Name: "db_project", Kind: "variable", Type: "string", File: "modules/database/main.tf", Start: 1, Module: "module.database", Text: `variable "db_project" {
+  type = string
+}`},
+					{Name: "main", Kind: "managed", Type: "google_sql_database_instance", File: "modules/database/main.tf", Start: 5, Module: "module.database", Text: `resource "google_sql_database_instance" "main" {
+  project = var.db_project
+}`, Attr: map[string]string{"project": "var.db_project"}},
+					{Name: "assets", Kind: "managed", Type: "google_storage_bucket", File: "modules/database/storage/main.tf", Start: 1, Module: "module.database.module.storage", Text: `resource "google_storage_bucket" "assets" {
+  name = "assets"
+}`},
+				},
+			},
+			want: config.Config{
+				SchemaVersion: config.CurrentSchemaVersion,
+				Title:         "Modules Test",
+				Name:          "modules-test",
+				Project:       true,
+				PathTerraform: ".",
+				Products: []config.Product{
+					{Product: "Cloud SQL"},
+					{Product: "Cloud Storage"},
+				},
+			},
+		},
+		"variable-metadata": {
+			in: Meta{
+				Github: github.Repo{Name: "deploystack-metadata-test", Owner: "GoogleCloudPlatform"},
+				Terraform: terraform.Blocks{
+					{
+						Name:  "region",
+						Kind:  "variable",
+						Type:  "string",
+						File:  "terraform/variables.tf",
+						Start: 1,
+						Text: `variable "region" {
+  description = "Where to run"
+  default     = "europe-west1"
+}`,
+					},
+					{
+						Name:  "tier",
+						Kind:  "variable",
+						Type:  "string",
+						File:  "terraform/variables.tf",
+						Start: 6,
+						Text: `variable "tier" {
+  description = "Which tier of service to use"
+  type        = string
+  default     = "standard"
+
+  validation {
+    condition     = contains(["basic", "standard"], var.tier)
+    error_message = "Pick basic or standard."
+  }
+}`,
+					},
+					{
+						Name:  "db_password",
+						Kind:  "variable",
+						Type:  "string",
+						File:  "terraform/variables.tf",
+						Start: 17,
+						Text: `variable "db_password" {
+  description = "The password for the default database user"
+  type        = string
+  sensitive   = true
+}`,
+					},
+				},
+			},
+			want: config.Config{
+				SchemaVersion: config.CurrentSchemaVersion,
+				Title:         "Metadata Test",
+				Name:          "metadata-test",
+				Region:        true,
+				RegionType:    "compute",
+				RegionDefault: "europe-west1",
+				PathTerraform: "terraform",
+				CustomSettings: config.Customs{
+					{
+						Name:        "tier",
+						Description: "Which tier of service to use",
+						Default:     "standard",
+						Type:        "string",
+						Options:     []string{"basic", "standard"},
+					},
+					{
+						Name:        "db_password",
+						Description: "The password for the default database user",
+						Type:        "string",
+						Secret:      true,
+					},
+				},
+			},
+		},
 	}
 
 	for name, tc := range tests {
@@ -972,6 +1161,129 @@ func TestSuggest(t *testing.T) {
 	}
 }
 
+func TestSuggestConfig(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		want   string
+	}{
+		"described": {
+			config: "title: Stack\ndescription: A stack\n",
+			want:   "A stack",
+		},
+		"undescribed": {
+			config: "title: Stack\n",
+			want:   suggestedDescription(github.Repo{}),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			files := map[string]string{
+				".deploystack/deploystack.yaml": tc.config,
+				"terraform/main.tf":             "variable \"project_id\" {\n  type = string\n}\n",
+			}
+
+			for name, content := range files {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatalf("could not set up test: %s", err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatalf("could not set up test: %s", err)
+				}
+			}
+
+			_, got, err := suggestConfig(dir, github.Repo{})
+			if err != nil {
+				t.Fatalf("expected no error, got %s", err)
+			}
+
+			if !reflect.DeepEqual(tc.want, got.Description) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got.Description)
+			}
+		})
+	}
+}
+
+func TestSuggestDiff(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		".deploystack/deploystack.yaml": `title: Existing Stack
+name: existing
+collect_project: true
+collect_region: true
+region_type: run
+region_default: us-east1
+custom_settings:
+  - name: nodes
+    description: How many nodes
+    default: "3"
+`,
+		"terraform/main.tf": `variable "project_id" {
+  type = string
+}
+
+variable "region" {
+  type = string
+}
+
+variable "zone" {
+  type = string
+}
+
+variable "nodes" {
+  type = number
+}
+
+variable "machine_type" {
+  type = string
+}
+
+resource "google_compute_instance" "main" {
+  name = "main"
+}
+`,
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("could not set up test: %s", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("could not set up test: %s", err)
+		}
+	}
+
+	want := []string{
+		fmt.Sprintf("~ description:  -> %s", suggestedDescription(github.Repo{})),
+		"~ collect_zone: false -> true",
+		`+ custom_settings.machine_type: {"name":"machine_type","description":"","default":"","type":"string","options":null,"prepend_project":false}`,
+		`+ products.Compute Engine: {"info":"","product":"Compute Engine"}`,
+	}
+
+	changes, err := SuggestDiff(dir, github.Repo{})
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	got := []string{}
+	for _, v := range changes {
+		got = append(got, v.String())
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected: %+v, got: %+v", want, got)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".deploystack", "messages")); err == nil {
+		t.Fatalf("expected SuggestDiff not to write anything")
+	}
+}
+
 func TestAttemptRepo(t *testing.T) {
 	tempName, err := os.MkdirTemp("", "testrepos")
 	defer os.RemoveAll(tempName)
@@ -1091,3 +1403,39 @@ func TestWriteConfig(t *testing.T) {
 		t.Fatalf("writeconfig: failed to write file %s", err)
 	}
 }
+
+func TestInitOverride(t *testing.T) {
+	tests := map[string]struct {
+		env  map[string]string
+		want string
+		err  bool
+	}{
+		"setting":   {env: map[string]string{"DEPLOYSTACK_SETTING_NODES": "2"}, want: "2"},
+		"tf var":    {env: map[string]string{"TF_VAR_nodes": "1"}, want: "1"},
+		"not set":   {env: map[string]string{}, want: ""},
+		"invalid":   {env: map[string]string{"DEPLOYSTACK_SETTING_NODES": "5"}, err: true},
+		"preferred": {env: map[string]string{"TF_VAR_nodes": "1", "DEPLOYSTACK_SETTING_NODES": "3"}, want: "3"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			s, err := Init("testdata/dsfolders/customs_options")
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected: no error got: %+v", err)
+			}
+
+			compareValues("nodes", tc.want, s.GetSetting("nodes"), t)
+			compareValues("overridden", tc.want != "", s.Overridden("nodes"), t)
+		})
+	}
+}
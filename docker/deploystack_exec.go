@@ -20,12 +20,15 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	_ "embed"
 
 	"github.com/GoogleCloudPlatform/deploystack"
+	"github.com/GoogleCloudPlatform/deploystack/config"
 	"github.com/GoogleCloudPlatform/deploystack/github"
+	"github.com/GoogleCloudPlatform/deploystack/terraform"
 	"github.com/GoogleCloudPlatform/deploystack/tui"
 )
 
@@ -41,6 +44,16 @@ func main() {
 	version := flag.Bool("version", false, "Shows version information")
 	repo := flag.String("repo", "", "The name only of a Google Cloud Platform repo to download")
 	suggest := flag.Bool("suggest", false, "Weather or not you want DeployStack to recommend a config")
+	suggestDiff := flag.Bool("diff", false, "With -suggest, print the changes the recommended config would make instead of writing it")
+	lint := flag.Bool("lint", false, "Check every DeployStack config in the current directory for problems")
+	schema := flag.Bool("schema", false, "Prints the JSON Schema for DeployStack configs")
+	migrate := flag.Bool("migrate", false, "Rewrite the DeployStack config in the current directory to the latest format")
+	profile := flag.String("profile", "", "The name of the environment profile in the config to install")
+	answers := flag.String("answers", "", "A yaml or json file of answers to run the stack without the interactive ui")
+	strict := flag.Bool("strict", false, "With -answers, stop on problems like missing permissions instead of warning about them")
+	createProjects := flag.Bool("create-projects", false, "With -answers, create projects that are answered but don't exist yet")
+	locale := flag.String("locale", "", "The locale to show prompts and messages in, instead of the one from LANG")
+	summary := flag.Bool("summary", false, "Shows the outputs and success message of the stack once it is installed")
 
 	flag.Parse()
 
@@ -50,6 +63,57 @@ func main() {
 		return
 	}
 
+	if *schema {
+		fmt.Printf("%s", config.PublishedSchema())
+		return
+	}
+
+	if *lint {
+		wd, err := os.Getwd()
+		if err != nil {
+			tui.Fatal(err)
+		}
+
+		issues, err := config.Lint(wd)
+		if err != nil {
+			tui.Fatal(err)
+		}
+
+		for _, v := range issues {
+			if rel, err := filepath.Rel(wd, v.File); err == nil {
+				v.File = rel
+			}
+			fmt.Printf("%s\n", v)
+		}
+
+		if len(issues) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *migrate {
+		wd, err := os.Getwd()
+		if err != nil {
+			tui.Fatal(err)
+		}
+
+		target, warnings, err := config.Rewrite(wd)
+		if err != nil {
+			tui.Fatal(err)
+		}
+
+		for _, v := range warnings {
+			fmt.Printf("%s\n", v)
+		}
+
+		if rel, err := filepath.Rel(wd, target); err == nil {
+			target = rel
+		}
+		fmt.Printf("Wrote %s at schema_version %d\n", target, config.CurrentSchemaVersion)
+		return
+	}
+
 	if *repo != "" {
 		wd, err := os.Getwd()
 		if err != nil {
@@ -85,6 +149,18 @@ func main() {
 			tui.Fatal(err)
 		}
 
+		if *suggestDiff {
+			changes, err := deploystack.SuggestDiff(wd, github.Repo{})
+			if err != nil {
+				tui.Fatal(err)
+			}
+
+			for _, v := range changes {
+				fmt.Printf("%s\n", v)
+			}
+			return
+		}
+
 		if err := deploystack.WriteConfig(wd, github.Repo{}); err != nil {
 			tui.Fatal(err)
 		}
@@ -119,6 +195,22 @@ func main() {
 		}
 	}
 
+	for _, v := range s.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", v)
+	}
+
+	if *locale != "" {
+		if err := s.Localize(*locale); err != nil {
+			tui.Fatal(err)
+		}
+	}
+
+	if *profile != "" {
+		if err := s.ApplyProfile(*profile); err != nil {
+			tui.Fatal(err)
+		}
+	}
+
 	if *verify {
 		fmt.Printf("%s|%s|%s\n", s.Config.PathTerraform, s.Config.PathMessages, s.Config.PathScripts)
 		return
@@ -129,6 +221,34 @@ func main() {
 		return
 	}
 
+	if *summary {
+		dir := filepath.Join(s.Config.Getwd(), s.Config.PathTerraform)
+		outputs, err := terraform.ReadOutputValues(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+		}
+
+		if err := tui.Summary(s, outputs); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+		}
+		return
+	}
+
+	if *answers != "" {
+		a, err := tui.ReadAnswers(*answers)
+		if err != nil {
+			tui.Fatal(err)
+		}
+		a.Override(os.Environ())
+
+		opts := tui.HeadlessOptions{Strict: *strict, CreateProjects: *createProjects}
+		if err := tui.RunHeadless(s, a, opts, false); err != nil {
+			fmt.Printf("%s", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	tui.Run(s, false)
 
 }
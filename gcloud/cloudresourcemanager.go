@@ -268,3 +268,52 @@ func (c *Client) ProjectExists(project string) bool {
 
 	return true
 }
+
+// testPermissionsLimit is how many permissions testIamPermissions will check
+// in one call
+const testPermissionsLimit = 100
+
+// ProjectMissingIAMPermissions returns which of the input permissions the
+// current user doesn't have on a project, in the order they were asked about
+func (c *Client) ProjectMissingIAMPermissions(project string, permissions []string) ([]string, error) {
+	resp := []string{}
+	svc, err := c.getCloudResourceManagerService()
+	if err != nil {
+		return resp, err
+	}
+
+	granted := map[string]bool{}
+	for _, batch := range batchStrings(permissions, testPermissionsLimit) {
+		req := cloudresourcemanager.TestIamPermissionsRequest{Permissions: batch}
+
+		results, err := svc.Projects.TestIamPermissions(project, &req).Do()
+		if err != nil {
+			return resp, fmt.Errorf("cannot test iam permissions for project (%s): %s", project, err)
+		}
+
+		for _, v := range results.Permissions {
+			granted[v] = true
+		}
+	}
+
+	for _, v := range permissions {
+		if !granted[v] {
+			resp = append(resp, v)
+		}
+	}
+
+	return resp, nil
+}
+
+// batchStrings splits in into slices of no more than size
+func batchStrings(in []string, size int) [][]string {
+	result := [][]string{}
+	for start := 0; start < len(in); start += size {
+		end := start + size
+		if end > len(in) {
+			end = len(in)
+		}
+		result = append(result, in[start:end])
+	}
+	return result
+}
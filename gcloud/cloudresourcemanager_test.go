@@ -99,6 +99,69 @@ func TestGetProjectParent(t *testing.T) {
 	}
 }
 
+func TestProjectMissingIAMPermissions(t *testing.T) {
+	t.Parallel()
+	c := NewClient(ctx, defaultUserAgent)
+	tests := map[string]struct {
+		input []string
+		want  []string
+	}{
+		"granted": {
+			input: []string{"compute.instances.create", "storage.buckets.create"},
+			want:  []string{},
+		},
+		"empty": {
+			input: []string{},
+			want:  []string{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := c.ProjectMissingIAMPermissions(creds["project_id"], tc.input)
+			if err != nil {
+				t.Fatalf("expected: no error, got: %v", err)
+			}
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("expected: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestBatchStrings(t *testing.T) {
+	tests := map[string]struct {
+		input []string
+		size  int
+		want  [][]string
+	}{
+		"even": {
+			input: []string{"a", "b", "c", "d"},
+			size:  2,
+			want:  [][]string{{"a", "b"}, {"c", "d"}},
+		},
+		"remainder": {
+			input: []string{"a", "b", "c"},
+			size:  2,
+			want:  [][]string{{"a", "b"}, {"c"}},
+		},
+		"empty": {
+			input: []string{},
+			size:  2,
+			want:  [][]string{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := batchStrings(tc.input, tc.size)
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("expected: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+}
+
 func TestGetProjects(t *testing.T) {
 	t.Parallel()
 	c := NewClient(ctx, defaultUserAgent)
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 
 	domains "cloud.google.com/go/domains/apiv1beta1"
 	scheduler "cloud.google.com/go/scheduler/apiv1beta1"
@@ -91,7 +92,10 @@ type Client struct {
 	userAgent       string
 	opts            option.ClientOption
 	enabledServices map[string]bool
-	cache           map[string]interface{}
+	// servicesMu guards enabledServices and the Service Usage service, so
+	// services can be enabled in parallel
+	servicesMu *sync.Mutex
+	cache      map[string]interface{}
 }
 
 // NewClient initiates a new gcloud Client
@@ -101,6 +105,7 @@ func NewClient(ctx context.Context, ua string) Client {
 	c.userAgent = ua
 	c.opts = option.WithCredentialsFile("")
 	c.enabledServices = make(map[string]bool)
+	c.servicesMu = &sync.Mutex{}
 	c.cache = map[string]interface{}{}
 	return c
 }
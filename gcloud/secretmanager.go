@@ -16,8 +16,11 @@ package gcloud
 
 import (
 	b64 "encoding/base64"
+	"errors"
 	"fmt"
+	"net/http"
 
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/secretmanager/v1"
 )
 
@@ -68,13 +71,47 @@ func (c *Client) SecretCreate(project, name, payload string) error {
 		return fmt.Errorf("failed to create secret: %s", err)
 	}
 
+	return c.addSecretVersion(svc, result.Name, payload)
+}
+
+// SecretExists reports whether a secret is already in a project
+func (c *Client) SecretExists(project, name string) (bool, error) {
+	svc, err := c.getSecretManagerService(project)
+	if err != nil {
+		return false, err
+	}
+
+	secret := fmt.Sprintf("projects/%s/secrets/%s", project, name)
+	if _, err := svc.Projects.Secrets.Get(secret).Do(); err != nil {
+		var gerr *googleapi.Error
+		if errors.As(err, &gerr) && gerr.Code == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("could not get secret (%s) in project (%s): %s", name, project, err)
+	}
+
+	return true, nil
+}
+
+// SecretVersionAdd adds a payload to a secret that already exists, as its
+// latest version
+func (c *Client) SecretVersionAdd(project, name, payload string) error {
+	svc, err := c.getSecretManagerService(project)
+	if err != nil {
+		return err
+	}
+
+	return c.addSecretVersion(svc, fmt.Sprintf("projects/%s/secrets/%s", project, name), payload)
+}
+
+func (c *Client) addSecretVersion(svc *secretmanager.Service, secret, payload string) error {
 	version := &secretmanager.AddSecretVersionRequest{
 		Payload: &secretmanager.SecretPayload{
 			Data: b64.URLEncoding.EncodeToString([]byte(payload)),
 		},
 	}
 
-	if _, err := svc.Projects.Secrets.AddVersion(result.Name, version).Do(); err != nil {
+	if _, err := svc.Projects.Secrets.AddVersion(secret, version).Do(); err != nil {
 		return fmt.Errorf("failed to create secret versiopn: %s", err)
 	}
 
@@ -41,10 +41,25 @@ func TestSecretCreate(t *testing.T) {
 				t.Fatalf("expected: %+v, got: %+v", tc.err, err)
 			}
 
+			exists, err := c.SecretExists(tc.project, tc.name)
+			if err != nil || !exists {
+				t.Fatalf("expected: secret to exist, got: %t %+v", exists, err)
+			}
+
+			err = c.SecretVersionAdd(tc.project, tc.name, tc.payload+"again")
+			if err != tc.err {
+				t.Fatalf("expected: %+v, got: %+v", tc.err, err)
+			}
+
 			err = c.SecretDelete(tc.project, tc.name)
 			if err != tc.err {
 				t.Fatalf("expected: no error got: %+v", err)
 			}
+
+			exists, err = c.SecretExists(tc.project, tc.name)
+			if err != nil || exists {
+				t.Fatalf("expected: secret to be gone, got: %t %+v", exists, err)
+			}
 		})
 	}
 }
@@ -63,6 +78,21 @@ func TestSecretsBadProject(t *testing.T) {
 			},
 			err: fmt.Errorf("error activating service for polling"),
 		},
+		"SecretExists": {
+			servicefunc: func() error {
+				c := NewClient(context.Background(), "testing")
+				_, err := c.SecretExists(bad, "")
+				return err
+			},
+			err: fmt.Errorf("error activating service for polling"),
+		},
+		"SecretVersionAdd": {
+			servicefunc: func() error {
+				c := NewClient(context.Background(), "testing")
+				return c.SecretVersionAdd(bad, "", "")
+			},
+			err: fmt.Errorf("error activating service for polling"),
+		},
 		"SecretDelete": {
 			servicefunc: func() error {
 				c := NewClient(context.Background(), "testing")
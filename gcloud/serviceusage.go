@@ -17,6 +17,7 @@ package gcloud
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"google.golang.org/api/serviceusage/v1"
@@ -55,7 +56,49 @@ const (
 	Vault
 )
 
+// namedServiceStart is where Services made by NewService for names without a
+// constant of their own are numbered from
+const namedServiceStart Service = 1 << 32
+
+var (
+	namedServicesMu sync.Mutex
+	namedServices   = map[Service]string{}
+	serviceNames    = map[string]Service{}
+)
+
+// NewService returns the Service for a service name like
+// sqladmin.googleapis.com, so services that don't have a constant of their own
+// can still be enabled. Names that do have a constant return it.
+func NewService(name string) Service {
+	for s := Compute; s <= Vault; s++ {
+		if s.String() == name {
+			return s
+		}
+	}
+
+	namedServicesMu.Lock()
+	defer namedServicesMu.Unlock()
+
+	if s, ok := serviceNames[name]; ok {
+		return s
+	}
+
+	s := namedServiceStart + Service(len(namedServices))
+	namedServices[s] = name
+	serviceNames[name] = s
+
+	return s
+}
+
 func (s Service) String() string {
+	if s >= namedServiceStart {
+		namedServicesMu.Lock()
+		defer namedServicesMu.Unlock()
+		if name, ok := namedServices[s]; ok {
+			return name
+		}
+	}
+
 	apistring := "googleapis.com"
 	svc := ""
 	switch s {
@@ -99,6 +142,9 @@ var ErrorServiceNotExistOrNotAllowed = fmt.Errorf("Not found or permission denie
 var ErrorProjectRequired = fmt.Errorf("Project may not be an empty string")
 
 func (c *Client) getServiceUsageService() (*serviceusage.Service, error) {
+	c.servicesMu.Lock()
+	defer c.servicesMu.Unlock()
+
 	var err error
 	svc := c.services.serviceUsage
 
@@ -120,7 +166,7 @@ func (c *Client) getServiceUsageService() (*serviceusage.Service, error) {
 // ServiceEnable enable a service in the selected project so that query calls
 // to various lists will work.
 func (c *Client) ServiceEnable(project string, service Service) error {
-	if _, ok := c.enabledServices[service.String()]; ok {
+	if c.serviceEnabled(service) {
 		return nil
 	}
 
@@ -135,7 +181,7 @@ func (c *Client) ServiceEnable(project string, service Service) error {
 	}
 
 	if enabled {
-		c.enabledServices[service.String()] = true
+		c.markServiceEnabled(service)
 		return nil
 	}
 
@@ -152,17 +198,29 @@ func (c *Client) ServiceEnable(project string, service Service) error {
 				return err
 			}
 			if enabled {
-				c.enabledServices[service.String()] = true
+				c.markServiceEnabled(service)
 				return nil
 			}
 			time.Sleep(1 * time.Second)
 		}
 	}
 
-	c.enabledServices[service.String()] = true
+	c.markServiceEnabled(service)
 	return nil
 }
 
+func (c *Client) serviceEnabled(service Service) bool {
+	c.servicesMu.Lock()
+	defer c.servicesMu.Unlock()
+	return c.enabledServices[service.String()]
+}
+
+func (c *Client) markServiceEnabled(service Service) {
+	c.servicesMu.Lock()
+	defer c.servicesMu.Unlock()
+	c.enabledServices[service.String()] = true
+}
+
 // ServiceIsEnabled checks to see if the existing service is already enabled
 // in the project we are trying to enable it in.
 func (c *Client) ServiceIsEnabled(project string, service Service) (bool, error) {
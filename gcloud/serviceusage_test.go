@@ -99,3 +99,34 @@ func TestServiceDisable(t *testing.T) {
 		})
 	}
 }
+
+func TestNewService(t *testing.T) {
+	tests := map[string]struct {
+		in   string
+		want string
+	}{
+		"constant": {in: "compute.googleapis.com", want: "compute.googleapis.com"},
+		"named":    {in: "sqladmin.googleapis.com", want: "sqladmin.googleapis.com"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := NewService(tc.in)
+			if got.String() != tc.want {
+				t.Fatalf("expected: %s, got: %s", tc.want, got)
+			}
+
+			if again := NewService(tc.in); again != got {
+				t.Fatalf("expected the same service twice, got: %d and %d", got, again)
+			}
+		})
+	}
+
+	if NewService("compute.googleapis.com") != Compute {
+		t.Fatalf("expected a name with a constant to return the constant")
+	}
+
+	if FAKESERVICE.String() != "unknown.googleapis.com" {
+		t.Fatalf("expected an unregistered service to be unknown, got: %s", FAKESERVICE)
+	}
+}
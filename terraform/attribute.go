@@ -0,0 +1,276 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// attributes are the top level attributes and nested blocks of a block
+type attributes struct {
+	// exprs is the text of each attribute's expression, as it would be
+	// written in native syntax
+	exprs map[string]string
+	// values is the value of each attribute whose expression is a literal
+	values map[string]string
+	// nested is the names of the nested blocks, in the order they appear
+	nested []string
+}
+
+// readAttributes collects the attributes of a block body parsed from src.
+// Nested blocks are only found in native syntax; in JSON they can't be told
+// apart from object attributes without the provider's schema.
+func readAttributes(body hcl.Body, src []byte) attributes {
+	result := attributes{}
+
+	exprs := map[string]hcl.Expression{}
+	template := false
+
+	if native, ok := body.(*hclsyntax.Body); ok {
+		for name, attr := range native.Attributes {
+			exprs[name] = attr.Expr
+		}
+
+		for _, b := range native.Blocks {
+			name := nestedName(b)
+			if !contains(result.nested, name) {
+				result.nested = append(result.nested, name)
+			}
+		}
+	} else {
+		attrs, diags := body.JustAttributes()
+		if diags.HasErrors() {
+			return result
+		}
+		for name, attr := range attrs {
+			exprs[name] = attr.Expr
+		}
+		// Strings in JSON are templates that only evaluate with a context
+		template = true
+	}
+
+	for name, expr := range exprs {
+		raw := string(expr.Range().SliceBytes(src))
+		text := raw
+		if template {
+			text = jsonExpression(raw)
+		}
+
+		if result.exprs == nil {
+			result.exprs = map[string]string{}
+		}
+		result.exprs[name] = text
+
+		if template && (strings.Contains(raw, "${") || strings.Contains(raw, "%{")) {
+			continue
+		}
+
+		val, diags := expr.Value(nil)
+		if diags.HasErrors() || !val.IsWhollyKnown() || val.IsNull() {
+			continue
+		}
+
+		if result.values == nil {
+			result.values = map[string]string{}
+		}
+		result.values[name] = renderValue(val)
+	}
+
+	return result
+}
+
+// nestedName is the name of a nested block, which for a dynamic block is its
+// label rather than its type
+func nestedName(b *hclsyntax.Block) string {
+	if b.Type == "dynamic" && len(b.Labels) > 0 {
+		return b.Labels[0]
+	}
+	return b.Type
+}
+
+// nestedBody is the body of a nested block, which for a dynamic block is the
+// body of its content block
+func nestedBody(b *hclsyntax.Block) (*hclsyntax.Body, bool) {
+	if b.Type != "dynamic" {
+		return b.Body, true
+	}
+	for _, v := range b.Body.Blocks {
+		if v.Type == "content" {
+			return v.Body, true
+		}
+	}
+	return nil, false
+}
+
+// jsonExpression turns the text of a JSON value that is a single
+// interpolation, like "${var.region}", into the expression it wraps
+func jsonExpression(text string) string {
+	if !strings.HasPrefix(text, `"${`) || !strings.HasSuffix(text, `}"`) {
+		return text
+	}
+
+	inner := text[3 : len(text)-2]
+	if strings.Contains(inner, "${") || strings.Contains(inner, `"`) {
+		return text
+	}
+
+	return inner
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sameExpression compares two expressions, ignoring spacing
+func sameExpression(a, b string) bool {
+	return strings.Join(strings.Fields(a), "") == strings.Join(strings.Fields(b), "")
+}
+
+// HasAttr returns true if the block sets the attribute name. If expr isn't
+// empty the attribute's expression, or its value if it is a literal, has
+// to be expr as well.
+func (b Block) HasAttr(name, expr string) bool {
+	text, ok := b.Attr[name]
+	if !ok {
+		return false
+	}
+
+	if expr == "" || sameExpression(text, expr) {
+		return true
+	}
+
+	value, ok := b.Values[name]
+	return ok && value == expr
+}
+
+// VariableRef returns the name of the variable the attribute name is set to,
+// for attributes like project = var.project_id
+func (b Block) VariableRef(name string) (string, bool) {
+	text, ok := b.Attr[name]
+	if !ok {
+		return "", false
+	}
+
+	expr, diags := hclsyntax.ParseExpression([]byte(text), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return "", false
+	}
+
+	ref, ok := expr.(*hclsyntax.ScopeTraversalExpr)
+	if !ok || len(ref.Traversal) != 2 || ref.Traversal.RootName() != "var" {
+		return "", false
+	}
+
+	attr, ok := ref.Traversal[1].(hcl.TraverseAttr)
+	if !ok {
+		return "", false
+	}
+
+	return attr.Name, true
+}
+
+// SearchAttr returns the blocks that set the attribute name to expr, like
+// all of the resources with location = var.region. Expressions are compared
+// ignoring spacing, literals can be matched by their value, and an empty
+// expr matches any block that sets the attribute.
+func (b Blocks) SearchAttr(name, expr string) Blocks {
+	result := Blocks{}
+	for _, v := range b {
+		if v.HasAttr(name, expr) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// SearchNested returns the blocks that have a nested block called name
+func (b Blocks) SearchNested(name string) Blocks {
+	result := Blocks{}
+	for _, v := range b {
+		if contains(v.Nested, name) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// NestedBlock returns the nested block at path, like boot_disk then
+// initialize_params, with its attributes read the same way as the block's.
+// Only blocks in native syntax have nested blocks to find. A dynamic block is
+// found by its label, and its attributes are those of its content block.
+func (b Block) NestedBlock(path ...string) (Block, bool) {
+	src := []byte(b.Text)
+	f, diags := hclsyntax.ParseConfig(src, b.File, hcl.InitialPos)
+	if diags.HasErrors() || len(path) == 0 {
+		return Block{}, false
+	}
+
+	blocks := f.Body.(*hclsyntax.Body).Blocks
+	if len(blocks) != 1 {
+		return Block{}, false
+	}
+
+	current := blocks[0].Body
+	for _, name := range path {
+		var next *hclsyntax.Body
+		for _, v := range current.Blocks {
+			if nestedName(v) != name {
+				continue
+			}
+			if body, ok := nestedBody(v); ok {
+				next = body
+				break
+			}
+		}
+		if next == nil {
+			return Block{}, false
+		}
+		current = next
+	}
+
+	result := Block{Name: path[len(path)-1], Kind: "nested", File: b.File, Module: b.Module}
+	result.setAttributes(readAttributes(current, src))
+
+	return result, true
+}
+
+// VariableRefs returns the variables that the attributes called name of the
+// blocks are set to, sorted and without duplicates
+func (b Blocks) VariableRefs(names ...string) []string {
+	seen := map[string]bool{}
+	for _, v := range b {
+		for _, name := range names {
+			if ref, ok := v.VariableRef(name); ok {
+				seen[ref] = true
+			}
+		}
+	}
+
+	result := []string{}
+	for k := range seen {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
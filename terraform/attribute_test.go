@@ -0,0 +1,350 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadAttributes(t *testing.T) {
+	tests := map[string]struct {
+		in        string
+		blockType string
+		labels    []string
+		want      attributes
+	}{
+		"nested": {
+			in:        "attributes/main.tf",
+			blockType: "resource",
+			labels:    []string{"google_compute_instance", "web"},
+			want: attributes{
+				exprs: map[string]string{
+					"name":         `"${var.basename}-web"`,
+					"machine_type": `"e2-small"`,
+					"zone":         "var.zone",
+					"tags":         `["http", "https"]`,
+				},
+				values: map[string]string{
+					"machine_type": "e2-small",
+					"tags":         "http,https",
+				},
+				nested: []string{"boot_disk", "network_interface", "attached_disk"},
+			},
+		},
+		"data": {
+			in:        "attributes/main.tf",
+			blockType: "data",
+			labels:    []string{"google_project", "project"},
+			want: attributes{
+				exprs: map[string]string{"project_id": "var.project_id"},
+			},
+		},
+		"json": {
+			in:        "attributes/main.tf.json",
+			blockType: "resource",
+			labels:    []string{"google_storage_bucket", "assets"},
+			want: attributes{
+				exprs: map[string]string{
+					"name":          `"assets-${var.basename}"`,
+					"location":      "var.region",
+					"force_destroy": "true",
+					"labels":        `{"app": "web"}`,
+				},
+				values: map[string]string{
+					"force_destroy": "true",
+					"labels":        "app=web",
+				},
+			},
+		},
+		"empty": {
+			in:        "tricky/main.tf.json",
+			blockType: "module",
+			labels:    []string{"network"},
+			want: attributes{
+				exprs:  map[string]string{"source": `"./modules/network"`},
+				values: map[string]string{"source": "./modules/network"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(testFilesDir, "terraform", tc.in)
+			src, err := getBlockSource(file, tc.blockType, tc.labels...)
+			if err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			if !reflect.DeepEqual(tc.want, src.attrs) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, src.attrs)
+			}
+		})
+	}
+}
+
+func TestJSONExpression(t *testing.T) {
+	tests := map[string]struct {
+		in   string
+		want string
+	}{
+		"reference":     {in: `"${var.region}"`, want: "var.region"},
+		"function":      {in: `"${lower(var.name)}"`, want: "lower(var.name)"},
+		"template":      {in: `"${var.a}-${var.b}"`, want: `"${var.a}-${var.b}"`},
+		"string inside": {in: `"${lower("A")}"`, want: `"${lower("A")}"`},
+		"literal":       {in: `"us-central1"`, want: `"us-central1"`},
+		"number":        {in: `3`, want: `3`},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := jsonExpression(tc.in)
+			if tc.want != got {
+				t.Fatalf("expected: %s, got: %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestBlockHasAttr(t *testing.T) {
+	b := Block{
+		Name:   "assets",
+		Attr:   map[string]string{"location": "var.region", "storage_class": `"STANDARD"`, "labels": `{ app = "web" }`},
+		Values: map[string]string{"storage_class": "STANDARD", "labels": "app=web"},
+	}
+
+	tests := map[string]struct {
+		name string
+		expr string
+		want bool
+	}{
+		"any":        {name: "location", want: true},
+		"expression": {name: "location", expr: "var.region", want: true},
+		"spacing":    {name: "labels", expr: `{app="web"}`, want: true},
+		"value":      {name: "storage_class", expr: "STANDARD", want: true},
+		"text":       {name: "storage_class", expr: `"STANDARD"`, want: true},
+		"different":  {name: "location", expr: "var.zone"},
+		"missing":    {name: "project"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := b.HasAttr(tc.name, tc.expr)
+			if tc.want != got {
+				t.Fatalf("expected: %t, got: %t", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestBlockVariableRef(t *testing.T) {
+	b := Block{
+		Name: "web",
+		Attr: map[string]string{
+			"project": "var.project_id",
+			"zone":    " var.zone ",
+			"name":    `"${var.basename}-web"`,
+			"network": "var.networks[0]",
+			"region":  "local.region",
+		},
+	}
+
+	tests := map[string]struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		"variable": {in: "project", want: "project_id", ok: true},
+		"spacing":  {in: "zone", want: "zone", ok: true},
+		"template": {in: "name"},
+		"index":    {in: "network"},
+		"local":    {in: "region"},
+		"missing":  {in: "labels"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := b.VariableRef(tc.in)
+			if tc.ok != ok || tc.want != got {
+				t.Fatalf("expected: %s %t, got: %s %t", tc.want, tc.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestBlocksSearchAttr(t *testing.T) {
+	blocks := Blocks{
+		{Name: "run", Attr: map[string]string{"location": "var.region", "project": "var.project_id"}},
+		{Name: "bucket", Attr: map[string]string{"location": `"US"`}, Values: map[string]string{"location": "US"}},
+		{Name: "sql", Attr: map[string]string{"region": "var.region"}},
+		{Name: "project_id", Kind: "variable"},
+	}
+
+	tests := map[string]struct {
+		name string
+		expr string
+		want []string
+	}{
+		"expression": {name: "location", expr: "var.region", want: []string{"run"}},
+		"value":      {name: "location", expr: "US", want: []string{"bucket"}},
+		"any":        {name: "location", want: []string{"run", "bucket"}},
+		"none":       {name: "zone", want: []string{}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := []string{}
+			for _, v := range blocks.SearchAttr(tc.name, tc.expr) {
+				got = append(got, v.Name)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestBlocksSearchNested(t *testing.T) {
+	blocks := Blocks{
+		{Name: "web", Nested: []string{"boot_disk", "network_interface"}},
+		{Name: "db", Nested: []string{"settings"}},
+		{Name: "bucket"},
+	}
+
+	got := blocks.SearchNested("network_interface")
+	if len(got) != 1 || got[0].Name != "web" {
+		t.Fatalf("expected only web, got: %+v", got)
+	}
+}
+
+func TestBlocksVariableRefs(t *testing.T) {
+	blocks := Blocks{
+		{Name: "run", Attr: map[string]string{"project": "var.project_id"}},
+		{Name: "module", Attr: map[string]string{"project_id": "var.project_id_2"}},
+		{Name: "bucket", Attr: map[string]string{"project": "var.project_id", "location": "var.region"}},
+		{Name: "sql", Attr: map[string]string{"project": `"fixed"`}},
+	}
+
+	want := []string{"project_id", "project_id_2"}
+	got := blocks.VariableRefs("project", "project_id")
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected: %+v, got: %+v", want, got)
+	}
+}
+
+func TestBlockNestedBlock(t *testing.T) {
+	instance := Block{
+		Text: `resource "google_compute_instance" "web" {
+  machine_type = "e2-small"
+  boot_disk {
+    initialize_params {
+      size  = 50
+      type  = var.disk_type
+    }
+  }
+}`,
+	}
+
+	tests := map[string]struct {
+		in   Block
+		path []string
+		want Block
+		ok   bool
+	}{
+		"nested": {
+			in:   instance,
+			path: []string{"boot_disk", "initialize_params"},
+			want: Block{
+				Name:   "initialize_params",
+				Kind:   "nested",
+				Attr:   map[string]string{"size": "50", "type": "var.disk_type"},
+				Values: map[string]string{"size": "50"},
+			},
+			ok: true,
+		},
+		"middle": {
+			in:   instance,
+			path: []string{"boot_disk"},
+			want: Block{Name: "boot_disk", Kind: "nested", Nested: []string{"initialize_params"}},
+			ok:   true,
+		},
+		"missing": {
+			in:   instance,
+			path: []string{"boot_disk", "source"},
+		},
+		"dynamic": {
+			in: Block{
+				Text: `resource "google_compute_instance" "web" {
+  dynamic "boot_disk" {
+    for_each = var.disks
+    content {
+      auto_delete = true
+      initialize_params {
+        size = boot_disk.value.size
+      }
+    }
+  }
+}`,
+			},
+			path: []string{"boot_disk", "initialize_params"},
+			want: Block{
+				Name: "initialize_params",
+				Kind: "nested",
+				Attr: map[string]string{"size": "boot_disk.value.size"},
+			},
+			ok: true,
+		},
+		"dynamic content": {
+			in: Block{
+				Text: `resource "google_compute_instance" "web" {
+  dynamic "boot_disk" {
+    for_each = var.disks
+    content {
+      auto_delete = true
+    }
+  }
+}`,
+			},
+			path: []string{"boot_disk"},
+			want: Block{
+				Name:   "boot_disk",
+				Kind:   "nested",
+				Attr:   map[string]string{"auto_delete": "true"},
+				Values: map[string]string{"auto_delete": "true"},
+			},
+			ok: true,
+		},
+		"json": {
+			in:   Block{Text: `"web": {"boot_disk": {"initialize_params": {"size": 50}}}`},
+			path: []string{"boot_disk"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := tc.in.NestedBlock(tc.path...)
+			if ok != tc.ok {
+				t.Fatalf("expected ok %t, got %t", tc.ok, ok)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
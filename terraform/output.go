@@ -0,0 +1,150 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Output is what a Terraform output block declares
+type Output struct {
+	Name        string
+	Description string
+	Sensitive   bool
+	// Value is the text of the expression the output is set to
+	Value string
+}
+
+// Output reads what an output block declares from its attributes
+func (b Block) Output() (Output, error) {
+	o := Output{Name: b.Name}
+
+	if !b.IsOutput() {
+		return o, fmt.Errorf("%s is a %s, not an output", b.Name, b.Kind)
+	}
+
+	o.Description = b.Values["description"]
+	o.Sensitive = b.Values["sensitive"] == "true"
+	o.Value = b.Attr["value"]
+
+	return o, nil
+}
+
+// OutputValue is an output of a configuration that has been applied
+type OutputValue struct {
+	Name string
+	// Value is the value as text. Strings are as they are, and anything else
+	// is JSON.
+	Value     string
+	Type      string
+	Sensitive bool
+}
+
+// OutputValues is a slice of OutputValue
+type OutputValues []OutputValue
+
+// Get returns the output called name, or nil if there isn't one
+func (o OutputValues) Get(name string) *OutputValue {
+	for i, v := range o {
+		if v.Name == name {
+			return &o[i]
+		}
+	}
+	return nil
+}
+
+// Map returns the values of the outputs that aren't sensitive, by name
+func (o OutputValues) Map() map[string]string {
+	result := map[string]string{}
+	for _, v := range o {
+		if v.Sensitive {
+			continue
+		}
+		result[v.Name] = v.Value
+	}
+	return result
+}
+
+// outputJSON is an output as terraform output -json reports it
+type outputJSON struct {
+	Sensitive bool            `json:"sensitive"`
+	Type      json.RawMessage `json:"type"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// ParseOutputValues reads the result of terraform output -json, sorted by
+// name
+func ParseOutputValues(dat []byte) (OutputValues, error) {
+	raw := map[string]outputJSON{}
+	if err := json.Unmarshal(dat, &raw); err != nil {
+		return nil, fmt.Errorf("could not read terraform outputs: %s", err)
+	}
+
+	result := OutputValues{}
+	for name, v := range raw {
+		o := OutputValue{Name: name, Sensitive: v.Sensitive}
+
+		t := ""
+		if err := json.Unmarshal(v.Type, &t); err == nil {
+			o.Type = t
+		} else {
+			o.Type = compactJSON(v.Type)
+		}
+
+		s := ""
+		if err := json.Unmarshal(v.Value, &s); err == nil {
+			o.Value = s
+		} else if string(v.Value) != "null" {
+			o.Value = compactJSON(v.Value)
+		}
+
+		result = append(result, o)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+func compactJSON(dat []byte) string {
+	buf := bytes.Buffer{}
+	if err := json.Compact(&buf, dat); err != nil {
+		return strings.TrimSpace(string(dat))
+	}
+	return buf.String()
+}
+
+// ReadOutputValues runs terraform output -json in dir, which has to have been
+// applied already, and returns the outputs
+func ReadOutputValues(dir string) (OutputValues, error) {
+	cmd := exec.Command("terraform", fmt.Sprintf("-chdir=%s", dir), "output", "-json")
+
+	stderr := bytes.Buffer{}
+	cmd.Stderr = &stderr
+
+	dat, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not get terraform outputs: %s %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return ParseOutputValues(dat)
+}
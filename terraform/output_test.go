@@ -0,0 +1,192 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOutputExtract(t *testing.T) {
+	testdata := filepath.Join(testFilesDir, "terraform", "outputs")
+
+	blocks, err := Extract(testdata)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	want := Blocks{
+		{
+			Name: "url",
+			Text: `output "url" {
+  description = "Where the app is served"
+  value       = google_cloud_run_service.app.status[0].url
+}`,
+			Kind: "output",
+			Attr: map[string]string{
+				"description": `"Where the app is served"`,
+				"value":       "google_cloud_run_service.app.status[0].url",
+			},
+			Values: map[string]string{"description": "Where the app is served"},
+			File:   filepath.Join(testdata, "main.tf"),
+			Start:  6,
+			End:    9,
+		},
+		{
+			Name: "db_password",
+			Text: `output "db_password" {
+  value     = random_password.db.result
+  sensitive = true
+}`,
+			Kind: "output",
+			Attr: map[string]string{
+				"value":     "random_password.db.result",
+				"sensitive": "true",
+			},
+			Values: map[string]string{"sensitive": "true"},
+			File:   filepath.Join(testdata, "main.tf"),
+			Start:  11,
+			End:    14,
+		},
+	}
+
+	got := Blocks{}
+	for _, v := range *blocks {
+		if v.IsOutput() {
+			got = append(got, v)
+		}
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected: %+v, got: %+v", want, got)
+	}
+}
+
+func TestBlockOutput(t *testing.T) {
+	tests := map[string]struct {
+		in   Block
+		want Output
+		err  bool
+	}{
+		"full": {
+			in: Block{
+				Name:   "url",
+				Kind:   "output",
+				Attr:   map[string]string{"description": `"Where it is"`, "value": "google_cloud_run_service.app.status[0].url"},
+				Values: map[string]string{"description": "Where it is"},
+			},
+			want: Output{Name: "url", Description: "Where it is", Value: "google_cloud_run_service.app.status[0].url"},
+		},
+		"sensitive": {
+			in: Block{
+				Name:   "password",
+				Kind:   "output",
+				Attr:   map[string]string{"value": "random_password.db.result", "sensitive": "true"},
+				Values: map[string]string{"sensitive": "true"},
+			},
+			want: Output{Name: "password", Sensitive: true, Value: "random_password.db.result"},
+		},
+		"notoutput": {
+			in:   Block{Name: "region", Kind: "variable"},
+			want: Output{Name: "region"},
+			err:  true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.in.Output()
+
+			if tc.err != (err != nil) {
+				t.Fatalf("expected error: %t, got: %v", tc.err, err)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseOutputValues(t *testing.T) {
+	dat, err := os.ReadFile(filepath.Join(testFilesDir, "terraform", "outputs", "outputs.json"))
+	if err != nil {
+		t.Fatalf("could not read testdata: %s", err)
+	}
+
+	tests := map[string]struct {
+		in   []byte
+		want OutputValues
+		err  bool
+	}{
+		"basic": {
+			in: dat,
+			want: OutputValues{
+				{Name: "db_password", Value: "hunter2", Type: "string", Sensitive: true},
+				{Name: "nodes", Value: "3", Type: "number"},
+				{Name: "regions", Value: `["us-central1","europe-west1"]`, Type: `["list","string"]`},
+				{Name: "url", Value: "https://app-abc123-uc.a.run.app", Type: "string"},
+			},
+		},
+		"null": {
+			in:   []byte(`{"maybe": {"sensitive": false, "type": "string", "value": null}}`),
+			want: OutputValues{{Name: "maybe", Type: "string"}},
+		},
+		"empty": {
+			in:   []byte(`{}`),
+			want: OutputValues{},
+		},
+		"bad": {
+			in:  []byte(`not json`),
+			err: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseOutputValues(tc.in)
+
+			if tc.err != (err != nil) {
+				t.Fatalf("expected error: %t, got: %v", tc.err, err)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestOutputValuesMap(t *testing.T) {
+	o := OutputValues{
+		{Name: "db_password", Value: "hunter2", Sensitive: true},
+		{Name: "url", Value: "https://example.com"},
+	}
+
+	want := map[string]string{"url": "https://example.com"}
+	if got := o.Map(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected: %+v, got: %+v", want, got)
+	}
+
+	if got := o.Get("db_password"); got == nil || got.Value != "hunter2" {
+		t.Fatalf("expected to get db_password, got: %+v", got)
+	}
+
+	if got := o.Get("missing"); got != nil {
+		t.Fatalf("expected nil, got: %+v", got)
+	}
+}
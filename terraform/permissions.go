@@ -0,0 +1,108 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+//go:embed permissions.yaml
+var permissions []byte
+
+// Permissions maps the API calls Terraform makes to the IAM permissions they
+// need, so what a stack needs can be checked before it is installed
+type Permissions struct {
+	// APICalls are the permissions each of the API calls in resources.yaml
+	// needs
+	APICalls map[string][]string `yaml:"api_calls"`
+	// Roles are the permissions predefined roles grant. A permission ending
+	// in * stands for every permission that starts with it.
+	Roles map[string][]string `yaml:"roles"`
+}
+
+// NewPermissions reads in the permissions DeployStack knows about
+func NewPermissions() (Permissions, error) {
+	result := Permissions{}
+
+	if err := yaml.Unmarshal(permissions, &result); err != nil {
+		return result, fmt.Errorf("unable to convert content to Permissions: %s", err)
+	}
+
+	return result, nil
+}
+
+// Needed returns the permissions that the API calls need, sorted and without
+// repeats. Calls that aren't known need nothing.
+func (p Permissions) Needed(calls []string) []string {
+	seen := map[string]bool{}
+	for _, c := range calls {
+		for _, v := range p.APICalls[c] {
+			seen[v] = true
+		}
+	}
+
+	result := []string{}
+	for v := range seen {
+		result = append(result, v)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// RolesFor returns the predefined roles that grant permission. Roles that
+// name it exactly come first, as they tend to grant less besides.
+func (p Permissions) RolesFor(permission string) []string {
+	exact, wildcard := []string{}, []string{}
+
+	for role, granted := range p.Roles {
+		switch grants(granted, permission) {
+		case grantExact:
+			exact = append(exact, role)
+		case grantWildcard:
+			wildcard = append(wildcard, role)
+		}
+	}
+
+	sort.Strings(exact)
+	sort.Strings(wildcard)
+
+	return append(exact, wildcard...)
+}
+
+const (
+	grantNone = iota
+	grantWildcard
+	grantExact
+)
+
+// grants reports how permission is in granted, if it is at all
+func grants(granted []string, permission string) int {
+	result := grantNone
+	for _, g := range granted {
+		if g == permission {
+			return grantExact
+		}
+		if strings.HasSuffix(g, "*") && strings.HasPrefix(permission, strings.TrimSuffix(g, "*")) {
+			result = grantWildcard
+		}
+	}
+	return result
+}
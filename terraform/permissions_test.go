@@ -0,0 +1,142 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewPermissions(t *testing.T) {
+	p, err := NewPermissions()
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	resources, err := NewGCPResources()
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	for key, v := range resources {
+		for _, call := range v.APICalls {
+			call = strings.TrimSpace(call)
+			if _, ok := p.APICalls[call]; !ok {
+				t.Errorf("%s: api call %s has no permissions", key, call)
+			}
+		}
+	}
+
+	for call, needed := range p.APICalls {
+		for _, v := range needed {
+			if len(p.RolesFor(v)) == 0 {
+				t.Errorf("%s: no role grants %s", call, v)
+			}
+		}
+	}
+
+	for role := range p.Roles {
+		if !strings.HasPrefix(role, "roles/") {
+			t.Errorf("%s: is not a predefined role", role)
+		}
+	}
+}
+
+func TestPermissionsNeeded(t *testing.T) {
+	p := Permissions{
+		APICalls: map[string][]string{
+			"compute.[version].InstancesService.Insert":           {"compute.instances.create"},
+			"compute.[version].DisksService.CreateSnapshot":       {"compute.disks.createSnapshot", "compute.snapshots.create"},
+			"compute.[version].InstancesService.SetMetadata":      {"compute.instances.setMetadata"},
+			"google.cloudresourcemanager.[version].CreateProject": {},
+		},
+	}
+
+	tests := map[string]struct {
+		in   []string
+		want []string
+	}{
+		"basic": {
+			in: []string{
+				"compute.[version].InstancesService.SetMetadata",
+				"compute.[version].DisksService.CreateSnapshot",
+				"compute.[version].InstancesService.Insert",
+			},
+			want: []string{
+				"compute.disks.createSnapshot",
+				"compute.instances.create",
+				"compute.instances.setMetadata",
+				"compute.snapshots.create",
+			},
+		},
+		"repeats": {
+			in:   []string{"compute.[version].InstancesService.Insert", "compute.[version].InstancesService.Insert"},
+			want: []string{"compute.instances.create"},
+		},
+		"nothing needed": {
+			in:   []string{"google.cloudresourcemanager.[version].CreateProject", "unknown.call"},
+			want: []string{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := p.Needed(tc.in)
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPermissionsRolesFor(t *testing.T) {
+	p := Permissions{
+		Roles: map[string][]string{
+			"roles/compute.admin":            {"compute.*"},
+			"roles/compute.instanceAdmin.v1": {"compute.instances.*", "compute.disks.*"},
+			"roles/compute.imageCreator":     {"compute.images.create"},
+			"roles/compute.storageAdmin":     {"compute.images.*"},
+			"roles/storage.admin":            {"storage.*"},
+		},
+	}
+
+	tests := map[string]struct {
+		in   string
+		want []string
+	}{
+		"wildcards": {
+			in:   "compute.instances.create",
+			want: []string{"roles/compute.admin", "roles/compute.instanceAdmin.v1"},
+		},
+		"exact first": {
+			in:   "compute.images.create",
+			want: []string{"roles/compute.imageCreator", "roles/compute.admin", "roles/compute.storageAdmin"},
+		},
+		"none": {
+			in:   "pubsub.topics.create",
+			want: []string{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := p.RolesFor(tc.in)
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
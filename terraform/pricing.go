@@ -0,0 +1,224 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	_ "embed"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+//go:embed pricing.yaml
+var pricing []byte
+
+// Settings that size a Compute Engine instance when its Terraform doesn't
+// say, as collected by the instance pages. The size and type of the boot disk
+// in the instance's boot_disk block come first.
+const (
+	SettingMachineType = "instance-machine-type"
+	SettingDiskSize    = "instance-disksize"
+	SettingDiskType    = "instance-disktype"
+)
+
+const (
+	defaultDiskSize = 10
+	defaultDiskType = "pd-standard"
+)
+
+// Pricing is rough monthly list prices, used to estimate what a stack will
+// cost before it is installed
+type Pricing struct {
+	Resources map[string]Price `yaml:"resources"`
+	// MachineTypes are what Compute Engine machine types cost a month
+	MachineTypes map[string]float64 `yaml:"machine_types"`
+	// DiskTypes are what persistent disk types cost a GB a month
+	DiskTypes map[string]float64 `yaml:"disk_types"`
+	// Regions are how much more regions cost, by the start of their name
+	Regions map[string]float64 `yaml:"regions"`
+}
+
+// Price is what a type of resource costs a month, with a note about what
+// isn't included, like resources that are billed for what is used
+type Price struct {
+	Monthly float64 `yaml:"monthly"`
+	Note    string  `yaml:"note"`
+}
+
+// NewPricing reads in the prices DeployStack knows about
+func NewPricing() (Pricing, error) {
+	result := Pricing{}
+
+	if err := yaml.Unmarshal(pricing, &result); err != nil {
+		return result, fmt.Errorf("unable to convert content to Pricing: %s", err)
+	}
+
+	return result, nil
+}
+
+// CostItem is one resource of an Estimate
+type CostItem struct {
+	// Resource is the address of the resource, like google_compute_instance.web
+	Resource string
+	Detail   string
+	Monthly  float64
+}
+
+// Estimate is what a stack is expected to cost a month
+type Estimate struct {
+	Items []CostItem
+	Total float64
+	// Unpriced are the resources that could not be priced, with why
+	Unpriced []string
+}
+
+// Estimate works out what the resources in blocks will cost a month.
+// Settings fill in attributes that are set from variables, and size
+// Compute Engine instances whose Terraform doesn't. Google Cloud resources
+// that aren't in the pricing data are listed as unpriced, while those of
+// other providers, like random_id, are taken to be free.
+func (p Pricing) Estimate(blocks Blocks, settings map[string]string) Estimate {
+	result := Estimate{Items: []CostItem{}, Unpriced: []string{}}
+	multiplier := p.regionMultiplier(settings["region"])
+
+	for _, b := range blocks {
+		if !b.IsResource() {
+			continue
+		}
+
+		item := CostItem{Resource: b.Address()}
+
+		switch b.Type {
+		case "google_compute_instance":
+			machine := attrSetting(b, "machine_type", settings)
+			if machine == "" {
+				machine = settings[SettingMachineType]
+			}
+			machine = path.Base(machine)
+
+			price, ok := p.MachineTypes[machine]
+			if !ok {
+				result.Unpriced = append(result.Unpriced, fmt.Sprintf("%s (machine type %q)", item.Resource, machine))
+				continue
+			}
+
+			size, disk, diskPrice, ok := p.disk(bootDisk(b, settings))
+			if !ok {
+				result.Unpriced = append(result.Unpriced, fmt.Sprintf("%s (disk type %q)", item.Resource, disk))
+				continue
+			}
+
+			item.Detail = fmt.Sprintf("%s, %d GB %s", machine, size, disk)
+			item.Monthly = price + diskPrice
+		case "google_compute_disk":
+			size, disk, diskPrice, ok := p.disk(attrSetting(b, "size", settings), attrSetting(b, "type", settings))
+			if !ok {
+				result.Unpriced = append(result.Unpriced, fmt.Sprintf("%s (disk type %q)", item.Resource, disk))
+				continue
+			}
+
+			item.Detail = fmt.Sprintf("%d GB %s", size, disk)
+			item.Monthly = diskPrice
+		default:
+			price, ok := p.Resources[b.Type]
+			if !ok {
+				if strings.HasPrefix(b.Type, "google_") {
+					result.Unpriced = append(result.Unpriced, item.Resource)
+				}
+				continue
+			}
+			item.Detail = price.Note
+			item.Monthly = price.Monthly
+		}
+
+		if count, err := strconv.Atoi(b.Values["count"]); err == nil && count != 1 {
+			item.Monthly *= float64(count)
+			item.Detail = strings.TrimSuffix(fmt.Sprintf("%d of them, %s", count, item.Detail), ", ")
+		}
+
+		item.Monthly *= multiplier
+		result.Total += item.Monthly
+		result.Items = append(result.Items, item)
+	}
+
+	return result
+}
+
+// bootDisk returns the size and type of an instance's boot disk, from its
+// boot_disk block, or from the settings for anything the block doesn't say
+func bootDisk(b Block, settings map[string]string) (string, string) {
+	size, diskType := settings[SettingDiskSize], settings[SettingDiskType]
+
+	params, ok := b.NestedBlock("boot_disk", "initialize_params")
+	if !ok {
+		return size, diskType
+	}
+
+	if v := attrSetting(params, "size", settings); v != "" {
+		size = v
+	}
+	if v := attrSetting(params, "type", settings); v != "" {
+		diskType = v
+	}
+
+	return size, diskType
+}
+
+// disk prices a persistent disk, filling in Compute Engine's defaults for
+// anything that isn't known
+func (p Pricing) disk(size, diskType string) (int, string, float64, bool) {
+	gb, err := strconv.Atoi(size)
+	if err != nil || gb <= 0 {
+		gb = defaultDiskSize
+	}
+
+	if diskType == "" {
+		diskType = defaultDiskType
+	}
+	diskType = path.Base(diskType)
+
+	price, ok := p.DiskTypes[diskType]
+	return gb, diskType, price * float64(gb), ok
+}
+
+// regionMultiplier is how much more things cost in region, going by the
+// longest start of a region name that matches
+func (p Pricing) regionMultiplier(region string) float64 {
+	result, longest := 1.0, 0
+	for prefix, v := range p.Regions {
+		if strings.HasPrefix(region, prefix) && len(prefix) > longest {
+			result, longest = v, len(prefix)
+		}
+	}
+	return result
+}
+
+// attrSetting returns the value of an attribute of a block, either as it is
+// written if it is a literal, or from the setting for the variable it is
+// set to
+func attrSetting(b Block, name string, settings map[string]string) string {
+	if v, ok := b.Values[name]; ok {
+		return v
+	}
+
+	if ref, ok := b.VariableRef(name); ok {
+		return settings[ref]
+	}
+
+	return ""
+}
@@ -0,0 +1,222 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestNewPricing(t *testing.T) {
+	p, err := NewPricing()
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	for k, v := range p.Resources {
+		if !strings.HasPrefix(k, "google_") {
+			t.Errorf("%s: is not a Google Cloud resource", k)
+		}
+		if v.Monthly == 0 && v.Note == "" {
+			t.Errorf("%s: is free, and doesn't need pricing", k)
+		}
+	}
+
+	resources, err := NewGCPResources()
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	for k := range resources {
+		if _, ok := p.Resources[k]; !ok && strings.HasPrefix(k, "google_") && k != "google_compute_instance" && k != "google_compute_disk" {
+			t.Errorf("%s: has no price", k)
+		}
+	}
+
+	for k, v := range p.MachineTypes {
+		if v <= 0 {
+			t.Errorf("machine type %s: has no price", k)
+		}
+	}
+
+	for _, k := range []string{defaultDiskType, "pd-balanced", "pd-ssd"} {
+		if _, ok := p.DiskTypes[k]; !ok {
+			t.Errorf("disk type %s: has no price", k)
+		}
+	}
+}
+
+func TestPricingEstimate(t *testing.T) {
+	p := Pricing{
+		Resources: map[string]Price{
+			"google_redis_instance":    {Monthly: 30, Note: "1 GB"},
+			"google_cloud_run_service": {Note: "Billed per request"},
+			"google_compute_network":   {Note: "Free"},
+		},
+		MachineTypes: map[string]float64{"e2-small": 12, "e2-medium": 24},
+		DiskTypes:    map[string]float64{"pd-standard": 0.04, "pd-ssd": 0.2},
+		Regions:      map[string]float64{"europe-": 1.5, "europe-west9": 2},
+	}
+
+	instance := Block{Name: "web", Kind: "managed", Type: "google_compute_instance"}
+
+	tests := map[string]struct {
+		blocks   Blocks
+		settings map[string]string
+		want     Estimate
+	}{
+		"flat": {
+			blocks: Blocks{
+				{Name: "cache", Kind: "managed", Type: "google_redis_instance"},
+				{Name: "app", Kind: "managed", Type: "google_cloud_run_service", Module: "module.app"},
+				{Name: "vpc", Kind: "managed", Type: "google_compute_network"},
+				{Name: "project", Kind: "data", Type: "google_project"},
+				{Name: "region", Kind: "variable"},
+			},
+			want: Estimate{
+				Items: []CostItem{
+					{Resource: "google_redis_instance.cache", Detail: "1 GB", Monthly: 30},
+					{Resource: "module.app.google_cloud_run_service.app", Detail: "Billed per request"},
+					{Resource: "google_compute_network.vpc", Detail: "Free"},
+				},
+				Total:    30,
+				Unpriced: []string{},
+			},
+		},
+		"instance settings": {
+			blocks: Blocks{instance},
+			settings: map[string]string{
+				SettingMachineType: "e2-medium",
+				SettingDiskSize:    "100",
+				SettingDiskType:    "pd-ssd",
+			},
+			want: Estimate{
+				Items:    []CostItem{{Resource: "google_compute_instance.web", Detail: "e2-medium, 100 GB pd-ssd", Monthly: 44}},
+				Total:    44,
+				Unpriced: []string{},
+			},
+		},
+		"instance attributes": {
+			blocks: Blocks{{
+				Name:   "web",
+				Kind:   "managed",
+				Type:   "google_compute_instance",
+				Attr:   map[string]string{"machine_type": `"e2-small"`, "count": "2"},
+				Values: map[string]string{"machine_type": "e2-small", "count": "2"},
+			}},
+			settings: map[string]string{SettingMachineType: "e2-medium"},
+			want: Estimate{
+				Items:    []CostItem{{Resource: "google_compute_instance.web", Detail: "2 of them, e2-small, 10 GB pd-standard", Monthly: 24.8}},
+				Total:    24.8,
+				Unpriced: []string{},
+			},
+		},
+		"variables": {
+			blocks: Blocks{{
+				Name: "data",
+				Kind: "managed",
+				Type: "google_compute_disk",
+				Attr: map[string]string{"size": "var.disk_size", "type": "var.disk_type"},
+			}},
+			settings: map[string]string{"disk_size": "50", "disk_type": "pd-ssd"},
+			want: Estimate{
+				Items:    []CostItem{{Resource: "google_compute_disk.data", Detail: "50 GB pd-ssd", Monthly: 10}},
+				Total:    10,
+				Unpriced: []string{},
+			},
+		},
+		"region": {
+			blocks:   Blocks{{Name: "cache", Kind: "managed", Type: "google_redis_instance"}},
+			settings: map[string]string{"region": "europe-west1"},
+			want: Estimate{
+				Items:    []CostItem{{Resource: "google_redis_instance.cache", Detail: "1 GB", Monthly: 45}},
+				Total:    45,
+				Unpriced: []string{},
+			},
+		},
+		"longest region": {
+			blocks:   Blocks{{Name: "cache", Kind: "managed", Type: "google_redis_instance"}},
+			settings: map[string]string{"region": "europe-west9"},
+			want: Estimate{
+				Items:    []CostItem{{Resource: "google_redis_instance.cache", Detail: "1 GB", Monthly: 60}},
+				Total:    60,
+				Unpriced: []string{},
+			},
+		},
+		"unknown type": {
+			blocks: Blocks{
+				{Name: "db", Kind: "managed", Type: "google_spanner_database"},
+				{Name: "suffix", Kind: "managed", Type: "random_id"},
+			},
+			want: Estimate{
+				Items:    []CostItem{},
+				Unpriced: []string{"google_spanner_database.db"},
+			},
+		},
+		"instance boot disk": {
+			blocks: Blocks{{
+				Name: "web",
+				Kind: "managed",
+				Type: "google_compute_instance",
+				Text: `resource "google_compute_instance" "web" {
+  boot_disk {
+    initialize_params {
+      size = var.boot_size
+    }
+  }
+}`,
+			}},
+			settings: map[string]string{
+				SettingMachineType: "e2-small",
+				SettingDiskSize:    "100",
+				SettingDiskType:    "pd-ssd",
+				"boot_size":        "20",
+			},
+			want: Estimate{
+				Items:    []CostItem{{Resource: "google_compute_instance.web", Detail: "e2-small, 20 GB pd-ssd", Monthly: 16}},
+				Total:    16,
+				Unpriced: []string{},
+			},
+		},
+		"unknown machine": {
+			blocks:   Blocks{instance},
+			settings: map[string]string{SettingMachineType: "zones/us-central1-a/machineTypes/z9-huge"},
+			want: Estimate{
+				Items:    []CostItem{},
+				Unpriced: []string{`google_compute_instance.web (machine type "z9-huge")`},
+			},
+		},
+		"unknown disk": {
+			blocks:   Blocks{instance},
+			settings: map[string]string{SettingMachineType: "e2-small", SettingDiskType: "hyperdisk-extreme"},
+			want: Estimate{
+				Items:    []CostItem{},
+				Unpriced: []string{`google_compute_instance.web (disk type "hyperdisk-extreme")`},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := p.Estimate(tc.blocks, tc.settings)
+
+			if diff := deep.Equal(tc.want, got); diff != nil {
+				t.Fatalf("expected: %+v, got: %+v, diff: %v", tc.want, got, diff)
+			}
+		})
+	}
+}
@@ -19,12 +19,17 @@ package terraform
 
 import (
 	_ "embed"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcljson "github.com/hashicorp/hcl/v2/json"
 	"github.com/hashicorp/terraform-config-inspect/tfconfig"
 	"gopkg.in/yaml.v2"
 )
@@ -32,8 +37,15 @@ import (
 //go:embed resources.yaml
 var resources []byte
 
+// ModuleDepth is how deeply nested modules are followed by Extract
+const ModuleDepth = 8
+
 // Extract points to a path that includes Terraform files and extracts all of
-// the information out of it for use with DeployStack Tools
+// the information out of it for use with DeployStack Tools. The modules it
+// calls are followed too: local ones by their source path, and registry ones
+// by where terraform init put them in .terraform/modules. Blocks from a module
+// have Module set to its address, like module.network.module.subnets.
+// Modules that can't be found or read are left out.
 func Extract(path string) (*Blocks, error) {
 	mod, dia := tfconfig.LoadModule(path)
 	if dia.Err() != nil {
@@ -45,70 +57,242 @@ func Extract(path string) (*Blocks, error) {
 		return nil, fmt.Errorf("could not properly parse blocks %s", err)
 	}
 
-	return b, nil
+	installed := readModuleManifest(path)
+	result := append(Blocks{}, *b...)
+	result = append(result, extractModules(path, mod, "", "", installed, map[string]bool{filepath.Clean(path): true}, 1)...)
+
+	return &result, nil
+}
+
+// moduleManifest is the part of .terraform/modules/modules.json that says
+// where terraform init put each module
+type moduleManifest struct {
+	Modules []struct {
+		Key string `json:"Key"`
+		Dir string `json:"Dir"`
+	} `json:"Modules"`
+}
+
+// readModuleManifest returns where each installed module is, keyed the way
+// Terraform keys them, like network.subnets. Paths are relative to root.
+func readModuleManifest(root string) map[string]string {
+	result := map[string]string{}
+
+	dat, err := os.ReadFile(filepath.Join(root, ".terraform", "modules", "modules.json"))
+	if err != nil {
+		return result
+	}
+
+	manifest := moduleManifest{}
+	if err := json.Unmarshal(dat, &manifest); err != nil {
+		return result
+	}
+
+	for _, v := range manifest.Modules {
+		if v.Key == "" {
+			continue
+		}
+		result[v.Key] = filepath.Join(root, filepath.FromSlash(v.Dir))
+	}
+
+	return result
+}
+
+// extractModules returns the blocks of the modules mod calls, and of the
+// modules they call in turn. key and address are the manifest key and
+// Terraform address of mod, and seen guards against modules that call
+// themselves.
+func extractModules(dir string, mod *tfconfig.Module, key, address string, installed map[string]string, seen map[string]bool, depth int) Blocks {
+	result := Blocks{}
+
+	if depth > ModuleDepth {
+		return result
+	}
+
+	names := []string{}
+	for name := range mod.ModuleCalls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		call := mod.ModuleCalls[name]
+
+		childKey := name
+		if key != "" {
+			childKey = key + "." + name
+		}
+		childAddress := "module." + name
+		if address != "" {
+			childAddress = address + "." + childAddress
+		}
+
+		childDir, ok := installed[childKey]
+		if !ok {
+			if !isLocalSource(call.Source) {
+				continue
+			}
+			childDir = filepath.Join(dir, filepath.FromSlash(call.Source))
+		}
+
+		if seen[childDir] {
+			continue
+		}
+
+		child, dia := tfconfig.LoadModule(childDir)
+		if dia.Err() != nil {
+			continue
+		}
+
+		blocks, err := NewBlocks(child)
+		if err != nil {
+			continue
+		}
+
+		for _, v := range *blocks {
+			v.Module = childAddress
+			result = append(result, v)
+		}
+
+		seen[childDir] = true
+		result = append(result, extractModules(childDir, child, childKey, childAddress, installed, seen, depth+1)...)
+		delete(seen, childDir)
+	}
+
+	return result
+}
+
+// isLocalSource reports whether a module source is a path on disk rather
+// than something to download
+func isLocalSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
 }
 
 // Block represents one of several kinds of Terraform constructs: resources,
-// variables, module
+// variables, modules and outputs. Module is the address of the module the
+// block is in, like module.network, and is empty for the root module.
+//
+// For everything but variables, Attr is the expression text of each top
+// level attribute, Values is the value of the ones that are literals, and
+// Nested is the names of the nested blocks.
 type Block struct {
-	Name  string            `json:"name" yaml:"name"`
-	Text  string            `json:"text" yaml:"text"`
-	Kind  string            `json:"kind" yaml:"kind"`
-	Type  string            `json:"type" yaml:"type"`
-	Attr  map[string]string `json:"attr" yaml:"attr"`
-	File  string            `json:"file" yaml:"file"`
-	Start int               `json:"start" yaml:"start"`
+	Name   string            `json:"name" yaml:"name"`
+	Text   string            `json:"text" yaml:"text"`
+	Kind   string            `json:"kind" yaml:"kind"`
+	Type   string            `json:"type" yaml:"type"`
+	Attr   map[string]string `json:"attr" yaml:"attr"`
+	Values map[string]string `json:"values,omitempty" yaml:"values,omitempty"`
+	Nested []string          `json:"nested,omitempty" yaml:"nested,omitempty"`
+	File   string            `json:"file" yaml:"file"`
+	Start  int               `json:"start" yaml:"start"`
+	End    int               `json:"end" yaml:"end"`
+	Module string            `json:"module,omitempty" yaml:"module,omitempty"`
 }
 
 // NewResourceBlock converts a parsed Terraform Resource to a Block
 func NewResourceBlock(t *tfconfig.Resource) (Block, error) {
+	return newResourceBlock(t, sourceFiles{})
+}
+
+func newResourceBlock(t *tfconfig.Resource, files sourceFiles) (Block, error) {
 	b := Block{}
-	var err error
 	b.Name = t.Name
 	b.Type = t.Type
 	b.Kind = t.Mode.String()
 	b.Start = t.Pos.Line
 	b.File = t.Pos.Filename
-	b.Text, err = getResourceText(t.Pos.Filename, t.Pos.Line)
+
+	blockType := "resource"
+	if t.Mode == tfconfig.DataResourceMode {
+		blockType = "data"
+	}
+
+	src, err := files.blockSource(t.Pos.Filename, blockType, t.Type, t.Name)
 	if err != nil {
 		return b, fmt.Errorf("could not extract text from Resource: %s", err)
 	}
+	b.setSource(src)
+	b.setAttributes(src.attrs)
 
 	return b, nil
 }
 
 // NewVariableBlock converts a parsed Terraform Variable to a Block
 func NewVariableBlock(t *tfconfig.Variable) (Block, error) {
+	return newVariableBlock(t, sourceFiles{})
+}
+
+func newVariableBlock(t *tfconfig.Variable, files sourceFiles) (Block, error) {
 	b := Block{}
-	var err error
 	b.Name = t.Name
 	b.Type = t.Type
 	b.Kind = "variable"
 	b.Start = t.Pos.Line
 	b.File = t.Pos.Filename
-	b.Text, err = getResourceText(t.Pos.Filename, t.Pos.Line)
+	src, err := files.blockSource(t.Pos.Filename, "variable", t.Name)
 	if err != nil {
 		return b, fmt.Errorf("could not extract text from Variable: %s", err)
 	}
+	b.setSource(src)
+
 	return b, nil
 }
 
 // NewModuleBlock converts a parsed Terraform Module to a Block
 func NewModuleBlock(t *tfconfig.ModuleCall) (Block, error) {
+	return newModuleBlock(t, sourceFiles{})
+}
+
+func newModuleBlock(t *tfconfig.ModuleCall, files sourceFiles) (Block, error) {
 	b := Block{}
-	var err error
 	b.Name = t.Name
 	b.Type = t.Source
 	b.Kind = "module"
 	b.Start = t.Pos.Line
 	b.File = t.Pos.Filename
-	b.Text, err = getResourceText(t.Pos.Filename, t.Pos.Line)
+	src, err := files.blockSource(t.Pos.Filename, "module", t.Name)
 	if err != nil {
 		return b, fmt.Errorf("could not extract text from Module: %s", err)
 	}
+	b.setSource(src)
+	b.setAttributes(src.attrs)
+
+	return b, nil
+}
+
+// NewOutputBlock converts a parsed Terraform Output to a Block
+func NewOutputBlock(t *tfconfig.Output) (Block, error) {
+	return newOutputBlock(t, sourceFiles{})
+}
+
+func newOutputBlock(t *tfconfig.Output, files sourceFiles) (Block, error) {
+	b := Block{}
+	b.Name = t.Name
+	b.Kind = "output"
+	b.Start = t.Pos.Line
+	b.File = t.Pos.Filename
+	src, err := files.blockSource(t.Pos.Filename, "output", t.Name)
+	if err != nil {
+		return b, fmt.Errorf("could not extract text from Output: %s", err)
+	}
+	b.setSource(src)
+	b.setAttributes(src.attrs)
+
 	return b, nil
 }
 
+func (b *Block) setSource(src blockSource) {
+	b.Text = src.text
+	b.Start = src.start
+	b.End = src.end
+}
+
+func (b *Block) setAttributes(attrs attributes) {
+	b.Attr = attrs.exprs
+	b.Values = attrs.values
+	b.Nested = attrs.nested
+}
+
 // IsResource returns true if block is a Terraform resource
 func (b Block) IsResource() bool {
 	return b.Kind == "managed"
@@ -119,56 +303,235 @@ func (b Block) IsModule() bool {
 	return b.Kind == "module"
 }
 
+// InRoot returns true if block is in the root module rather than in a module
+// it calls
+func (b Block) InRoot() bool {
+	return b.Module == ""
+}
+
 // IsVariable returns true if block is a Terraform variable
 func (b Block) IsVariable() bool {
 	return b.Kind == "variable"
 }
 
+// Address is how Terraform refers to the block, like
+// module.network.google_compute_network.main
+func (b Block) Address() string {
+	local := fmt.Sprintf("%s.%s", b.Type, b.Name)
+	switch b.Kind {
+	case "data":
+		local = fmt.Sprintf("data.%s.%s", b.Type, b.Name)
+	case "module":
+		local = fmt.Sprintf("module.%s", b.Name)
+	case "variable":
+		local = fmt.Sprintf("var.%s", b.Name)
+	case "output":
+		local = fmt.Sprintf("output.%s", b.Name)
+	}
+
+	if b.InRoot() {
+		return local
+	}
+	return fmt.Sprintf("%s.%s", b.Module, local)
+}
+
+// IsOutput returns true if block is a Terraform output
+func (b Block) IsOutput() bool {
+	return b.Kind == "output"
+}
+
 // NoDefault returns true if block does not contain a default value
 func (b Block) NoDefault() bool {
-	return !strings.Contains(b.Text, "default")
+	v, err := b.Variable()
+	if err != nil {
+		return !strings.Contains(b.Text, "default")
+	}
+	return !v.HasDefault
 }
 
-func getResourceText(file string, start int) (string, error) {
+// blockSource is the text of a block and the lines it starts and ends on
+type blockSource struct {
+	text  string
+	start int
+	end   int
+	attrs attributes
+}
+
+// getBlockSource finds the block with the given type and labels in file. It
+// uses the HCL parser, so braces in strings, heredocs and comments don't throw
+// it off, and understands both native and JSON syntax.
+func getBlockSource(file, blockType string, labels ...string) (blockSource, error) {
+	return sourceFiles{}.blockSource(file, blockType, labels...)
+}
+
+// sourceFiles are Terraform files that have been read and parsed, keyed by
+// path, so finding many blocks in one file only parses it once
+type sourceFiles map[string]*sourceFile
+
+// sourceFile is a parsed Terraform file. Native syntax files have body, and
+// JSON ones have content.
+type sourceFile struct {
+	dat     []byte
+	body    *hclsyntax.Body
+	content *hcl.BodyContent
+	err     error
+}
+
+// parse returns file, reading and parsing it the first time it is asked for
+func (s sourceFiles) parse(file string) (*sourceFile, error) {
+	if f, ok := s[file]; ok {
+		return f, f.err
+	}
+
+	f := &sourceFile{}
+	s[file] = f
 
 	dat, err := os.ReadFile(file)
 	if err != nil {
-		return "", fmt.Errorf("could not get terraform file: %s", err)
+		f.err = fmt.Errorf("could not get terraform file: %s", err)
+		return f, f.err
 	}
-	sl := strings.Split(string(dat), "\n")
+	f.dat = dat
 
-	resultSl := []string{}
-	startpos := start - 1
-	end := findClosingBracket(start, sl) + 1
+	if strings.HasSuffix(file, ".json") {
+		parsed, diags := hcljson.Parse(dat, file)
+		if diags.HasErrors() {
+			f.err = fmt.Errorf("could not parse terraform file: %s", diags)
+			return f, f.err
+		}
+		f.content, _, _ = parsed.Body.PartialContent(blockSchema)
+		return f, nil
+	}
 
-	if startpos == 0 {
-		startpos = 1
+	parsed, diags := hclsyntax.ParseConfig(dat, file, hcl.InitialPos)
+	if diags.HasErrors() {
+		f.err = fmt.Errorf("could not parse terraform file: %s", diags)
+		return f, f.err
 	}
+	f.body = parsed.Body.(*hclsyntax.Body)
+
+	return f, nil
+}
 
-	for i := startpos - 1; i < end; i++ {
-		resultSl = append(resultSl, sl[i])
+// blockSource finds the block with the given type and labels in file
+func (s sourceFiles) blockSource(file, blockType string, labels ...string) (blockSource, error) {
+	f, err := s.parse(file)
+	if err != nil {
+		return blockSource{}, err
 	}
 
-	result := strings.Join(resultSl, "\n")
+	if f.content != nil {
+		return jsonBlockSource(f.dat, f.content, file, blockType, labels)
+	}
 
-	return result, nil
+	for _, b := range f.body.Blocks {
+		if b.Type != blockType || !sameLabels(b.Labels, labels) {
+			continue
+		}
+
+		rng := b.Range()
+		return blockSource{
+			text:  string(rng.SliceBytes(f.dat)),
+			start: rng.Start.Line,
+			end:   rng.End.Line,
+			attrs: readAttributes(b.Body, f.dat),
+		}, nil
+	}
+
+	return blockSource{}, fmt.Errorf("could not find %s %s in %s", blockType, strings.Join(labels, "."), file)
+}
+
+// blockSchema is the top level blocks of Terraform that become Blocks
+var blockSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "resource", LabelNames: []string{"type", "name"}},
+		{Type: "data", LabelNames: []string{"type", "name"}},
+		{Type: "variable", LabelNames: []string{"name"}},
+		{Type: "module", LabelNames: []string{"name"}},
+		{Type: "output", LabelNames: []string{"name"}},
+	},
 }
 
-func findClosingBracket(start int, sl []string) int {
-	count := 0
-	for i := start - 1; i < len(sl); i++ {
-		if strings.Contains(sl[i], "{") {
-			count++
+// jsonBlockSource finds a block in the content of a .tf.json file. The text
+// of the block is its last label and object, like "main": { ... }
+func jsonBlockSource(dat []byte, content *hcl.BodyContent, file, blockType string, labels []string) (blockSource, error) {
+	for _, b := range content.Blocks {
+		if b.Type != blockType || !sameLabels(b.Labels, labels) {
+			continue
 		}
-		if strings.Contains(sl[i], "}") {
-			count--
+
+		// The definition of a JSON block is the brace that opens its object
+		open := b.DefRange.Start.Byte
+		closing, ok := closingBrace(dat, open)
+		if !ok {
+			break
 		}
-		if count == 0 {
-			return i
+
+		from, line := open, b.DefRange.Start.Line
+		if len(b.LabelRanges) > 0 {
+			last := b.LabelRanges[len(b.LabelRanges)-1]
+			from, line = last.Start.Byte, last.Start.Line
 		}
+
+		text := string(dat[from : closing+1])
+		return blockSource{
+			text:  text,
+			start: line,
+			end:   line + strings.Count(text, "\n"),
+			attrs: readAttributes(b.Body, dat),
+		}, nil
 	}
 
-	return len(sl)
+	return blockSource{}, fmt.Errorf("could not find %s %s in %s", blockType, strings.Join(labels, "."), file)
+}
+
+// closingBrace returns where the JSON object or array that opens at open
+// closes, skipping over anything in strings
+func closingBrace(src []byte, open int) (int, bool) {
+	depth := 0
+	inString, escaped := false, false
+
+	for i := open; i < len(src); i++ {
+		c := src[i]
+
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+func sameLabels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
 }
 
 // Blocks is a slice of type Block
@@ -177,9 +540,10 @@ type Blocks []Block
 // NewBlocks converts the results from a Terraform parse operation to Blocks.
 func NewBlocks(mod *tfconfig.Module) (*Blocks, error) {
 	result := Blocks{}
+	files := sourceFiles{}
 
 	for _, v := range mod.ModuleCalls {
-		b, err := NewModuleBlock(v)
+		b, err := newModuleBlock(v, files)
 		if err != nil {
 			return nil, fmt.Errorf("could not parse Module Calls: %s", err)
 		}
@@ -187,7 +551,7 @@ func NewBlocks(mod *tfconfig.Module) (*Blocks, error) {
 	}
 
 	for _, v := range mod.ManagedResources {
-		b, err := NewResourceBlock(v)
+		b, err := newResourceBlock(v, files)
 		if err != nil {
 			return nil, fmt.Errorf("could not parse ManagedResources: %s", err)
 		}
@@ -195,7 +559,7 @@ func NewBlocks(mod *tfconfig.Module) (*Blocks, error) {
 	}
 
 	for _, v := range mod.DataResources {
-		b, err := NewResourceBlock(v)
+		b, err := newResourceBlock(v, files)
 		if err != nil {
 			return nil, fmt.Errorf("could not parse DataResources: %s", err)
 		}
@@ -203,13 +567,21 @@ func NewBlocks(mod *tfconfig.Module) (*Blocks, error) {
 	}
 
 	for _, v := range mod.Variables {
-		b, err := NewVariableBlock(v)
+		b, err := newVariableBlock(v, files)
 		if err != nil {
 			return nil, fmt.Errorf("could not parse Variables: %s", err)
 		}
 		result = append(result, b)
 	}
 
+	for _, v := range mod.Outputs {
+		b, err := newOutputBlock(v, files)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse Outputs: %s", err)
+		}
+		result = append(result, b)
+	}
+
 	sort.Slice(result, func(i, j int) bool {
 		return result[i].Start < result[j].Start
 	})
@@ -283,12 +655,67 @@ func (g GCPResources) GetProduct(key string) string {
 	return v.Product
 }
 
+// Services returns the Google Cloud services that have to be enabled for the
+// resources and modules in blocks to be created, sorted and without repeats.
+// Modules that are an alias of other resources need the services of those
+// resources.
+func (g GCPResources) Services(blocks Blocks) []string {
+	return g.collect(blocks, func(r GCPResource) []string { return r.Services })
+}
+
+// APICalls returns the API calls Terraform makes to create the resources and
+// modules in blocks, sorted and without repeats. Like Services, modules that
+// are an alias of other resources make the calls of those resources.
+func (g GCPResources) APICalls(blocks Blocks) []string {
+	return g.collect(blocks, func(r GCPResource) []string { return r.APICalls })
+}
+
+// collect gathers one of the lists of the resources that blocks use,
+// following aliases
+func (g GCPResources) collect(blocks Blocks, field func(GCPResource) []string) []string {
+	seen := map[string]bool{}
+	visited := map[string]bool{}
+
+	var add func(key string)
+	add = func(key string) {
+		v, ok := g[key]
+		if !ok || visited[key] {
+			return
+		}
+		visited[key] = true
+		for _, s := range field(v) {
+			if s = strings.TrimSpace(s); s != "" {
+				seen[s] = true
+			}
+		}
+		for _, alias := range v.AliasOf {
+			add(alias)
+		}
+	}
+
+	for _, b := range blocks {
+		if b.IsVariable() {
+			continue
+		}
+		add(b.Type)
+	}
+
+	result := []string{}
+	for s := range seen {
+		result = append(result, s)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
 // GCPResource is a Terraform resource that matches up with a GCP product. This
 // is used to automate the generation of tests and documentation
 type GCPResource struct {
 	Label      string     `json:"label" yaml:"label"`
 	Product    string     `json:"product" yaml:"product"`
 	APICalls   []string   `json:"api_calls" yaml:"api_calls"`
+	Services   []string   `json:"services" yaml:"services"`
 	TestConfig TestConfig `json:"test_config" yaml:"test_config"`
 	AliasOf    []string   `json:"aliasof" yaml:"aliasof"`
 }
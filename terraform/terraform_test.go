@@ -251,14 +251,14 @@ func TestVariableExtract(t *testing.T) {
 	want := Blocks{
 		Block{
 			Name: "project_id",
-			Text: `
-variable "project_id" {
+			Text: `variable "project_id" {
   type = string
 }`,
 			Kind:  "variable",
 			Type:  "string",
 			File:  filepath.Join(testdata, "variables.tf"),
 			Start: 15,
+			End:   17,
 		},
 	}
 
@@ -282,8 +282,7 @@ func TestResourceExtract(t *testing.T) {
 	want := &Blocks{
 		Block{
 			Name: "snapshot",
-			Text: `
-resource "google_compute_snapshot" "snapshot" {
+			Text: `resource "google_compute_snapshot" "snapshot" {
   project           = var.project_id
   name              = "${var.basename}-snapshot"
   source_disk       = google_compute_instance.exemplar.boot_disk[0].source
@@ -291,10 +290,19 @@ resource "google_compute_snapshot" "snapshot" {
   storage_locations = ["${var.region}"]
   depends_on        = [time_sleep.startup_completion]
 }`,
-			Kind:  "managed",
-			Type:  "google_compute_snapshot",
+			Kind: "managed",
+			Type: "google_compute_snapshot",
+			Attr: map[string]string{
+				"project":           "var.project_id",
+				"name":              `"${var.basename}-snapshot"`,
+				"source_disk":       "google_compute_instance.exemplar.boot_disk[0].source",
+				"zone":              "var.zone",
+				"storage_locations": `["${var.region}"]`,
+				"depends_on":        "[time_sleep.startup_completion]",
+			},
 			File:  filepath.Join(testdata, "main.tf"),
 			Start: 15,
+			End:   22,
 		},
 	}
 
@@ -317,8 +325,7 @@ func TestModuleExtract(t *testing.T) {
 	want := &Blocks{
 		Block{
 			Name: "project-services",
-			Text: `
-module "project-services" {
+			Text: `module "project-services" {
   source                      = "terraform-google-modules/project-factory/google//modules/project_services"
   version                     = "~> 13.0"
   disable_services_on_destroy = false
@@ -330,48 +337,172 @@ module "project-services" {
     "compute.googleapis.com"
   ]
 }`,
-			Kind:  "module",
-			Type:  "terraform-google-modules/project-factory/google//modules/project_services",
+			Kind: "module",
+			Type: "terraform-google-modules/project-factory/google//modules/project_services",
+			Attr: map[string]string{
+				"source":                      `"terraform-google-modules/project-factory/google//modules/project_services"`,
+				"version":                     `"~> 13.0"`,
+				"disable_services_on_destroy": "false",
+				"project_id":                  "var.project_id",
+				"enable_apis":                 "var.enable_apis",
+				"activate_apis": `[
+    "compute.googleapis.com"
+  ]`,
+			},
+			Values: map[string]string{
+				"source":                      "terraform-google-modules/project-factory/google//modules/project_services",
+				"version":                     "~> 13.0",
+				"disable_services_on_destroy": "false",
+				"activate_apis":               "compute.googleapis.com",
+			},
 			File:  filepath.Join(testdata, "main.tf"),
 			Start: 15,
+			End:   26,
 		},
 	}
 
 	assert.Equal(t, (*want)[0], (*got)[0])
 }
 
-func TestFindClosingBracket(t *testing.T) {
+func TestExtractModules(t *testing.T) {
+	nested := filepath.Join(testFilesDir, "terraform", "nestedmodules")
+
+	// The same local modules, without terraform init having been run, and
+	// with a module that calls itself
+	local := t.TempDir()
+	files := map[string]string{
+		"main.tf": `module "database" {
+  source = "./modules/database"
+}`,
+		"modules/database/main.tf": `resource "google_sql_database_instance" "main" {
+  name = "main"
+}
+
+module "users" {
+  source = "../users"
+}`,
+		"modules/users/main.tf": `resource "google_sql_user" "admin" {
+  name = "admin"
+}
+
+module "again" {
+  source = "./"
+}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(local, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("could not make testdata: %s", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("could not make testdata: %s", err)
+		}
+	}
+
 	tests := map[string]struct {
-		start   int
-		content string
-		want    int
+		in   string
+		want []string
 	}{
-		"none": {start: 1, content: "", want: 0},
-		"regular usage": {start: 4, content: `
-
-		# Enabling services in your GCP project
-		variable "gcp_service_list" {
-		  description = "The list of apis necessary for the project"
-		  type        = list(string)
-		  default = [
-			"compute.googleapis.com",
-		  ]
-		}`, want: 9},
-		"broken": {start: 4, content: `
-
-		# Enabling services in your GCP project
-		variable "gcp_service_list" {
-		  description = "The list of apis necessary for the project"
-		  type        = list(string)
-		  default = [
-			"compute.googleapis.com",
-		  ]
-		`, want: 10},
+		"installed": {
+			in: nested,
+			want: []string{
+				"variable project_id",
+				"module database",
+				"module network",
+				"module missing",
+				"managed assets",
+				"variable project_id module.database",
+				"managed main module.database",
+				"module users module.database",
+				"variable project_id module.database.module.users",
+				"managed admin module.database.module.users",
+				"variable project_id module.network",
+				"managed network module.network",
+			},
+		},
+		"local": {
+			in: local,
+			want: []string{
+				"module database",
+				"managed main module.database",
+				"module users module.database",
+				"managed admin module.database.module.users",
+				"module again module.database.module.users",
+			},
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			got := findClosingBracket(tc.start, strings.Split(tc.content, "\n"))
+			blocks, err := Extract(tc.in)
+			if err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			got := []string{}
+			for _, v := range *blocks {
+				got = append(got, strings.TrimSpace(fmt.Sprintf("%s %s %s", v.Kind, v.Name, v.Module)))
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("expected: \n%s\ngot: \n%s", strings.Join(tc.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestBlockInRoot(t *testing.T) {
+	if !(Block{Name: "main"}).InRoot() {
+		t.Fatalf("expected a block without a module to be in the root module")
+	}
+
+	if (Block{Name: "main", Module: "module.database"}).InRoot() {
+		t.Fatalf("expected a block with a module not to be in the root module")
+	}
+}
+
+func TestBlockAddress(t *testing.T) {
+	tests := map[string]struct {
+		in   Block
+		want string
+	}{
+		"resource": {in: Block{Name: "main", Kind: "managed", Type: "google_compute_network"}, want: "google_compute_network.main"},
+		"data":     {in: Block{Name: "project", Kind: "data", Type: "google_project"}, want: "data.google_project.project"},
+		"module":   {in: Block{Name: "network", Kind: "module", Type: "./modules/network"}, want: "module.network"},
+		"variable": {in: Block{Name: "region", Kind: "variable", Type: "string"}, want: "var.region"},
+		"output":   {in: Block{Name: "url", Kind: "output"}, want: "output.url"},
+		"nested": {
+			in:   Block{Name: "main", Kind: "managed", Type: "google_sql_database_instance", Module: "module.database.module.users"},
+			want: "module.database.module.users.google_sql_database_instance.main",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tc.in.Address(); tc.want != got {
+				t.Fatalf("expected: %s, got: %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestClosingBrace(t *testing.T) {
+	tests := map[string]struct {
+		in   string
+		want int
+		ok   bool
+	}{
+		"none":    {in: "", want: 0},
+		"simple":  {in: `{"a": 1}`, want: 7, ok: true},
+		"nested":  {in: `{"a": {"b": [1, {"c": 2}]}} `, want: 26, ok: true},
+		"strings": {in: `{"a": "} { ] [", "b": "\"}"}`, want: 27, ok: true},
+		"broken":  {in: `{"a": {"b": 1}`, want: 0},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := closingBrace([]byte(tc.in), 0)
+			assert.Equal(t, tc.ok, ok)
 			assert.Equal(t, tc.want, got)
 		})
 	}
@@ -609,78 +740,260 @@ func TestGCPResourceGetProduct(t *testing.T) {
 	}
 }
 
-func TestGetResourceText(t *testing.T) {
+func TestGCPResourcesServices(t *testing.T) {
+	resources := GCPResources{
+		"google_compute_instance": GCPResource{Services: []string{"compute.googleapis.com"}},
+		"google_cloudfunctions_function": GCPResource{Services: []string{
+			"cloudfunctions.googleapis.com",
+			"cloudbuild.googleapis.com",
+		}},
+		"google_storage_bucket": GCPResource{Services: []string{"storage.googleapis.com"}},
+		"fabric//modules/gcs":   GCPResource{AliasOf: []string{"google_storage_bucket"}},
+		"loop":                  GCPResource{AliasOf: []string{"loop"}},
+	}
+
 	tests := map[string]struct {
-		in     string
-		want   string
-		err    error
-		target string
+		in   Blocks
+		want []string
 	}{
-		"basic": {
-			in:     "resources",
-			target: "google_compute_snapshot.snapshot",
-			want: `
-resource "google_compute_snapshot" "snapshot" {
+		"resources": {
+			in: Blocks{
+				{Name: "main", Kind: "managed", Type: "google_compute_instance"},
+				{Name: "other", Kind: "managed", Type: "google_compute_instance"},
+				{Name: "function", Kind: "managed", Type: "google_cloudfunctions_function"},
+			},
+			want: []string{
+				"cloudbuild.googleapis.com",
+				"cloudfunctions.googleapis.com",
+				"compute.googleapis.com",
+			},
+		},
+		"alias": {
+			in:   Blocks{{Name: "bucket", Kind: "module", Type: "fabric//modules/gcs"}},
+			want: []string{"storage.googleapis.com"},
+		},
+		"unknown": {
+			in: Blocks{
+				{Name: "random", Kind: "managed", Type: "random_id"},
+				{Name: "loop", Kind: "module", Type: "loop"},
+			},
+			want: []string{},
+		},
+		"variables": {
+			in:   Blocks{{Name: "google_compute_instance", Kind: "variable", Type: "google_compute_instance"}},
+			want: []string{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := resources.Services(tc.in)
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGCPResourcesServicesKnown(t *testing.T) {
+	resources, err := NewGCPResources()
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	for key, v := range resources {
+		for _, s := range v.Services {
+			if !strings.HasSuffix(s, ".googleapis.com") {
+				t.Fatalf("%s: expected a service name, got: %s", key, s)
+			}
+		}
+	}
+
+	got := resources.Services(Blocks{{Name: "db", Kind: "managed", Type: "google_sql_database_instance"}})
+	want := []string{"sqladmin.googleapis.com"}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected: %+v, got: %+v", want, got)
+	}
+}
+
+func TestGCPResourcesAPICalls(t *testing.T) {
+	resources := GCPResources{
+		"google_compute_instance": GCPResource{APICalls: []string{
+			"compute.[version].InstancesService.Insert ",
+			"compute.[version].InstancesService.SetMetadata",
+		}},
+		"google_compute_disk":   GCPResource{APICalls: []string{"compute.[version].DisksService.Insert"}},
+		"fabric//modules/disks": GCPResource{AliasOf: []string{"google_compute_disk"}},
+	}
+
+	tests := map[string]struct {
+		in   Blocks
+		want []string
+	}{
+		"resources": {
+			in: Blocks{{Name: "main", Kind: "managed", Type: "google_compute_instance"}},
+			want: []string{
+				"compute.[version].InstancesService.Insert",
+				"compute.[version].InstancesService.SetMetadata",
+			},
+		},
+		"alias": {
+			in:   Blocks{{Name: "disks", Kind: "module", Type: "fabric//modules/disks"}},
+			want: []string{"compute.[version].DisksService.Insert"},
+		},
+		"unknown": {
+			in:   Blocks{{Name: "random", Kind: "managed", Type: "random_id"}},
+			want: []string{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := resources.APICalls(tc.in)
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGetBlockSource(t *testing.T) {
+	snapshot := `resource "google_compute_snapshot" "snapshot" {
   project           = var.project_id
   name              = "${var.basename}-snapshot"
   source_disk       = google_compute_instance.exemplar.boot_disk[0].source
   zone              = var.zone
   storage_locations = ["${var.region}"]
   depends_on        = [time_sleep.startup_completion]
-}`,
+}`
+
+	tests := map[string]struct {
+		in        string
+		blockType string
+		labels    []string
+		want      blockSource
+		err       error
+	}{
+		"basic": {
+			in:        "resources/main.tf",
+			blockType: "resource",
+			labels:    []string{"google_compute_snapshot", "snapshot"},
+			want:      blockSource{text: snapshot, start: 15, end: 22},
 		},
 		"begin at zero": {
-			in:     "resources_begin_at_zero",
-			target: "google_compute_snapshot.snapshot",
-			want: `resource "google_compute_snapshot" "snapshot" {
-  project           = var.project_id
-  name              = "${var.basename}-snapshot"
-  source_disk       = google_compute_instance.exemplar.boot_disk[0].source
-  zone              = var.zone
-  storage_locations = ["${var.region}"]
-  depends_on        = [time_sleep.startup_completion]
-}`,
+			in:        "resources_begin_at_zero/main.tf",
+			blockType: "resource",
+			labels:    []string{"google_compute_snapshot", "snapshot"},
+			want:      blockSource{text: snapshot, start: 1, end: 8},
+		},
+		"one line": {
+			in:        "tricky/main.tf",
+			blockType: "variable",
+			labels:    []string{"project_id"},
+			want:      blockSource{text: `variable "project_id" { type = string }`, start: 1, end: 1},
+		},
+		"heredoc": {
+			in:        "tricky/main.tf",
+			blockType: "resource",
+			labels:    []string{"google_compute_instance", "web"},
+			want: blockSource{text: `resource "google_compute_instance" "web" {
+  name = "web-{x}"
+  metadata_startup_script = <<-EOT
+    #!/bin/bash
+    if [ -z "$X" ]; then
+      echo "}"
+    fi
+    echo "{{ not a brace }"
+  EOT
+  labels = { app = "web", "tier" = "front" } # }}}
+}`, start: 3, end: 13},
+		},
+		"json": {
+			in:        "tricky/main.tf.json",
+			blockType: "resource",
+			labels:    []string{"google_pubsub_topic", "events"},
+			want: blockSource{text: `"events": {
+        "name": "events-}",
+        "labels": {"a": "b"}
+      }`, start: 10, end: 13},
+		},
+		"json variable": {
+			in:        "tricky/main.tf.json",
+			blockType: "variable",
+			labels:    []string{"region"},
+			want: blockSource{text: `"region": {
+      "description": "Where to run {things}",
+      "default": "us-central1"
+    }`, start: 3, end: 6},
+		},
+		"notinfile": {
+			in:        "tricky/main.tf",
+			blockType: "resource",
+			labels:    []string{"google_compute_instance", "missing"},
+			err:       fmt.Errorf("could not find resource google_compute_instance.missing"),
 		},
 		"filenotfound": {
-			in:     "resources_not_exist",
-			target: "google_compute_snapshot.snapshot",
-			want:   ``,
-			err:    fmt.Errorf("could not get terraform file"),
+			in:        "resources_not_exist/main.tf",
+			blockType: "resource",
+			labels:    []string{"google_compute_snapshot", "snapshot"},
+			err:       fmt.Errorf("could not get terraform file"),
 		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-
-			testdata := filepath.Join(testFilesDir, "terraform", tc.in)
-			mod, _ := tfconfig.LoadModule(testdata)
-
-			r := mod.ManagedResources[tc.target]
-
-			var got string
-			var err error
-			if r == nil {
-				got, err = getResourceText(tc.in, 0)
-			} else {
-				got, err = getResourceText(r.Pos.Filename, r.Pos.Line)
-			}
+			file := filepath.Join(testFilesDir, "terraform", tc.in)
+			got, err := getBlockSource(file, tc.blockType, tc.labels...)
 
 			if tc.err == nil && err != nil {
 				t.Fatalf("expected:no error, got: %+v", err)
 			}
 
-			if tc.err != nil && !strings.Contains(err.Error(), tc.err.Error()) {
-				t.Fatalf("expected error: %s, got: %s", tc.err, err)
+			if tc.err != nil {
+				if err == nil || !strings.Contains(err.Error(), tc.err.Error()) {
+					t.Fatalf("expected error: %s, got: %v", tc.err, err)
+				}
+				return
 			}
 
+			// The attributes of blocks are covered by TestReadAttributes
+			got.attrs = attributes{}
+
 			if !reflect.DeepEqual(tc.want, got) {
-				fmt.Println(diff.Diff(tc.want, got))
-				t.Fatalf("text doesn't match")
+				fmt.Println(diff.Diff(tc.want.text, got.text))
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got)
 			}
 		})
 	}
 }
 
+func TestSourceFilesParseOnce(t *testing.T) {
+	files := sourceFiles{}
+	file := filepath.Join(testFilesDir, "terraform", "tricky/main.tf")
+
+	first, err := files.parse(file)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	for _, labels := range [][]string{{"google_compute_instance", "web"}, {"google_storage_bucket", "assets"}} {
+		if _, err := files.blockSource(file, "resource", labels...); err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+	}
+
+	if second, _ := files.parse(file); second != first || len(files) != 1 {
+		t.Fatalf("expected %s to be parsed once, got: %d files", file, len(files))
+	}
+
+	missing := filepath.Join(testFilesDir, "terraform", "resources_not_exist/main.tf")
+	for i := 0; i < 2; i++ {
+		if _, err := files.blockSource(missing, "resource", "a", "b"); err == nil {
+			t.Fatalf("expected an error for %s", missing)
+		}
+	}
+}
+
 func TestBlocks(t *testing.T) {
 	testdata := filepath.Join(testFilesDir, "terraform")
 	tests := map[string]struct {
@@ -703,9 +1016,9 @@ func TestBlocks(t *testing.T) {
 				Type:  "google_compute_snapshot",
 				Kind:  "managed",
 				Start: 15,
+				End:   22,
 				File:  filepath.Join(testdata, "resources/main.tf"),
-				Text: `
-resource "google_compute_snapshot" "snapshot" {
+				Text: `resource "google_compute_snapshot" "snapshot" {
   project           = var.project_id
   name              = "${var.basename}-snapshot"
   source_disk       = google_compute_instance.exemplar.boot_disk[0].source
@@ -748,9 +1061,9 @@ resource "google_compute_snapshot" "snapshot" {
 				Type:  "string",
 				Kind:  "variable",
 				Start: 15,
+				End:   17,
 				File:  filepath.Join(testdata, "variables/variables.tf"),
-				Text: `
-variable "project_id" {
+				Text: `variable "project_id" {
   type = string
 }`,
 			},
@@ -787,9 +1100,9 @@ variable "project_id" {
 				Type:  "terraform-google-modules/project-factory/google//modules/project_services",
 				Kind:  "module",
 				Start: 15,
+				End:   26,
 				File:  filepath.Join(testdata, "modules/main.tf"),
-				Text: `
-module "project-services" {
+				Text: `module "project-services" {
   source                      = "terraform-google-modules/project-factory/google//modules/project_services"
   version                     = "~> 13.0"
   disable_services_on_destroy = false
@@ -0,0 +1,276 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Variable is what a Terraform variable block declares
+type Variable struct {
+	Name        string
+	Type        string
+	Description string
+	// Default is the default value as text. Lists of strings are joined with
+	// commas and maps of strings are key=value pairs joined with commas.
+	// Anything more complicated is JSON.
+	Default    string
+	HasDefault bool
+	Sensitive  bool
+	// Options are the values a validation of the form
+	// contains([...], var.name) limits the variable to
+	Options []string
+}
+
+var variableStart = regexp.MustCompile(`(?m)^\s*variable\s`)
+
+var variableSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "description"}, {Name: "default"}, {Name: "sensitive"}},
+	Blocks:     []hcl.BlockHeaderSchema{{Type: "validation"}},
+}
+
+var validationSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "condition"}},
+}
+
+// Variable parses the text of a variable block for what it declares
+func (b Block) Variable() (Variable, error) {
+	v := Variable{Name: b.Name, Type: b.Type}
+
+	if !b.IsVariable() {
+		return v, fmt.Errorf("%s is a %s, not a variable", b.Name, b.Kind)
+	}
+
+	body, src, err := b.variableBody()
+	if err != nil {
+		return v, err
+	}
+
+	content, _, diags := body.PartialContent(variableSchema)
+	if diags.HasErrors() {
+		return v, fmt.Errorf("could not read variable %s: %s", b.Name, diags)
+	}
+
+	if attr, ok := content.Attributes["description"]; ok {
+		if val, diags := attr.Expr.Value(nil); !diags.HasErrors() && val.Type() == cty.String && val.IsKnown() && !val.IsNull() {
+			v.Description = val.AsString()
+		}
+	}
+
+	if attr, ok := content.Attributes["default"]; ok {
+		v.HasDefault = true
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			// Keep what the author wrote for defaults that need evaluating
+			rng := attr.Expr.Range()
+			v.Default = string(rng.SliceBytes(src))
+		} else {
+			v.Default = renderValue(val)
+		}
+	}
+
+	if attr, ok := content.Attributes["sensitive"]; ok {
+		if val, diags := attr.Expr.Value(nil); !diags.HasErrors() && val.Type() == cty.Bool && val.IsKnown() && !val.IsNull() {
+			v.Sensitive = val.True()
+		}
+	}
+
+	for _, validation := range content.Blocks {
+		vc, _, diags := validation.Body.PartialContent(validationSchema)
+		if diags.HasErrors() {
+			continue
+		}
+
+		if attr, ok := vc.Attributes["condition"]; ok {
+			if options := optionsFromCondition(conditionExpression(attr.Expr), b.Name); options != nil {
+				v.Options = options
+				break
+			}
+		}
+	}
+
+	return v, nil
+}
+
+// variableBody parses the text of a variable block, in whichever syntax it
+// was written, and returns its body and the source it was parsed from
+func (b Block) variableBody() (hcl.Body, []byte, error) {
+	if strings.HasSuffix(b.File, ".json") {
+		// The text of a JSON block is its name and object
+		src := []byte(fmt.Sprintf(`{"variable": {%s}}`, b.Text))
+		file, diags := hcljson.Parse(src, b.File)
+		if diags.HasErrors() {
+			return nil, nil, fmt.Errorf("could not parse variable %s: %s", b.Name, diags)
+		}
+
+		content, _, _ := file.Body.PartialContent(blockSchema)
+		for _, candidate := range content.Blocks {
+			if candidate.Type == "variable" {
+				return candidate.Body, src, nil
+			}
+		}
+
+		return nil, nil, fmt.Errorf("could not find a variable block for %s", b.Name)
+	}
+
+	// Text that didn't come from Extract can have more before the block
+	text := b.Text
+	if loc := variableStart.FindStringIndex(text); loc != nil {
+		text = text[loc[0]:]
+	}
+
+	file, diags := hclsyntax.ParseConfig([]byte(text), b.File, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, nil, fmt.Errorf("could not parse variable %s: %s", b.Name, diags)
+	}
+
+	for _, candidate := range file.Body.(*hclsyntax.Body).Blocks {
+		if candidate.Type == "variable" {
+			return candidate.Body, []byte(text), nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("could not find a variable block for %s", b.Name)
+}
+
+// conditionExpression returns a validation condition as native syntax. In
+// JSON files conditions are strings, like "${contains([...], var.name)}".
+func conditionExpression(expr hcl.Expression) hclsyntax.Expression {
+	if native, ok := expr.(hclsyntax.Expression); ok {
+		return native
+	}
+
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() || val.Type() != cty.String || !val.IsKnown() || val.IsNull() {
+		return nil
+	}
+
+	text := strings.TrimSpace(val.AsString())
+	if strings.HasPrefix(text, "${") && strings.HasSuffix(text, "}") {
+		text = text[2 : len(text)-1]
+	}
+
+	native, diags := hclsyntax.ParseExpression([]byte(text), "", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil
+	}
+
+	return native
+}
+
+// optionsFromCondition returns the values a condition like
+// contains(["a", "b"], var.name) allows, or nil if the condition isn't of
+// that form
+func optionsFromCondition(expr hclsyntax.Expression, name string) []string {
+	call, ok := expr.(*hclsyntax.FunctionCallExpr)
+	if !ok || call.Name != "contains" || len(call.Args) != 2 {
+		return nil
+	}
+
+	ref, ok := call.Args[1].(*hclsyntax.ScopeTraversalExpr)
+	if !ok || len(ref.Traversal) != 2 || ref.Traversal.RootName() != "var" {
+		return nil
+	}
+
+	if attr, ok := ref.Traversal[1].(hcl.TraverseAttr); !ok || attr.Name != name {
+		return nil
+	}
+
+	list, diags := call.Args[0].Value(nil)
+	if diags.HasErrors() || !list.IsWhollyKnown() || list.IsNull() {
+		return nil
+	}
+
+	options, ok := stringElements(list)
+	if !ok || len(options) == 0 {
+		return nil
+	}
+
+	return options
+}
+
+// stringElements returns the elements of a list, set or tuple of strings
+func stringElements(v cty.Value) ([]string, bool) {
+	t := v.Type()
+	if !t.IsListType() && !t.IsSetType() && !t.IsTupleType() {
+		return nil, false
+	}
+
+	result := []string{}
+	for it := v.ElementIterator(); it.Next(); {
+		_, el := it.Element()
+		if el.Type() != cty.String || el.IsNull() {
+			return nil, false
+		}
+		result = append(result, el.AsString())
+	}
+
+	return result, true
+}
+
+func renderValue(v cty.Value) string {
+	if v.IsNull() || !v.IsWhollyKnown() {
+		return ""
+	}
+
+	t := v.Type()
+	switch {
+	case t == cty.String:
+		return v.AsString()
+	case t == cty.Number:
+		return v.AsBigFloat().Text('f', -1)
+	case t == cty.Bool:
+		if v.True() {
+			return "true"
+		}
+		return "false"
+	}
+
+	if l, ok := stringElements(v); ok {
+		return strings.Join(l, ",")
+	}
+
+	if t.IsMapType() || t.IsObjectType() {
+		pairs := []string{}
+		all := true
+		for it := v.ElementIterator(); it.Next(); {
+			k, el := it.Element()
+			if el.Type() != cty.String || el.IsNull() {
+				all = false
+				break
+			}
+			pairs = append(pairs, fmt.Sprintf("%s=%s", k.AsString(), el.AsString()))
+		}
+		if all {
+			sort.Strings(pairs)
+			return strings.Join(pairs, ",")
+		}
+	}
+
+	b, err := ctyjson.Marshal(v, t)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
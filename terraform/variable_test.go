@@ -0,0 +1,179 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBlockVariable(t *testing.T) {
+	tests := map[string]struct {
+		in   Block
+		want Variable
+		err  bool
+	}{
+		"bare": {
+			in: Block{Name: "project_id", Kind: "variable", Type: "string", Text: `
+variable "project_id" {
+  type = string
+}`},
+			want: Variable{Name: "project_id", Type: "string"},
+		},
+		"full": {
+			in: Block{Name: "tier", Kind: "variable", Type: "string", Text: `variable "tier" {
+  description = "The default tier to run in"
+  type        = string
+  default     = "standard"
+  sensitive   = true
+
+  validation {
+    condition     = length(var.tier) > 0
+    error_message = "Pick a tier."
+  }
+
+  validation {
+    condition     = contains(["basic", "standard", "premium"], var.tier)
+    error_message = "Pick a real tier."
+  }
+}`},
+			want: Variable{
+				Name:        "tier",
+				Type:        "string",
+				Description: "The default tier to run in",
+				Default:     "standard",
+				HasDefault:  true,
+				Sensitive:   true,
+				Options:     []string{"basic", "standard", "premium"},
+			},
+		},
+		"previousline": {
+			in: Block{Name: "nodes", Kind: "variable", Type: "number", Text: `}
+variable "nodes" {
+  default = 3
+}`},
+			want: Variable{Name: "nodes", Type: "number", Default: "3", HasDefault: true},
+		},
+		"list": {
+			in: Block{Name: "apis", Kind: "variable", Type: "list(string)", Text: `variable "apis" {
+  default = [
+    "compute.googleapis.com",
+    "run.googleapis.com",
+  ]
+}`},
+			want: Variable{Name: "apis", Type: "list(string)", Default: "compute.googleapis.com,run.googleapis.com", HasDefault: true},
+		},
+		"map": {
+			in: Block{Name: "labels", Kind: "variable", Type: "map(string)", Text: `variable "labels" {
+  default = { team = "web", env = "dev" }
+}`},
+			want: Variable{Name: "labels", Type: "map(string)", Default: "env=dev,team=web", HasDefault: true},
+		},
+		"complex": {
+			in: Block{Name: "sizes", Kind: "variable", Type: "list(number)", Text: `variable "sizes" {
+  default = [1, 2]
+}`},
+			want: Variable{Name: "sizes", Type: "list(number)", Default: "[1,2]", HasDefault: true},
+		},
+		"bool": {
+			in: Block{Name: "debug", Kind: "variable", Type: "bool", Text: `variable "debug" {
+  default = false
+}`},
+			want: Variable{Name: "debug", Type: "bool", Default: "false", HasDefault: true},
+		},
+		"null": {
+			in: Block{Name: "optional", Kind: "variable", Type: "string", Text: `variable "optional" {
+  default = null
+}`},
+			want: Variable{Name: "optional", Type: "string", HasDefault: true},
+		},
+		"expression": {
+			in: Block{Name: "when", Kind: "variable", Type: "string", Text: `variable "when" {
+  default = timestamp()
+}`},
+			want: Variable{Name: "when", Type: "string", Default: "timestamp()", HasDefault: true},
+		},
+		"othervariable": {
+			in: Block{Name: "tier", Kind: "variable", Type: "string", Text: `variable "tier" {
+  validation {
+    condition     = contains(["a", "b"], var.other)
+    error_message = "Nope."
+  }
+}`},
+			want: Variable{Name: "tier", Type: "string"},
+		},
+		"json": {
+			in: Block{Name: "tier", Kind: "variable", Type: "string", File: "variables.tf.json", Text: `"tier": {
+      "description": "The tier to run in, like ${standard}",
+      "default": "standard",
+      "sensitive": true,
+      "validation": [
+        {
+          "condition": "${contains([\"basic\", \"standard\"], var.tier)}",
+          "error_message": "Pick a real tier."
+        }
+      ]
+    }`},
+			want: Variable{
+				Name:        "tier",
+				Type:        "string",
+				Description: "The tier to run in, like ${standard}",
+				Default:     "standard",
+				HasDefault:  true,
+				Sensitive:   true,
+				Options:     []string{"basic", "standard"},
+			},
+		},
+		"jsonlist": {
+			in:   Block{Name: "apis", Kind: "variable", File: "variables.tf.json", Text: `"apis": {"default": ["compute.googleapis.com", "run.googleapis.com"]}`},
+			want: Variable{Name: "apis", Default: "compute.googleapis.com,run.googleapis.com", HasDefault: true},
+		},
+		"notvariable": {
+			in:   Block{Name: "main", Kind: "managed", Text: `resource "google_compute_instance" "main" {}`},
+			want: Variable{Name: "main"},
+			err:  true,
+		},
+		"unparseable": {
+			in:   Block{Name: "test", Kind: "variable", Text: "default "},
+			want: Variable{Name: "test"},
+			err:  true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.in.Variable()
+
+			if tc.err != (err != nil) {
+				t.Fatalf("expected error: %t, got: %v", tc.err, err)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestBlockNoDefaultDescription(t *testing.T) {
+	b := Block{Name: "region", Kind: "variable", Text: `variable "region" {
+  description = "Leave out to use the default region"
+  type        = string
+}`}
+
+	if !b.NoDefault() {
+		t.Fatalf("expected a description mentioning default not to count as a default")
+	}
+}
@@ -0,0 +1,31 @@
+resource "google_compute_instance" "web" {
+  name         = "${var.basename}-web"
+  machine_type = "e2-small"
+  zone         = var.zone
+  tags         = ["http", "https"]
+
+  boot_disk {
+    initialize_params {
+      image = "debian-cloud/debian-11"
+    }
+  }
+
+  network_interface {
+    network = "default"
+  }
+
+  network_interface {
+    network = "internal"
+  }
+
+  dynamic "attached_disk" {
+    for_each = var.disks
+    content {
+      source = attached_disk.value
+    }
+  }
+}
+
+data "google_project" "project" {
+  project_id = var.project_id
+}
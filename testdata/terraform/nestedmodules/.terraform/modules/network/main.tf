@@ -0,0 +1,8 @@
+variable "project_id" {
+  type = string
+}
+
+resource "google_compute_network" "network" {
+  project = var.project_id
+  name    = "network"
+}
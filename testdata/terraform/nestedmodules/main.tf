@@ -0,0 +1,24 @@
+variable "project_id" {
+  type = string
+}
+
+module "database" {
+  source     = "./modules/database"
+  project_id = var.project_id
+}
+
+module "network" {
+  source     = "terraform-google-modules/network/google"
+  version    = "~> 7.0"
+  project_id = var.project_id
+}
+
+module "missing" {
+  source = "terraform-google-modules/missing/google"
+}
+
+resource "google_storage_bucket" "assets" {
+  project  = var.project_id
+  name     = "${var.project_id}-assets"
+  location = "US"
+}
@@ -0,0 +1,14 @@
+variable "project_id" {
+  type = string
+}
+
+resource "google_sql_database_instance" "main" {
+  project          = var.project_id
+  name             = "main"
+  database_version = "POSTGRES_14"
+}
+
+module "users" {
+  source     = "../users"
+  project_id = var.project_id
+}
@@ -0,0 +1,9 @@
+variable "project_id" {
+  type = string
+}
+
+resource "google_sql_user" "admin" {
+  project  = var.project_id
+  name     = "admin"
+  instance = "main"
+}
@@ -0,0 +1,14 @@
+resource "google_cloud_run_service" "app" {
+  name     = "app"
+  location = "us-central1"
+}
+
+output "url" {
+  description = "Where the app is served"
+  value       = google_cloud_run_service.app.status[0].url
+}
+
+output "db_password" {
+  value     = random_password.db.result
+  sensitive = true
+}
@@ -0,0 +1,15 @@
+variable "project_id" { type = string }
+
+resource "google_compute_instance" "web" {
+  name = "web-{x}"
+  metadata_startup_script = <<-EOT
+    #!/bin/bash
+    if [ -z "$X" ]; then
+      echo "}"
+    fi
+    echo "{{ not a brace }"
+  EOT
+  labels = { app = "web", "tier" = "front" } # }}}
+}
+
+resource "google_storage_bucket" "assets" { name = "assets" }
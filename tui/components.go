@@ -18,8 +18,10 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/GoogleCloudPlatform/deploystack/config"
+	"github.com/GoogleCloudPlatform/deploystack/terraform"
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/lipgloss"
 	"golang.org/x/text/cases"
@@ -79,6 +81,7 @@ func (d *description) parse() (productList, []string) {
 
 func (d description) render() string {
 	doc := strings.Builder{}
+	txt := newTexts(d.stack)
 
 	list, additionalText := d.parse()
 
@@ -106,7 +109,7 @@ func (d description) render() string {
 	t.SetStyles(tableStyle)
 
 	if len(list) > 0 {
-		doc.WriteString(normal.Render("This process will install the following resources:"))
+		doc.WriteString(normal.Render(txt.text(msgResourcesIntro)))
 		doc.WriteString(t.View())
 		doc.WriteString("\n\n")
 	}
@@ -116,19 +119,18 @@ func (d description) render() string {
 		doc.WriteString("\n\n")
 	}
 
-	doc.WriteString(normal.Render("It's going to take around "))
-	doc.WriteString(strong.Render(strconv.Itoa(d.stack.Config.Duration)))
-
+	duration := msgDuration
 	if d.stack.Config.Duration == 1 {
-		doc.WriteString(normal.Render(" minute."))
-	} else {
-		doc.WriteString(normal.Render(" minutes."))
+		duration = msgDurationOne
 	}
+	before, after, _ := strings.Cut(txt.text(duration), "%s")
+	doc.WriteString(normal.Render(before))
+	doc.WriteString(strong.Render(strconv.Itoa(d.stack.Config.Duration)))
+	doc.WriteString(normal.Render(after))
 	doc.WriteString("\n\n")
 
 	if len(d.stack.Config.DocumentationLink) > 0 {
-		doc.WriteString(normal.Render("If you would like more information about this stack, "))
-		doc.WriteString(normal.Render("please read the documentation at: "))
+		doc.WriteString(normal.Render(txt.text(msgDocumentation)))
 		doc.WriteString(url.Render(d.stack.Config.DocumentationLink))
 		doc.WriteString("\n\n")
 	}
@@ -137,7 +139,8 @@ func (d description) render() string {
 }
 
 type errorAlert struct {
-	err errMsg
+	err   errMsg
+	texts texts
 }
 
 func (e errorAlert) Render() string {
@@ -148,33 +151,34 @@ func (e errorAlert) Render() string {
 	style.Height(height)
 
 	sb.WriteString("\n")
-	sb.WriteString(boldAlert.Render("There was an error!"))
+	sb.WriteString(boldAlert.Render(e.texts.text(msgErrorTitle)))
 	sb.WriteString("\n")
 	if e.err.usermsg != "" {
 		sb.WriteString(e.err.usermsg)
 		sb.WriteString("\n")
 	}
 	sb.WriteString("\n")
-	sb.WriteString("Details: \n")
+	sb.WriteString(e.texts.text(msgErrorDetails))
+	sb.WriteString("\n")
 	sb.WriteString(e.err.Error())
 	sb.WriteString("\n")
 	sb.WriteString("\n")
 
 	if !e.err.quit {
-		sb.WriteString("You can exit the program by typing ")
+		sb.WriteString(e.texts.text(msgErrorExit))
 		sb.WriteString(cmdStyle.Render("ctr+c."))
 	}
 
 	if e.err.target != "" {
-		text := " Press the Enter Key to go back and change choice "
+		prompt := msgErrorBack
 
 		if e.err.target == "quit" {
-			text = " Press the Enter Key exit "
+			prompt = msgErrorQuit
 		}
 
 		sb.WriteString("\n")
 		sb.WriteString("\n")
-		sb.WriteString(bodyStyle.Render(promptStyle.Render(text)))
+		sb.WriteString(bodyStyle.Render(promptStyle.Render(fmt.Sprintf(" %s ", e.texts.text(prompt)))))
 		sb.WriteString("\n")
 	}
 
@@ -204,14 +208,14 @@ func (h header) render() string {
 	return doc.String()
 }
 
-func drawProgress(percent int) string {
+func drawProgress(t texts, percent int) string {
 
 	sb := strings.Builder{}
 
-	label := "   Progress "
+	label := fmt.Sprintf("   %s ", t.text(msgProgress))
 	sb.WriteString(textStyle.Render(label))
 
-	totalWidth := hardWidthLimit - len(label)
+	totalWidth := hardWidthLimit - lipgloss.Width(label)
 	completeLength := int(float32(totalWidth) * (float32(percent) / float32(100)))
 	pendingLength := totalWidth - completeLength
 
@@ -231,6 +235,147 @@ func drawProgress(percent int) string {
 	return sb.String()
 }
 
+// serviceProgress shows how enabling each of the services a stack needs is
+// going. Services are enabled in parallel, so it is safe to update from more
+// than one goroutine.
+type serviceProgress struct {
+	mu       *sync.Mutex
+	texts    texts
+	services []string
+	done     map[string]error
+}
+
+func newServiceProgress(t texts, services []string) *serviceProgress {
+	return &serviceProgress{mu: &sync.Mutex{}, texts: t, services: services, done: map[string]error{}}
+}
+
+func (s *serviceProgress) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done = map[string]error{}
+}
+
+func (s *serviceProgress) finish(service string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done[service] = err
+}
+
+// failures are the services that could not be enabled, with why
+func (s *serviceProgress) failures() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []string{}
+	for _, v := range s.services {
+		if err := s.done[v]; err != nil {
+			result = append(result, fmt.Sprintf("%s (%s)", v, err))
+		}
+	}
+	return result
+}
+
+func (s *serviceProgress) render() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sb := strings.Builder{}
+	sb.WriteString(textStyle.Render(s.texts.textf(msgServicesChecked, len(s.done), len(s.services))))
+	sb.WriteString("\n")
+
+	for _, v := range s.services {
+		err, ok := s.done[v]
+		switch {
+		case !ok:
+			sb.WriteString(pendingStyle.Render(fmt.Sprintf(" … %s", v)))
+		case err != nil:
+			sb.WriteString(alertStyle.Render(fmt.Sprintf(" ✗ %s", v)))
+		default:
+			sb.WriteString(completeStyle.Render(fmt.Sprintf(" ✓ %s", v)))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// permissionReport shows which of the permissions a stack needs are missing
+// on each of its projects, and the roles that would grant them. It is filled
+// in by a pre-processor, so it is safe to update from another goroutine.
+type permissionReport struct {
+	mu          *sync.Mutex
+	texts       texts
+	permissions terraform.Permissions
+	needed      []string
+	results     []projectPermissions
+	checked     bool
+}
+
+// projectPermissions are the permissions missing on one project
+type projectPermissions struct {
+	project string
+	missing []string
+}
+
+func newPermissionReport(t texts, permissions terraform.Permissions, needed []string) *permissionReport {
+	return &permissionReport{mu: &sync.Mutex{}, texts: t, permissions: permissions, needed: needed}
+}
+
+func (p *permissionReport) finish(results []projectPermissions) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.results = results
+	p.checked = true
+}
+
+func (p *permissionReport) render() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.checked {
+		return ""
+	}
+
+	sb := strings.Builder{}
+
+	missing := 0
+	for _, v := range p.results {
+		missing += len(v.missing)
+	}
+	needed := len(p.needed) * len(p.results)
+
+	if missing == 0 {
+		sb.WriteString(completeStyle.Render(fmt.Sprintf(" ✓ %s", p.texts.textf(msgPermissionsGranted, needed))))
+		sb.WriteString("\n")
+		return sb.String()
+	}
+
+	sb.WriteString(textStyle.Render(p.texts.textf(msgPermissionsMissing, missing, needed)))
+	sb.WriteString("\n")
+
+	for _, result := range p.results {
+		if len(result.missing) == 0 {
+			continue
+		}
+
+		if len(p.results) > 1 {
+			sb.WriteString(textStyle.Render(p.texts.textf(msgPermissionsProject, result.project)))
+			sb.WriteString("\n")
+		}
+
+		for _, v := range result.missing {
+			sb.WriteString(alertStyle.Render(fmt.Sprintf(" ✗ %s", v)))
+			sb.WriteString("\n")
+			if roles := p.permissions.RolesFor(v); len(roles) > 0 {
+				sb.WriteString(textStyle.Render(fmt.Sprintf("   %s", p.texts.textf(msgPermissionsRoles, strings.Join(roles, ", ")))))
+				sb.WriteString("\n")
+			}
+		}
+	}
+
+	return sb.String()
+}
+
 type settingsTable struct {
 	stack *config.Stack
 }
@@ -241,6 +386,7 @@ func newSettingsTable(s *config.Stack) settingsTable {
 
 func (s settingsTable) render() string {
 	doc := strings.Builder{}
+	txt := newTexts(s.stack)
 	wSetting := 0
 	wValue := 0
 
@@ -250,29 +396,33 @@ func (s settingsTable) render() string {
 
 	if s := s.stack.Settings.Find("stack_name"); s != nil && len(s.Value) > 0 {
 		rows = append(rows, table.Row{
-			titleStyle.Render("Stack Name"),
+			titleStyle.Render(txt.text(msgStackName)),
 			strong.Render(s.Value),
+			s.Source,
 		})
 	}
 
 	if s := s.stack.Settings.Find("project_name"); s != nil && len(s.Value) > 0 {
 		rows = append(rows, table.Row{
-			titleStyle.Render("Project Name"),
+			titleStyle.Render(txt.text(msgProjectName)),
 			strong.Render(s.Value),
+			s.Source,
 		})
 	}
 
 	if s := s.stack.Settings.Find("project_id"); s != nil && len(s.Value) > 0 {
 		rows = append(rows, table.Row{
-			titleStyle.Render("Project ID"),
+			titleStyle.Render(txt.text(msgProjectID)),
 			strong.Render(s.Value),
+			s.Source,
 		})
 	}
 
 	if s := s.stack.Settings.Find("project_number"); s != nil && len(s.Value) > 0 {
 		rows = append(rows, table.Row{
-			titleStyle.Render("Project Number"),
+			titleStyle.Render(txt.text(msgProjectNumber)),
 			strong.Render(s.Value),
+			s.Source,
 		})
 	}
 
@@ -280,6 +430,9 @@ func (s settingsTable) render() string {
 
 		rawValue := setting.TFvarsValue()
 		rawValue = strings.Trim(rawValue, "\"")
+		if setting.Secret {
+			rawValue = redacted
+		}
 		value := strong.Render(strings.TrimSpace(rawValue))
 
 		if len(rawValue) > 45 {
@@ -308,13 +461,14 @@ func (s settingsTable) render() string {
 		nameRaw = strings.ReplaceAll(nameRaw, "_", " ")
 		nameRaw = strings.ReplaceAll(nameRaw, "-", " ")
 		formatted := cases.Title(language.English).String(nameRaw)
-		rows = append(rows, table.Row{titleStyle.Render(formatted), value})
+		rows = append(rows, table.Row{titleStyle.Render(formatted), value, setting.Source})
 
 	}
 
 	columns := []table.Column{
-		{Title: "Setting", Width: 35},
-		{Title: "Value", Width: 55},
+		{Title: txt.text(msgColumnSetting), Width: 30},
+		{Title: txt.text(msgColumnValue), Width: 55},
+		{Title: txt.text(msgColumnSource), Width: 10},
 	}
 
 	t := table.New(
@@ -332,6 +486,114 @@ func (s settingsTable) render() string {
 	return doc.String()
 }
 
+// outputList shows the outputs of an installed stack, with the descriptions
+// from their output blocks. Sensitive outputs are left out, and URLs are
+// shown as links.
+type outputList struct {
+	outputs      terraform.OutputValues
+	descriptions map[string]string
+}
+
+func newOutputList(outputs terraform.OutputValues, descriptions map[string]string) outputList {
+	return outputList{outputs: outputs, descriptions: descriptions}
+}
+
+func (o outputList) render() string {
+	doc := strings.Builder{}
+
+	for _, v := range o.outputs {
+		if v.Sensitive || v.Value == "" {
+			continue
+		}
+
+		nameRaw := strings.ReplaceAll(v.Name, "_", " ")
+		nameRaw = strings.ReplaceAll(nameRaw, "-", " ")
+		doc.WriteString(titleStyle.Render(cases.Title(language.English).String(nameRaw)))
+		doc.WriteString("\n")
+
+		value := strong.Render(v.Value)
+		if strings.HasPrefix(v.Value, "http://") || strings.HasPrefix(v.Value, "https://") {
+			value = url.Render(v.Value)
+		}
+		doc.WriteString(fmt.Sprintf("  %s\n", value))
+
+		if desc := o.descriptions[v.Name]; desc != "" {
+			doc.WriteString(fmt.Sprintf("  %s\n", normal.Render(desc)))
+		}
+		doc.WriteString("\n")
+	}
+
+	return doc.String()
+}
+
+const pricingCalculator = "https://cloud.google.com/products/calculator"
+
+// costEstimate shows what a stack is expected to cost a month. It is worked
+// out each time it is shown, so it reflects the settings collected so far.
+type costEstimate struct {
+	stack   *config.Stack
+	blocks  terraform.Blocks
+	pricing terraform.Pricing
+}
+
+func newCostEstimate(s *config.Stack, blocks terraform.Blocks, pricing terraform.Pricing) costEstimate {
+	return costEstimate{stack: s, blocks: blocks, pricing: pricing}
+}
+
+func (c costEstimate) estimate() terraform.Estimate {
+	settings := map[string]string{}
+	for _, v := range c.stack.Settings {
+		settings[v.Name] = v.Value
+	}
+	return c.pricing.Estimate(c.blocks, settings)
+}
+
+func (c costEstimate) render() string {
+	doc := strings.Builder{}
+	txt := newTexts(c.stack)
+	e := c.estimate()
+
+	rows := []table.Row{}
+	for _, v := range e.Items {
+		cost := "-"
+		if v.Monthly > 0 {
+			cost = fmt.Sprintf("$%.2f", v.Monthly)
+		}
+		rows = append(rows, table.Row{v.Resource, v.Detail, cost})
+	}
+
+	columns := []table.Column{
+		{Title: txt.text(msgColumnResource), Width: 40},
+		{Title: txt.text(msgColumnDetail), Width: 45},
+		{Title: txt.text(msgColumnMonthly), Width: 10},
+	}
+
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithRows(rows),
+		table.WithFocused(false),
+		table.WithHeight(len(rows)),
+	)
+	t.SetStyles(tableStyle)
+
+	doc.WriteString("\n")
+	doc.WriteString(t.View())
+	doc.WriteString("\n\n")
+	doc.WriteString(strong.Render(txt.textf(msgCostTotal, fmt.Sprintf("$%.2f", e.Total))))
+	doc.WriteString("\n\n")
+
+	if len(e.Unpriced) > 0 {
+		doc.WriteString(normal.Render(txt.textf(msgCostUnpriced, strings.Join(e.Unpriced, ", "))))
+		doc.WriteString("\n\n")
+	}
+
+	doc.WriteString(normal.Render(txt.text(msgCostDisclaimer)))
+	doc.WriteString(url.Render(pricingCalculator))
+	doc.WriteString("\n")
+
+	return doc.String()
+}
+
 type textBlock string
 
 func (t textBlock) render() string    { return string(t) }
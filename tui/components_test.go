@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/GoogleCloudPlatform/deploystack/config"
+	"github.com/GoogleCloudPlatform/deploystack/terraform"
 	"github.com/kylelemons/godebug/diff"
 )
 
@@ -54,7 +55,7 @@ func TestDrawProgress(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			got := drawProgress(tc.in)
+			got := drawProgress(texts{}, tc.in)
 
 			got = strings.ReplaceAll(got, "\x1b[1;m", "")
 			got = strings.ReplaceAll(got, "\x1b[0m", "")
@@ -207,7 +208,7 @@ func TestErrorAlertRender(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			e := errorAlert{tc.errMsg}
+			e := errorAlert{err: tc.errMsg}
 
 			testdata := filepath.Join(testFilesDir, "tui/testdata", tc.outputFile)
 
@@ -280,3 +281,142 @@ func TestSettingsTableRender(t *testing.T) {
 		})
 	}
 }
+
+func TestOutputListRender(t *testing.T) {
+	outputs := terraform.OutputValues{
+		{Name: "db_password", Value: "hunter2", Sensitive: true},
+		{Name: "empty", Value: ""},
+		{Name: "node_count", Value: "3"},
+		{Name: "service_url", Value: "https://app.example.com"},
+	}
+	descriptions := map[string]string{"service_url": "Where the app is served"}
+
+	got := newOutputList(outputs, descriptions).render()
+
+	tests := map[string]struct {
+		text string
+		want bool
+	}{
+		"title":       {text: "Service Url", want: true},
+		"url":         {text: url.Render("https://app.example.com"), want: true},
+		"value":       {text: strong.Render("3"), want: true},
+		"description": {text: "Where the app is served", want: true},
+		"sensitive":   {text: "hunter2"},
+		"empty":       {text: "Empty"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if strings.Contains(got, tc.text) != tc.want {
+				t.Fatalf("expected %q in output: %t, got: %s", tc.text, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCostEstimateRender(t *testing.T) {
+	pricing := terraform.Pricing{
+		Resources:    map[string]terraform.Price{"google_cloud_run_service": {Note: "Billed per request"}},
+		MachineTypes: map[string]float64{"e2-small": 12},
+		DiskTypes:    map[string]float64{"pd-standard": 0.05},
+	}
+	blocks := terraform.Blocks{
+		{Name: "web", Kind: "managed", Type: "google_compute_instance"},
+		{Name: "other", Kind: "managed", Type: "google_compute_instance", Attr: map[string]string{"machine_type": "var.other_type"}},
+		{Name: "app", Kind: "managed", Type: "google_cloud_run_service"},
+	}
+
+	stack := config.NewStack()
+	stack.AddSetting(terraform.SettingMachineType, "e2-small")
+	stack.AddSetting("other_type", "z9-huge")
+
+	got := newCostEstimate(&stack, blocks, pricing).render()
+
+	tests := map[string]string{
+		"instance":   "e2-small, 10 GB pd-standard",
+		"price":      "$12.50",
+		"note":       "Billed per request",
+		"total":      textf(msgCostTotal, "$12.50"),
+		"unpriced":   `google_compute_instance.other (machine type "z9-huge")`,
+		"disclaimer": pricingCalculator,
+	}
+
+	for name, want := range tests {
+		t.Run(name, func(t *testing.T) {
+			if !strings.Contains(got, want) {
+				t.Fatalf("expected %q in: %s", want, got)
+			}
+		})
+	}
+}
+
+func TestPermissionReportRender(t *testing.T) {
+	permissions := terraform.Permissions{
+		Roles: map[string][]string{
+			"roles/compute.admin":                   {"compute.*"},
+			"roles/resourcemanager.projectIamAdmin": {"resourcemanager.projects.setIamPolicy"},
+		},
+	}
+	needed := []string{"compute.instances.create", "resourcemanager.projects.setIamPolicy", "storage.buckets.create"}
+
+	tests := map[string]struct {
+		results []projectPermissions
+		checked bool
+		want    []string
+		notwant []string
+	}{
+		"unchecked": {
+			notwant: []string{"compute.instances.create"},
+		},
+		"granted": {
+			results: []projectPermissions{{project: "test-project"}},
+			checked: true,
+			want:    []string{textf(msgPermissionsGranted, 3)},
+		},
+		"missing": {
+			results: []projectPermissions{{project: "test-project", missing: []string{"compute.instances.create", "storage.buckets.create"}}},
+			checked: true,
+			want: []string{
+				textf(msgPermissionsMissing, 2, 3),
+				"compute.instances.create",
+				textf(msgPermissionsRoles, "roles/compute.admin"),
+				"storage.buckets.create",
+			},
+			notwant: []string{"resourcemanager.projects.setIamPolicy", textf(msgPermissionsProject, "test-project")},
+		},
+		"projects": {
+			results: []projectPermissions{
+				{project: "test-project"},
+				{project: "test-project-2", missing: []string{"storage.buckets.create"}},
+			},
+			checked: true,
+			want: []string{
+				textf(msgPermissionsMissing, 1, 6),
+				textf(msgPermissionsProject, "test-project-2"),
+				"storage.buckets.create",
+			},
+			notwant: []string{textf(msgPermissionsProject, "test-project")},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := newPermissionReport(texts{}, permissions, needed)
+			if tc.checked {
+				r.finish(tc.results)
+			}
+			got := r.render()
+
+			for _, v := range tc.want {
+				if !strings.Contains(got, v) {
+					t.Fatalf("expected %q in: %s", v, got)
+				}
+			}
+			for _, v := range tc.notwant {
+				if strings.Contains(got, v) {
+					t.Fatalf("expected no %q in: %s", v, got)
+				}
+			}
+		})
+	}
+}
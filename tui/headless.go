@@ -0,0 +1,524 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/deploystack/config"
+	"github.com/GoogleCloudPlatform/deploystack/gcloud"
+	"github.com/charmbracelet/bubbles/list"
+	"gopkg.in/yaml.v2"
+)
+
+// AnswerEnvPrefix is the prefix for environment variables that override
+// entries in an answers file. DEPLOYSTACK_ANSWER_REGION=us-east1 sets region.
+const AnswerEnvPrefix = "DEPLOYSTACK_ANSWER_"
+
+// Answers are the responses to the questions DeployStack would ask a user,
+// keyed by setting name, used to run a stack without the TUI.
+type Answers map[string]string
+
+// NewAnswersYAML returns Answers from yaml content
+func NewAnswersYAML(content []byte) (Answers, error) {
+	raw := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("unable to convert content to Answers: %s", err)
+	}
+
+	return newAnswers(raw), nil
+}
+
+// NewAnswersJSON returns Answers from json content
+func NewAnswersJSON(content []byte) (Answers, error) {
+	raw := map[string]interface{}{}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("unable to convert content to Answers: %s", err)
+	}
+
+	return newAnswers(raw), nil
+}
+
+func newAnswers(raw map[string]interface{}) Answers {
+	result := Answers{}
+	for i, v := range raw {
+		switch val := v.(type) {
+		case nil:
+			result[strings.ToLower(i)] = ""
+		case []interface{}:
+			sl := []string{}
+			for _, item := range val {
+				sl = append(sl, answerValue(item))
+			}
+			result[strings.ToLower(i)] = fmt.Sprintf("[%s]", strings.Join(sl, ","))
+		case map[string]interface{}:
+			sl := []string{}
+			for k, item := range val {
+				sl = append(sl, fmt.Sprintf("%s=%s", k, answerValue(item)))
+			}
+			sort.Strings(sl)
+			result[strings.ToLower(i)] = strings.Join(sl, ",")
+		case map[interface{}]interface{}:
+			sl := []string{}
+			for k, item := range val {
+				sl = append(sl, fmt.Sprintf("%v=%s", k, answerValue(item)))
+			}
+			sort.Strings(sl)
+			result[strings.ToLower(i)] = strings.Join(sl, ",")
+		default:
+			result[strings.ToLower(i)] = answerValue(val)
+		}
+	}
+	return result
+}
+
+// answerValue writes a single answer the way it was written in the file, so
+// numbers like 1000000 don't come out as 1e+06
+func answerValue(v interface{}) string {
+	switch val := v.(type) {
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// ReadAnswers reads an answers file in either yaml or json
+func ReadAnswers(file string) (Answers, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read answers file (%s): %s", file, err)
+	}
+
+	if filepath.Ext(file) == ".json" {
+		return NewAnswersJSON(content)
+	}
+
+	return NewAnswersYAML(content)
+}
+
+// Override replaces answers with any DEPLOYSTACK_ANSWER_ environment
+// variables in env, which is expected in the format of os.Environ.
+func (a Answers) Override(env []string) {
+	for _, v := range env {
+		if !strings.HasPrefix(v, AnswerEnvPrefix) {
+			continue
+		}
+
+		sl := strings.SplitN(strings.TrimPrefix(v, AnswerEnvPrefix), "=", 2)
+		if len(sl) != 2 || sl[0] == "" {
+			continue
+		}
+
+		a[strings.ToLower(sl[0])] = sl[1]
+	}
+}
+
+func (a Answers) get(keys ...string) (string, bool) {
+	for _, k := range keys {
+		if v, ok := a[strings.ToLower(k)]; ok {
+			return v, true
+		}
+
+		// Environment variables can't have hyphens in them
+		if v, ok := a[strings.ReplaceAll(strings.ToLower(k), "-", "_")]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// AnswerError describes a question that could not be answered headlessly
+type AnswerError struct {
+	Key    string
+	Value  string
+	Reason string
+}
+
+func (a AnswerError) Error() string {
+	if a.Value == "" {
+		return fmt.Sprintf("%s: %s", a.Key, a.Reason)
+	}
+	return fmt.Sprintf("%s: '%s' %s", a.Key, a.Value, a.Reason)
+}
+
+// AnswerErrors is the report of every question that could not be answered,
+// one to a line
+type AnswerErrors []AnswerError
+
+func (a AnswerErrors) Error() string {
+	sb := strings.Builder{}
+	for _, v := range a {
+		sb.WriteString(fmt.Sprintf("  %s\n", v.Error()))
+	}
+	return sb.String()
+}
+
+// HeadlessOptions change how a stack is run without the TUI
+type HeadlessOptions struct {
+	// Strict makes problems the UI lets users carry on past, like missing
+	// permissions, errors rather than warnings.
+	Strict bool
+	// CreateProjects has a project answer that doesn't exist created, the
+	// same as picking "Create New Project" in the UI, rather than reported as
+	// unknown.
+	CreateProjects bool
+}
+
+// RunHeadless takes a deploystack configuration and a set of answers and does
+// everything Run does without presenting a user interface. It walks the same
+// queue of pages, runs the same processors and writes the same tfvars file.
+// Problems the UI lets users carry on past, like missing permissions, are
+// printed as warnings, unless opts makes them errors.
+func RunHeadless(s *config.Stack, answers Answers, opts HeadlessOptions, useMock bool) error {
+	defaultUserAgent := fmt.Sprintf("deploystack/%s", s.Config.Name)
+
+	client := gcloud.NewClient(context.Background(), defaultUserAgent)
+	q := NewQueue(s, &client)
+
+	if useMock {
+		q = NewQueue(s, GetMock(0))
+	}
+
+	q.Save(headlessStrict, opts.Strict)
+	q.Save(headlessCreateProjects, opts.CreateProjects)
+	q.InitializeUI()
+
+	err := q.answer(answers)
+	if warnings, ok := q.Get(headlessWarnings).([]string); ok {
+		for _, v := range warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", v)
+		}
+	}
+	if errs, ok := err.(AnswerErrors); ok {
+		return fmt.Errorf("%s\n%w", q.text(msgAnswersFailed), errs)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := q.deliverSettings(); err != nil {
+		return err
+	}
+
+	fmt.Print(titleStyle.Render("Deploystack"))
+	fmt.Print("\n")
+	fmt.Print(subTitleStyle.Render(s.Config.Title))
+	fmt.Print("\n")
+	fmt.Print(strong.Render(q.text(msgSettingsProceed)))
+	fmt.Print(q.getSettings())
+
+	return nil
+}
+
+// answer walks the queue the way a user would, feeding each page from the
+// answers instead of the keyboard. It works on its own copy of the answers,
+// as answering some pages fills in others, like the project creator.
+func (q *Queue) answer(given Answers) error {
+	errs := AnswerErrors{}
+
+	answers := Answers{}
+	for k, v := range given {
+		answers[k] = v
+	}
+
+	for q.current = 0; q.current < len(q.models); q.current++ {
+		var err *AnswerError
+
+		if q.answered(q.models[q.current].getKey()) {
+			continue
+		}
+
+		if !q.models[q.current].active() {
+			q.stack.DeleteSetting(q.models[q.current].getKey())
+			continue
+		}
+		q.resolveDefault(q.models[q.current])
+
+		switch m := q.models[q.current].(type) {
+		case *page:
+			if m.preProcessor == nil {
+				break
+			}
+			if msg, ok := m.preProcessor().(errMsg); ok {
+				if strict, _ := q.Get(headlessStrict).(bool); msg.warning && !strict {
+					warnings, _ := q.Get(headlessWarnings).([]string)
+					q.Save(headlessWarnings, append(warnings, fmt.Sprintf("%s: %s", m.key, msg.Error())))
+					break
+				}
+				err = &AnswerError{Key: m.key, Reason: msg.Error()}
+			}
+		case *textInput:
+			err = q.answerTextInput(m, answers)
+		case *listInput:
+			err = q.answerListInput(m, answers)
+		case *picker:
+			err = q.answerPicker(m, answers)
+		}
+
+		if err != nil {
+			errs = append(errs, *err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (q *Queue) answerTextInput(p *textInput, answers Answers) *AnswerError {
+	keyTarget := strings.ReplaceAll(p.key, projNewSuffix, "")
+
+	if q.stack.GetSetting(p.key) != "" || q.stack.GetSetting(keyTarget) != "" {
+		return nil
+	}
+
+	val, ok := answers.get(p.key, keyTarget)
+	p.source = headlessSource(ok && val != "")
+	if !ok || val == "" {
+		val = p.ti.Placeholder
+	}
+
+	if val == "" {
+		return &AnswerError{Key: keyTarget, Reason: q.text(msgAnswerRequired)}
+	}
+	p.value = val
+
+	if p.postProcessor == nil {
+		if !p.omitFromSettings {
+			q.stack.AddSettingSource(p.key, p.value, p.source)
+		}
+		return nil
+	}
+
+	switch msg := p.postProcessor(p.value, q)().(type) {
+	case errMsg:
+		return &AnswerError{Key: keyTarget, Value: val, Reason: msg.reason()}
+	case successMsg:
+		newValue, source := p.value, p.source
+		if msg.msg == "prependProject" {
+			currentProject := q.Get("currentProject").(string)
+			newValue = fmt.Sprintf("%s-%s", currentProject, newValue)
+			source = config.SourceComputed
+		}
+
+		if !msg.unset && !p.omitFromSettings {
+			q.stack.AddSettingSource(keyTarget, newValue, source)
+		}
+		if msg.unset {
+			q.claimSetting(keyTarget, source)
+		}
+	}
+
+	return nil
+}
+
+func (q *Queue) answerListInput(p *listInput, answers Answers) *AnswerError {
+	if q.stack.GetSetting(p.key) != "" {
+		return nil
+	}
+
+	val, ok := answers.get(p.key)
+	p.source = headlessSource(ok && val != "")
+	if !ok || val == "" {
+		val = p.defaults
+	}
+
+	if val == "" {
+		return &AnswerError{Key: p.key, Reason: q.text(msgAnswerRequired)}
+	}
+	p.value = val
+
+	if p.postProcessor == nil {
+		if !p.omitFromSettings {
+			q.stack.AddSettingSource(p.key, p.value, p.source)
+		}
+		return nil
+	}
+
+	switch msg := p.postProcessor(p.value, q)().(type) {
+	case errMsg:
+		return &AnswerError{Key: p.key, Value: val, Reason: msg.reason()}
+	case successMsg:
+		if !msg.unset && !p.omitFromSettings {
+			q.stack.AddSettingSource(p.key, p.value, p.source)
+		}
+		if msg.unset {
+			q.claimSetting(p.key, p.source)
+		}
+	}
+
+	return nil
+}
+
+func (q *Queue) answerPicker(p *picker, answers Answers) *AnswerError {
+	keyTarget := strings.ReplaceAll(p.key, billNewSuffix, "")
+
+	options := []list.Item{}
+	if p.preProcessor != nil {
+		switch msg := p.preProcessor().(type) {
+		case errMsg:
+			return &AnswerError{Key: p.key, Reason: q.textf(msgAnswerChoices, msg.Error())}
+		case successMsg:
+			// The preprocessor handled the question on its own, like
+			// attaching the only billing account available.
+			return nil
+		case []list.Item:
+			options = msg
+		}
+	}
+
+	keys := []string{p.key}
+	reportKey := p.key
+	if strings.HasSuffix(p.key, billNewSuffix) {
+		keys = append(keys, keyTarget+"_billing_account", "billing_account")
+		reportKey = "billing_account"
+	}
+
+	val, ok := answers.get(keys...)
+	p.source = headlessSource(ok && val != "")
+	if !ok || val == "" {
+		val = p.defaultValue
+	}
+
+	if val == "" {
+		return &AnswerError{Key: reportKey, Reason: q.text(msgAnswerRequired)}
+	}
+
+	if len(options) > 0 {
+		found, create := false, false
+		if match, ok := matchOption(options, val); ok {
+			val = match
+			found = true
+		}
+
+		// An unknown project is a request to create one, the same as
+		// picking "Create New Project" in the UI, but only when the run
+		// allows it, so a typo doesn't make a new project.
+		if !found && q.Model(p.key+projNewSuffix) != nil {
+			if create, _ := q.Get(headlessCreateProjects).(bool); !create {
+				return &AnswerError{Key: reportKey, Value: val, Reason: q.text(msgAnswerUnknownProject)}
+			}
+			answers[strings.ToLower(p.key+projNewSuffix)] = val
+			val = ""
+			found, create = true, true
+		}
+
+		if !found {
+			return &AnswerError{Key: reportKey, Value: val, Reason: q.textf(msgAnswerNotChoice, listValues(options))}
+		}
+
+		if create {
+			if !p.omitFromSettings {
+				q.stack.AddSettingSource(p.key, "", p.source)
+			}
+			return nil
+		}
+	}
+	p.value = val
+
+	if !p.omitFromSettings {
+		q.stack.AddSettingSource(p.key, p.value, p.source)
+	}
+
+	if p.postProcessor == nil {
+		return nil
+	}
+
+	switch msg := p.postProcessor(p.value, q)().(type) {
+	case errMsg:
+		return &AnswerError{Key: reportKey, Value: val, Reason: msg.reason()}
+	case successMsg:
+		newValue, source := p.value, p.source
+		if msg.msg == "prependProject" {
+			currentProject := q.Get("currentProject").(string)
+			newValue = fmt.Sprintf("%s-%s", currentProject, newValue)
+			source = config.SourceComputed
+		}
+
+		if !msg.unset && !p.omitFromSettings {
+			q.stack.AddSettingSource(p.key, newValue, source)
+		}
+		if msg.unset {
+			q.claimSetting(p.key, source)
+		}
+	}
+
+	return nil
+}
+
+// headlessSource names where an answer came from, given whether it was in
+// the answers or the default was used.
+func headlessSource(given bool) string {
+	if given {
+		return config.SourceAnswers
+	}
+	return config.SourceDefault
+}
+
+// matchOption finds the value of the option the answer refers to, the same way
+// positionDefault matches a default value. true, false, yes and no are
+// interchangeable for yes or no questions.
+func matchOption(options []list.Item, answer string) (string, bool) {
+	candidates := []string{answer}
+	switch strings.ToLower(answer) {
+	case "y", "yes", "true", "t", "1":
+		candidates = append(candidates, "y", "true")
+	case "n", "no", "false", "f", "0":
+		candidates = append(candidates, "n", "false")
+	}
+
+	for _, c := range candidates {
+		for _, v := range options {
+			i, ok := v.(item)
+			if !ok {
+				continue
+			}
+			if i.value == c || i.label == c || c == i.value+"|"+i.label {
+				return i.value, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func listValues(items []list.Item) string {
+	sl := []string{}
+	for _, v := range items {
+		if i, ok := v.(item); ok && i.value != "" {
+			sl = append(sl, i.value)
+		}
+	}
+
+	if len(sl) > 10 {
+		sl = append(sl[:10], "...")
+	}
+
+	return strings.Join(sl, ", ")
+}
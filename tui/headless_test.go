@@ -0,0 +1,554 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/deploystack/config"
+	"github.com/go-test/deep"
+)
+
+func TestNewAnswers(t *testing.T) {
+	tests := map[string]struct {
+		yaml string
+		json string
+		env  []string
+		want Answers
+	}{
+		"yaml": {
+			yaml: "region: us-east1\nnodes: 3\nwebserver: true\ntags:\n- http\n- https\n",
+			want: Answers{"region": "us-east1", "nodes": "3", "webserver": "true", "tags": "[http,https]"},
+		},
+		"json": {
+			json: `{"Region": "us-east1", "nodes": 3}`,
+			want: Answers{"region": "us-east1", "nodes": "3"},
+		},
+		"json numbers": {
+			json: `{"max_bytes": 1000000, "ratio": 0.5, "sizes": [1000000, 2], "limits": {"cpu": 1000000}}`,
+			want: Answers{"max_bytes": "1000000", "ratio": "0.5", "sizes": "[1000000,2]", "limits": "cpu=1000000"},
+		},
+		"yaml numbers": {
+			yaml: "max_bytes: 1000000\nratio: 0.0000001\n",
+			want: Answers{"max_bytes": "1000000", "ratio": "0.0000001"},
+		},
+		"env override": {
+			yaml: "region: us-east1\n",
+			env:  []string{"DEPLOYSTACK_ANSWER_REGION=europe-west1", "DEPLOYSTACK_ANSWER_INSTANCE_NAME=vm", "PATH=/bin"},
+			want: Answers{"region": "europe-west1", "instance_name": "vm"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got Answers
+			var err error
+
+			if tc.json != "" {
+				got, err = NewAnswersJSON([]byte(tc.json))
+			} else {
+				got, err = NewAnswersYAML([]byte(tc.yaml))
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			got.Override(tc.env)
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("compare failed: %v", deep.Equal(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestQueueAnswer(t *testing.T) {
+	tests := map[string]struct {
+		answers Answers
+		want    map[string]string
+		err     AnswerErrors
+	}{
+		"defaults": {
+			answers: Answers{
+				"project_id": "ds-test-ms-ua2jjt3u",
+				"bucket":     "assets",
+			},
+			want: map[string]string{
+				"project_id": "ds-test-ms-ua2jjt3u",
+				"region":     "us-central1",
+				"nodes":      "3",
+				"bucket":     "ds-test-ms-ua2jjt3u-assets",
+				"location":   "US",
+				"basename":   "headless",
+			},
+		},
+		"answered": {
+			answers: Answers{
+				"project_id": "ds-test-ms-ua2jjt3u",
+				"region":     "europe-west1",
+				"nodes":      "5",
+				"bucket":     "assets",
+				"location":   "EU",
+			},
+			want: map[string]string{
+				"project_id": "ds-test-ms-ua2jjt3u",
+				"region":     "europe-west1",
+				"nodes":      "5",
+				"bucket":     "ds-test-ms-ua2jjt3u-assets",
+				"location":   "EU",
+			},
+		},
+		"invalid and missing": {
+			answers: Answers{
+				"project_id": "ds-test-ms-ua2jjt3u",
+				"region":     "mars-north1",
+				"nodes":      "five",
+				"location":   "MOON",
+			},
+			err: AnswerErrors{
+				{Key: "region", Value: "mars-north1", Reason: "is not one of the available choices: asia-east1, asia-east2, asia-northeast1, asia-northeast2, asia-northeast3, asia-south1, asia-south2, asia-southeast1, asia-southeast2, australia-southeast1, ..."},
+				{Key: "nodes", Value: "five", Reason: "Your answer 'five' not a valid integer"},
+				{Key: "bucket", Reason: "is required but no answer was provided"},
+				{Key: "location", Value: "MOON", Reason: "is not one of the available choices: US, EU, ASIA"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getTestQueue(appTitle, "test")
+			testdata := filepath.Join(testFilesDir, "tui/testdata", "config_headless.yaml")
+
+			config, err := config.NewConfigYAML([]byte(readTestFile(testdata)))
+			if err != nil {
+				t.Fatalf("could not read in config %s:", err)
+			}
+			q.stack.Config = config
+			q.InitializeUI()
+
+			err = q.answer(tc.answers)
+
+			if tc.err != nil {
+				if !reflect.DeepEqual(tc.err, err) {
+					t.Fatalf("compare failed: %v", deep.Equal(tc.err, err))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			for k, v := range tc.want {
+				if got := q.stack.GetSetting(k); got != v {
+					t.Errorf("setting %s - want '%s' got '%s'", k, v, got)
+				}
+			}
+
+			if q.stack.GetSetting("project_id"+billNewSuffix) != "" {
+				t.Errorf("billing selector setting leaked into settings")
+			}
+		})
+	}
+}
+
+func TestQueueAnswerCreateProject(t *testing.T) {
+	tests := map[string]struct {
+		create bool
+		custom bool
+		want   string
+		err    string
+	}{
+		"create":  {create: true, want: "brand-new-project"},
+		"unknown": {err: "project_id: 'brand-new-project' " + text(msgAnswerUnknownProject)},
+		"setting": {custom: true, err: "project_id: 'brand-new-project' " + text(msgAnswerUnknownProject)},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getTestQueue(appTitle, "test")
+			q.stack.Config = config.Config{Name: "test", Project: true}
+			q.Save(headlessCreateProjects, tc.create)
+
+			answers := Answers{
+				"project_id":      "brand-new-project",
+				"billing_account": "000000-000000-00000X",
+			}
+			// A setting that happens to share a name with the option
+			if tc.custom {
+				q.stack.Config.CustomSettings = config.Customs{{Name: "create_project", Description: "Create it?"}}
+				answers["create_project"] = "true"
+			}
+			q.InitializeUI()
+
+			err := q.answer(answers)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error %q, got: %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			if got := q.stack.GetSetting("project_id"); got != tc.want {
+				t.Fatalf("want '%s' got '%s'", tc.want, got)
+			}
+
+			if _, ok := answers["project_id"+projNewSuffix]; ok {
+				t.Fatalf("expected the answers given to be left alone, got: %v", answers)
+			}
+		})
+	}
+}
+
+func TestQueueAnswerCondition(t *testing.T) {
+	tests := map[string]struct {
+		answers Answers
+		want    map[string]string
+	}{
+		"asked": {
+			answers: Answers{"enable_sql": "y", "sql_tier": "db-g1-small"},
+			want:    map[string]string{"enable_sql": "y", "sql_tier": "db-g1-small", "region": "us-central1"},
+		},
+		"skipped": {
+			answers: Answers{"enable_sql": "n", "sql_tier": "db-g1-small"},
+			want:    map[string]string{"enable_sql": "n", "sql_tier": "", "region": ""},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getTestQueue(appTitle, "test")
+			q.stack.Config = config.Config{
+				Name:          "test",
+				Region:        true,
+				RegionType:    "compute",
+				RegionDefault: "us-central1",
+				RegionWhen:    "enable_sql",
+				CustomSettings: config.Customs{
+					{Name: "enable_sql", Description: "Use Cloud SQL?", Options: []string{"y", "n"}},
+					{Name: "sql_tier", Description: "Pick a tier", When: "enable_sql == y"},
+				},
+			}
+
+			// Custom settings come after region in the queue, so region is
+			// decided on an answer given by an earlier run.
+			q.stack.AddSetting("enable_sql", tc.answers["enable_sql"])
+			q.InitializeUI()
+
+			if err := q.answer(tc.answers); err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			for k, v := range tc.want {
+				if got := q.stack.GetSetting(k); got != v {
+					t.Errorf("setting %s - want '%s' got '%s'", k, v, got)
+				}
+			}
+		})
+	}
+}
+
+func TestQueueAnswerTyped(t *testing.T) {
+	tests := map[string]struct {
+		answers Answers
+		want    string
+		err     AnswerErrors
+	}{
+		"answered": {
+			answers: Answers{"nodes": "5", "public": "yes", "zones": "[a,b]", "labels": "env=prod"},
+			want:    "labels={env=\"prod\"}\nnodes=5\npublic=true\nzones=[\"a\",\"b\"]\n",
+		},
+		"defaults": {
+			answers: Answers{},
+			want:    "labels={team=\"web\"}\nnodes=3\npublic=false\nzones=[\"us-central1-a\"]\n",
+		},
+		"invalid": {
+			answers: Answers{"nodes": "three", "labels": "env"},
+			err: AnswerErrors{
+				{Key: "nodes", Value: "three", Reason: "Your answer 'three' is not a number"},
+				{Key: "labels", Value: "env", Reason: "Your answer 'env' is not a key=value pair"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getTestQueue(appTitle, "test")
+			q.stack.Config = config.Config{
+				Name: "test",
+				CustomSettings: config.Customs{
+					{Name: "nodes", Description: "Number of nodes", Type: "number", Default: "3"},
+					{Name: "public", Description: "Make it public?", Type: "bool", Default: "no"},
+					{Name: "zones", Description: "Zones", Type: "list(string)", Default: "us-central1-a"},
+					{Name: "labels", Description: "Labels", Type: "map(string)", Default: "team=web"},
+				},
+			}
+			q.InitializeUI()
+
+			err := q.answer(tc.answers)
+
+			if tc.err != nil {
+				if !reflect.DeepEqual(tc.err, err) {
+					t.Fatalf("compare failed: %v", deep.Equal(tc.err, err))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			q.stack.DeleteSetting("stack_name")
+			if got := q.stack.Terraform(); got != tc.want {
+				t.Fatalf("want \n%s\ngot \n%s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestQueueAnswerTemplatedDefault(t *testing.T) {
+	q := getTestQueue(appTitle, "test")
+	q.stack.Config = config.Config{
+		Name:          "test",
+		Region:        true,
+		RegionType:    "compute",
+		RegionDefault: "us-central1",
+		CustomSettings: config.Customs{
+			{Name: "bucket", Description: "Bucket name", Default: "{{ .stack_name }}-{{ .region }}-assets"},
+		},
+	}
+	q.InitializeUI()
+
+	if err := q.answer(Answers{"region": "europe-west1"}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	if got := q.stack.GetSetting("bucket"); got != "test-europe-west1-assets" {
+		t.Fatalf("want '%s' got '%s'", "test-europe-west1-assets", got)
+	}
+}
+
+func TestQueueAnswerProfile(t *testing.T) {
+	tests := map[string]struct {
+		answers Answers
+		region  string
+		nodes   string
+	}{
+		"prod": {
+			answers: Answers{"profile": "prod"},
+			region:  "us-east1",
+			nodes:   "5",
+		},
+		"default": {
+			answers: Answers{"region": "europe-west1"},
+			region:  "europe-west1",
+			nodes:   "1",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getTestQueue(appTitle, "test")
+			q.stack.Config = config.Config{
+				Name:           "test",
+				Region:         true,
+				RegionType:     "compute",
+				ProfileDefault: "dev",
+				CustomSettings: config.Customs{
+					{Name: "nodes", Description: "Nodes", Default: "3"},
+				},
+				Profiles: config.Profiles{
+					{Name: "dev", Defaults: map[string]string{"nodes": "1"}},
+					{
+						Name:           "prod",
+						AuthorSettings: config.Settings{{Name: "region", Value: "us-east1"}},
+						Defaults:       map[string]string{"nodes": "5"},
+					},
+				},
+			}
+			q.InitializeUI()
+
+			if err := q.answer(tc.answers); err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			for key, want := range map[string]string{"region": tc.region, "nodes": tc.nodes} {
+				if got := q.stack.GetSetting(key); got != want {
+					t.Fatalf("%s: want '%s' got '%s'", key, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestQueueAnswerOverride(t *testing.T) {
+	q := getTestQueue(appTitle, "test")
+	q.stack.Config = config.Config{
+		Name:           "test",
+		Region:         true,
+		RegionType:     "compute",
+		RegionDefault:  "us-central1",
+		AuthorSettings: config.Settings{{Name: "basename", Value: "stack", Type: "string"}},
+		CustomSettings: config.Customs{
+			{Name: "tier", Description: "Tier", Options: []string{"db-f1-micro", "db-g1-small"}, Default: "db-f1-micro"},
+			{Name: "nodes", Description: "Nodes", Default: "3"},
+		},
+	}
+
+	env := []string{
+		"DEPLOYSTACK_SETTING_TIER=db-g1-small",
+		"DEPLOYSTACK_SETTING_BASENAME=pinned",
+		"DEPLOYSTACK_SETTING_REGION=europe-west1",
+	}
+	if err := q.stack.Override(env); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	q.InitializeUI()
+
+	if q.Model("region") != nil {
+		t.Fatalf("region was set from the environment, but is still asked for")
+	}
+
+	if err := q.answer(Answers{"tier": "db-f1-micro"}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	want := map[string]string{"tier": "db-g1-small", "basename": "pinned", "region": "europe-west1", "nodes": "3"}
+	for k, v := range want {
+		if got := q.stack.GetSetting(k); got != v {
+			t.Errorf("setting %s - want '%s' got '%s'", k, v, got)
+		}
+	}
+}
+
+func TestQueueAnswerSources(t *testing.T) {
+	q := getTestQueue(appTitle, "test")
+	q.stack.Config = config.Config{
+		Name:           "test",
+		Region:         true,
+		RegionType:     "compute",
+		RegionDefault:  "us-central1",
+		AuthorSettings: config.Settings{{Name: "basename", Value: "stack", Type: "string"}},
+		CustomSettings: config.Customs{
+			{Name: "nodes", Description: "Nodes", Type: "number", Default: "3"},
+			{Name: "tier", Description: "Tier", Options: []string{"db-f1-micro", "db-g1-small"}, Default: "db-f1-micro"},
+			{Name: "bucket", Description: "Bucket", Default: "assets", PrependProject: true},
+			{Name: "zone_pin", Description: "Zone"},
+		},
+	}
+	if err := q.stack.Override([]string{"DEPLOYSTACK_SETTING_ZONE_PIN=us-central1-a"}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	q.InitializeUI()
+
+	if err := q.answer(Answers{"nodes": "5"}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	want := map[string]string{
+		"basename":   config.SourceAuthor,
+		"stack_name": config.SourceComputed,
+		"nodes":      config.SourceAnswers,
+		"tier":       config.SourceDefault,
+		"region":     config.SourceDefault,
+		"bucket":     config.SourceComputed,
+		"zone_pin":   config.SourceEnv,
+	}
+
+	for k, v := range want {
+		set := q.stack.Settings.Find(k)
+		if set == nil {
+			t.Errorf("setting %s is missing", k)
+			continue
+		}
+		if set.Source != v {
+			t.Errorf("setting %s - want source '%s' got '%s'", k, v, set.Source)
+		}
+	}
+}
+
+func TestQueueAnswerServices(t *testing.T) {
+	stack := config.NewStack()
+	q := NewQueue(&stack, mock{forceErr: true})
+	q.stack.Config = config.Config{Name: "test", Services: []string{"sqladmin.googleapis.com"}}
+	q.stack.AddSetting("project_id", "test-project")
+	q.InitializeUI()
+
+	err := q.answer(Answers{})
+
+	want := AnswerErrors{{Key: "services", Reason: fmt.Sprintf("could not enable sqladmin.googleapis.com (%s)", errForced)}}
+	if !reflect.DeepEqual(want, err) {
+		t.Fatalf("want %v got %v", want, err)
+	}
+}
+
+func TestQueueAnswerPermissions(t *testing.T) {
+	tests := map[string]struct {
+		strict bool
+	}{
+		"warning": {},
+		"strict":  {strict: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := mock{}
+			m.save("ProjectMissingIAMPermissions", []string{})
+
+			stack := config.NewStack()
+			q := NewQueue(&stack, m)
+			q.stack.Config = config.Config{Name: "test", PathTerraform: "attributes"}
+			q.stack.Config.Setwd(filepath.Join(testFilesDir, "terraform"))
+			q.stack.AddSetting("project_id", "test-project")
+			q.Save(headlessStrict, tc.strict)
+			q.InitializeUI()
+
+			p, ok := q.Model("permissions").(*page)
+			if !ok {
+				t.Fatalf("expected a permissions page, got %T", q.Model("permissions"))
+			}
+			report := p.content[1].(*permissionReport)
+			m.save("ProjectMissingIAMPermissions", report.needed[:1])
+
+			err := q.answer(Answers{})
+			warnings, _ := q.Get(headlessWarnings).([]string)
+
+			if tc.strict {
+				errs, ok := err.(AnswerErrors)
+				if !ok || len(errs) != 1 || errs[0].Key != "permissions" {
+					t.Fatalf("expected a permissions error, got: %v", err)
+				}
+				if len(warnings) != 0 {
+					t.Fatalf("expected no warnings, got: %v", warnings)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+			if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "permissions: missing "+report.needed[0]) {
+				t.Fatalf("expected a permissions warning, got: %v", warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// listInput collects a list, or a map as key=value rows, one row at a time.
+type listInput struct {
+	dynamicPage
+
+	label    string
+	kind     string
+	rows     []string
+	defaults string
+	ti       textinput.Model
+}
+
+func newListInput(label, defaultValue, key, kind string) listInput {
+	l := listInput{}
+	l.key = key
+	l.label = label
+	l.kind = kind
+	l.defaults = defaultValue
+	l.rows = []string{}
+
+	l.state = "idle"
+	l.spinnerLabel = "validating"
+
+	ti := textinput.New()
+	ti.Focus()
+	ti.CharLimit = 156
+	ti.Width = hardWidthLimit
+	ti.Placeholder = "value"
+	if kind == "map" {
+		ti.Placeholder = "key=value"
+	}
+	l.ti = ti
+
+	s := spinner.New()
+	s.Spinner = spinnerType
+	l.spinner = s
+	l.showProgress = true
+
+	return l
+}
+
+func (p listInput) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, p.spinner.Tick)
+}
+
+func (p listInput) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	// if the intended key for this setting is already set, skip
+	if p.queue.stack.GetSetting(p.key) != "" {
+		return p.queue.next()
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch keypress := msg.String(); keypress {
+		case "ctrl+c":
+			return p.queue.exitPage()
+		case "alt+b", "ctrl+b":
+			return p.queue.prev()
+		case "backspace":
+			if p.ti.Value() == "" && len(p.rows) > 0 {
+				p.rows = p.rows[:len(p.rows)-1]
+				p.err = nil
+				return p, nil
+			}
+		case "enter":
+			row := strings.TrimSpace(p.ti.Value())
+
+			if row != "" {
+				if p.kind == "map" && !strings.Contains(row, "=") {
+					p.err = fmt.Errorf("'%s' should be in the format key=value", row)
+					return p, nil
+				}
+
+				p.rows = append(p.rows, row)
+				p.ti.SetValue("")
+				p.err = nil
+				return p, nil
+			}
+
+			val := strings.Join(p.rows, ",")
+			if val == "" {
+				val = p.defaults
+			}
+			p.source = answerSource(len(p.rows) > 0)
+
+			if val == "" {
+				p.err = errors.New(p.queue.text(msgListRequired))
+				return p, nil
+			}
+			p.value = val
+
+			if p.postProcessor != nil {
+				if p.state != "querying" {
+					p.state = "querying"
+					p.err = nil
+					return p, p.postProcessor(p.value, p.queue)
+				}
+
+				return p, nil
+			}
+
+			if !p.omitFromSettings {
+				p.queue.stack.AddSettingSource(p.key, p.value, p.source)
+			}
+			return p.queue.next()
+		}
+
+	case errMsg:
+		p.err = msg
+		p.state = "idle"
+
+		if msg.quit {
+			return p, tea.Quit
+		}
+
+		var cmdSpin tea.Cmd
+		p.spinner, cmdSpin = p.spinner.Update(msg)
+		return p, cmdSpin
+	case successMsg:
+		if !msg.unset && !p.omitFromSettings {
+			p.queue.stack.AddSettingSource(p.key, p.value, p.source)
+		}
+		if msg.unset {
+			p.queue.claimSetting(p.key, p.source)
+		}
+		return p.queue.next()
+	}
+
+	var cmdSpin tea.Cmd
+	p.spinner, cmdSpin = p.spinner.Update(msg)
+	p.ti, cmd = p.ti.Update(msg)
+	return p, tea.Batch(cmd, cmdSpin)
+}
+
+func (p listInput) View() string {
+	if p.preViewFunc != nil {
+		p.preViewFunc(p.queue)
+	}
+
+	doc := strings.Builder{}
+	doc.WriteString(p.queue.header.render())
+
+	if p.showProgress {
+		doc.WriteString(drawProgress(p.queue.texts, p.queue.calcPercent()))
+		doc.WriteString("\n\n")
+	}
+
+	doc.WriteString(bodyStyle.Render(titleStyle.Render(fmt.Sprintf("%s: ", p.label))))
+	doc.WriteString("\n")
+
+	for _, v := range p.content {
+		doc.WriteString(bodyStyle.Render(v.render()))
+		doc.WriteString("\n")
+	}
+
+	for i, v := range p.rows {
+		doc.WriteString(bodyStyle.Render(fmt.Sprintf("%d. %s", i+1, strong.Render(v))))
+		doc.WriteString("\n")
+	}
+
+	doc.WriteString("\n")
+	doc.WriteString(inputText.Render(p.ti.View()))
+	doc.WriteString("\n")
+
+	if p.err != nil {
+		height := len(p.err.Error()) / width
+		doc.WriteString("\n")
+		doc.WriteString(alertStyle.Width(width).Height(height).Render(p.queue.textf(msgInputError, p.err)))
+		doc.WriteString("\n")
+	}
+
+	if p.state == "querying" && p.err == nil {
+		spinnerSB := strings.Builder{}
+		spinnerSB.WriteString(textStyle.Render(fmt.Sprintf("%s ", p.spinnerLabel)))
+		spinnerSB.WriteString(spinnerStyle.Render(p.spinner.View()))
+		doc.WriteString(bodyStyle.Render(spinnerSB.String()))
+		doc.WriteString("\n")
+	}
+
+	if p.state != "querying" {
+		entry := p.queue.text(msgListValue)
+		if p.kind == "map" {
+			entry = p.queue.text(msgListPair)
+		}
+
+		prompt := p.queue.textf(msgListPrompt, entry)
+		if len(p.rows) == 0 && p.defaults != "" {
+			styledDefault := textInputDefaultStyle.Render(p.defaults)
+			prompt = p.queue.textf(msgListPromptDefault, entry, styledDefault)
+		}
+
+		doc.WriteString(textInputPrompt.Render(prompt))
+
+		if len(p.rows) > 0 {
+			doc.WriteString("\n")
+			doc.WriteString(textInputPrompt.Render(p.queue.text(msgListRemove)))
+		}
+	}
+
+	return docStyle.Render(doc.String())
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/deploystack/config"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListInput(t *testing.T) {
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	backspace := tea.KeyMsg{Type: tea.KeyBackspace}
+
+	tests := map[string]struct {
+		kind     string
+		defaults string
+		typed    []string
+		keys     []tea.KeyMsg
+		rows     []string
+		value    string
+		err      string
+	}{
+		"list": {
+			kind:  "list",
+			typed: []string{"a", "b"},
+			keys:  []tea.KeyMsg{enter},
+			rows:  []string{"a", "b"},
+			value: "a,b",
+		},
+		"remove": {
+			kind:  "list",
+			typed: []string{"a", "b"},
+			keys:  []tea.KeyMsg{backspace, enter},
+			rows:  []string{"a"},
+			value: "a",
+		},
+		"defaults": {
+			kind:     "list",
+			defaults: "x,y",
+			keys:     []tea.KeyMsg{enter},
+			rows:     []string{},
+			value:    "x,y",
+		},
+		"empty": {
+			kind:  "list",
+			keys:  []tea.KeyMsg{enter},
+			rows:  []string{},
+			value: "",
+			err:   "You must enter at least one value",
+		},
+		"map": {
+			kind:  "map",
+			typed: []string{"env=prod", "team=web"},
+			keys:  []tea.KeyMsg{enter},
+			rows:  []string{"env=prod", "team=web"},
+			value: "env=prod,team=web",
+		},
+		"map bad row": {
+			kind:  "map",
+			typed: []string{"env=prod", "team"},
+			rows:  []string{"env=prod"},
+			value: "",
+			err:   "'team' should be in the format key=value",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getTestQueue(appTitle, "test")
+			l := newListInput("test", tc.defaults, "test", tc.kind)
+			q.add(&l)
+
+			var m tea.Model = l
+
+			for _, v := range tc.typed {
+				li := m.(listInput)
+				li.ti.SetValue(v)
+				m, _ = li.Update(enter)
+			}
+
+			for _, v := range tc.keys {
+				m, _ = m.Update(v)
+			}
+
+			// Finishing the list moves the queue on, so look at what was
+			// stored instead
+			got, ok := m.(listInput)
+			if !ok {
+				assert.Equal(t, tc.value, q.stack.GetSetting("test"))
+				return
+			}
+
+			assert.Equal(t, tc.rows, got.rows)
+			assert.Equal(t, tc.value, got.value)
+
+			if tc.err != "" {
+				assert.EqualError(t, got.err, tc.err)
+			}
+		})
+	}
+}
+
+func TestListInputTyped(t *testing.T) {
+	tests := map[string]struct {
+		custom config.Custom
+		value  string
+		want   string
+	}{
+		"list": {
+			custom: config.Custom{Name: "zones", Type: "list(string)"},
+			value:  "us-central1-a,us-central1-b",
+			want:   "zones=[\"us-central1-a\",\"us-central1-b\"]\n",
+		},
+		"map": {
+			custom: config.Custom{Name: "labels", Type: "map(string)"},
+			value:  "env=prod,team=web",
+			want:   "labels={env=\"prod\",team=\"web\"}\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getTestQueue(appTitle, "test")
+			l := newCustom(&q, tc.custom)
+			q.add(l)
+
+			li := l.(*listInput)
+			li.value = tc.value
+
+			msg := li.postProcessor(li.value, &q)()
+			m, _ := li.Update(msg)
+
+			assert.NotEqual(t, "test", m.(QueueModel).getKey())
+			assert.Equal(t, tc.want, q.stack.Terraform())
+		})
+	}
+}
@@ -0,0 +1,518 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/deploystack/config"
+)
+
+// Ids of the messages in the catalog. They are also the keys a stack uses to
+// replace them in messages/<locale>/catalog.yaml.
+const (
+	msgExplain              = "explain"
+	msgFatal                = "fatal"
+	msgProjectSettings      = "project_settings"
+	msgContinue             = "continue"
+	msgDomainPrompt         = "domain_prompt"
+	msgDomainChecking       = "domain_checking"
+	msgDomainEmail          = "domain_email"
+	msgDomainPhone          = "domain_phone"
+	msgDomainCountry        = "domain_country"
+	msgDomainPostalCode     = "domain_postalcode"
+	msgDomainState          = "domain_state"
+	msgDomainCity           = "domain_city"
+	msgDomainAddress        = "domain_address"
+	msgDomainName           = "domain_name"
+	msgDomainCost           = "domain_cost"
+	msgDomainConsent        = "domain_consent"
+	msgDomainRegistering    = "domain_registering"
+	msgGCETitle             = "gce_title"
+	msgGCEIntro             = "gce_intro"
+	msgGCEDefaults          = "gce_defaults"
+	msgGCEName              = "gce_name"
+	msgGCEDiskSize          = "gce_disksize"
+	msgGCEDiskType          = "gce_disktype"
+	msgGCEWebserver         = "gce_webserver"
+	msgGCEMachineFamily     = "gce_machine_family"
+	msgGCEMachineFamilies   = "gce_machine_families_retrieving"
+	msgGCEMachineType       = "gce_machine_type"
+	msgGCEMachineTypes      = "gce_machine_types_retrieving"
+	msgGCEMachineTypesInfo  = "gce_machine_types_info"
+	msgGCEImageProject      = "gce_image_project"
+	msgGCEImageProjects     = "gce_image_projects_retrieving"
+	msgGCEImageFamily       = "gce_image_family"
+	msgGCEImageFamilies     = "gce_image_families_retrieving"
+	msgGCEImage             = "gce_image"
+	msgGCEImages            = "gce_images_retrieving"
+	msgGCEImagesInfo        = "gce_images_info"
+	msgRegion               = "region"
+	msgRegions              = "regions_retrieving"
+	msgZone                 = "zone"
+	msgZones                = "zones_retrieving"
+	msgServicesTitle        = "services_title"
+	msgServicesEnabling     = "services_enabling"
+	msgServicesChecked      = "services_checked"
+	msgServicesFailed       = "services_failed"
+	msgInstallComplete      = "install_complete"
+	msgOutputsTitle         = "outputs_title"
+	msgCostTitle            = "cost_title"
+	msgCostTotal            = "cost_total"
+	msgCostUnpriced         = "cost_unpriced"
+	msgCostDisclaimer       = "cost_disclaimer"
+	msgPermissionsTitle     = "permissions_title"
+	msgPermissionsChecking  = "permissions_checking"
+	msgPermissionsGranted   = "permissions_granted"
+	msgPermissionsMissing   = "permissions_missing"
+	msgPermissionsProject   = "permissions_project"
+	msgPermissionsRoles     = "permissions_roles"
+	msgPermissionsFailed    = "permissions_failed"
+	msgPermissionsUnknown   = "permissions_unknown"
+	msgResourcesIntro       = "resources_intro"
+	msgDuration             = "duration"
+	msgDurationOne          = "duration_one"
+	msgDocumentation        = "documentation"
+	msgErrorTitle           = "error_title"
+	msgErrorDetails         = "error_details"
+	msgErrorExit            = "error_exit"
+	msgErrorBack            = "error_back"
+	msgErrorQuit            = "error_quit"
+	msgPressContinue        = "press_continue"
+	msgProgress             = "progress"
+	msgStackName            = "stack_name"
+	msgProjectName          = "project_name"
+	msgProjectID            = "project_id"
+	msgProjectNumber        = "project_number"
+	msgColumnSetting        = "column_setting"
+	msgColumnValue          = "column_value"
+	msgColumnSource         = "column_source"
+	msgColumnResource       = "column_resource"
+	msgColumnDetail         = "column_detail"
+	msgColumnMonthly        = "column_monthly"
+	msgDefaultValue         = "default_value"
+	msgInputError           = "input_error"
+	msgInputRequired        = "input_required"
+	msgInputPrompt          = "input_prompt"
+	msgInputPromptDefault   = "input_prompt_default"
+	msgListRequired         = "list_required"
+	msgListValue            = "list_value"
+	msgListPair             = "list_pair"
+	msgListPrompt           = "list_prompt"
+	msgListPromptDefault    = "list_prompt_default"
+	msgListRemove           = "list_remove"
+	msgAnswerInvalid        = "answer_invalid"
+	msgAnswerInteger        = "answer_integer"
+	msgAnswerYesNo          = "answer_yesno"
+	msgAnswerPhone          = "answer_phone"
+	msgAnswerRequired       = "answer_required"
+	msgAnswerChoices        = "answer_choices"
+	msgAnswerNotChoice      = "answer_not_choice"
+	msgAnswersFailed        = "answers_failed"
+	msgAnswerUnknownProject = "answer_unknown_project"
+	msgRegionsSlow          = "regions_slow"
+	msgZonesSlow            = "zones_slow"
+	msgDomainVerifyFailed   = "domain_verify_failed"
+	msgDomainNotOwned       = "domain_not_owned"
+	msgDomainRegisterFailed = "domain_register_failed"
+	msgDiskStandard         = "disk_standard"
+	msgDiskBalanced         = "disk_balanced"
+	msgDiskSSD              = "disk_ssd"
+	msgYes                  = "yes"
+	msgNo                   = "no"
+	msgPrefillResume        = "prefill_resume"
+	msgPrefillReview        = "prefill_review"
+	msgPrefillFound         = "prefill_found"
+	msgPrefillReplaced      = "prefill_replaced"
+	msgPrefillNotesTitle    = "prefill_notes_title"
+	msgPrefillNotes         = "prefill_notes"
+	msgExitStopped          = "exit_stopped"
+	msgExitRetry            = "exit_retry"
+	msgProjectChoose        = "project_choose"
+	msgProjectCreate        = "project_create"
+	msgProjectCreating      = "project_creating"
+	msgProjectIDRules       = "project_id_rules"
+	msgProjectNamePrompt    = "project_name_prompt"
+	msgProjectsRetrieving   = "projects_retrieving"
+	msgProfileChoose        = "profile_choose"
+	msgBillingChoose        = "billing_choose"
+	msgBillingNewProject    = "billing_new_project"
+	msgBillingDisabled      = "billing_disabled"
+	msgBillingRetrieving    = "billing_retrieving"
+	msgValidating           = "validating"
+	msgValidatingPhone      = "validating_phone"
+	msgValidatingYesNo      = "validating_yesno"
+	msgValidatingInteger    = "validating_integer"
+	msgSettingsProceed      = "settings_proceed"
+	msgStacksDetected       = "stacks_detected"
+	msgStackPick            = "stack_pick"
+	msgStacksFinding        = "stacks_finding"
+	msgStackChosen          = "stack_chosen"
+	msgStackProceed         = "stack_proceed"
+)
+
+// catalog holds the text for one locale, keyed by message id
+type catalog map[string]string
+
+const baseLocale = "en"
+
+// catalogs are the built in translations, keyed by locale. English is
+// complete, and fills in for anything another locale is missing.
+var catalogs = map[string]catalog{
+	baseLocale: {
+		msgExplain: explainText,
+		msgFatal: `There was an issue collecting the information it takes to run this application.
+		You can try again by typing 'deploystack install' at the command prompt 
+		If the issue persists, please report at: 
+		https://github.com/GoogleCloudPlatform/deploystack/issues
+		`,
+		msgProjectSettings:   "Project Settings",
+		msgContinue:          "Continue?",
+		msgDomainPrompt:      "Enter a domain you wish to purchase and use for this application",
+		msgDomainChecking:    "Checking Domain Availability",
+		msgDomainEmail:       "Enter an email address",
+		msgDomainPhone:       "Enter a phone number. (Please enter with country code - +1 555 555 5555 for US for example)",
+		msgDomainCountry:     "Enter a country code",
+		msgDomainPostalCode:  "Enter a postal code",
+		msgDomainState:       "Enter a state or administrative area",
+		msgDomainCity:        "Enter a city",
+		msgDomainAddress:     "Enter an address",
+		msgDomainName:        "Enter name",
+		msgDomainCost:        "Cost for %s will be %s.  %s",
+		msgDomainConsent:     "Buying a domain is not reversible, saying 'y' will incur a charge.",
+		msgDomainRegistering: "Attempting to register domain",
+		msgGCETitle:          "Configure a Compute Engine Instance",
+		msgGCEIntro: `Let's walk through configuring a Compute Engine Instance (Virtual Machine).
+you can either accept a default configuration with settings that work for
+trying out most use cases, or hand configure key settings.
+	`,
+		msgGCEDefaults:        "Do you want to accept the default configuration? (Yes or No)",
+		msgGCEName:            "Enter the name of the instance",
+		msgGCEDiskSize:        "Enter the size of the boot disk you want in GB",
+		msgGCEDiskType:        "Pick the type of the boot disk you want",
+		msgGCEWebserver:       "Do you want this to be a webserver (Expose ports 80 & 443)?",
+		msgGCEMachineFamily:   "Pick a Machine Type Family",
+		msgGCEMachineFamilies: "Retrieving machine type families",
+		msgGCEMachineType:     "Pick a Machine Type",
+		msgGCEMachineTypes:    "Retrieving machine types",
+		msgGCEMachineTypesInfo: "There are a large number of machine types to choose from. For more information \n" +
+			"please refer to the following link for more information about Machine types: \n",
+		msgGCEImageProject:  "Pick an operating system",
+		msgGCEImageProjects: "Retrieving operating systems",
+		msgGCEImageFamily:   "Pick a disk family",
+		msgGCEImageFamilies: "Retrieving disk family",
+		msgGCEImage:         "Pick a disk image",
+		msgGCEImages:        "Retrieving disk image",
+		msgGCEImagesInfo: "There are a large number of machine images to choose from. For more information \n" +
+			"please refer to the following link for more information about Machine images: \n",
+		msgRegion:           "Pick a region",
+		msgRegions:          "Retrieving regions",
+		msgZone:             "Pick a zone",
+		msgZones:            "Retrieving zones",
+		msgServicesTitle:    "Enabling the APIs this application needs",
+		msgServicesEnabling: "Enabling APIs",
+		msgServicesChecked:  "%d of %d checked",
+		msgServicesFailed: "Some APIs could not be enabled, so installing may fail. " +
+			"Press the Enter Key to continue anyway.",
+		msgInstallComplete: "Installation is complete",
+		msgOutputsTitle:    "Here is what was set up:",
+		msgCostTitle:       "Estimated monthly cost",
+		msgCostTotal:       "About %s a month",
+		msgCostUnpriced:    "Not included, because there is no price for them: %s",
+		msgCostDisclaimer: "This is a rough estimate from list prices in USD, for resources running all month, " +
+			"before taxes, discounts and free tiers. What you pay depends on how much you use. " +
+			"For a quote, use the pricing calculator at ",
+		msgPermissionsTitle:    "Checking your permissions on the project",
+		msgPermissionsChecking: "Checking permissions",
+		msgPermissionsGranted:  "You have all %d of the permissions this application needs",
+		msgPermissionsMissing:  "You are missing %d of the %d permissions this application needs:",
+		msgPermissionsProject:  "On %s:",
+		msgPermissionsRoles:    "granted by %s",
+		msgPermissionsFailed: "You may not be allowed to create everything this application needs, so installing may fail. " +
+			"Ask someone who administers the project to grant you one of the roles listed, or press the Enter Key to continue anyway.",
+		msgPermissionsUnknown: "Your permissions on the project could not be checked, so installing may fail. " +
+			"Press the Enter Key to continue anyway.",
+		msgResourcesIntro:       "This process will install the following resources:",
+		msgDuration:             "It's going to take around %s minutes.",
+		msgDurationOne:          "It's going to take around %s minute.",
+		msgDocumentation:        "If you would like more information about this stack, please read the documentation at: ",
+		msgErrorTitle:           "There was an error!",
+		msgErrorDetails:         "Details: ",
+		msgErrorExit:            "You can exit the program by typing ",
+		msgErrorBack:            "Press the Enter Key to go back and change choice",
+		msgErrorQuit:            "Press the Enter Key exit",
+		msgPressContinue:        "Press the Enter Key to continue",
+		msgProgress:             "Progress",
+		msgStackName:            "Stack Name",
+		msgProjectName:          "Project Name",
+		msgProjectID:            "Project ID",
+		msgProjectNumber:        "Project Number",
+		msgColumnSetting:        "Setting",
+		msgColumnValue:          "Value",
+		msgColumnSource:         "Source",
+		msgColumnResource:       "Resource",
+		msgColumnDetail:         "Detail",
+		msgColumnMonthly:        "Monthly",
+		msgDefaultValue:         "(Default Value)",
+		msgInputError:           "Error: %s",
+		msgInputRequired:        "You must enter a value",
+		msgInputPrompt:          "Type a value and hit enter to continue",
+		msgInputPromptDefault:   "Type a value or hit enter for '%s'",
+		msgListRequired:         "You must enter at least one value",
+		msgListValue:            "a value",
+		msgListPair:             "a key=value pair",
+		msgListPrompt:           "Type %s and hit enter to add it. Hit enter on an empty line when you are done",
+		msgListPromptDefault:    "Type %s and hit enter to add it, or hit enter for '%s'",
+		msgListRemove:           "Hit backspace on an empty line to remove the last one",
+		msgAnswerInvalid:        "Your answer %s",
+		msgAnswerInteger:        "Your answer '%s' not a valid integer",
+		msgAnswerYesNo:          "Your answer '%s' is neither 'yes' nor 'no'",
+		msgAnswerPhone:          "Your answer '%s' is not a valid phone number. Please try again",
+		msgAnswerRequired:       "is required but no answer was provided",
+		msgAnswerChoices:        "could not retrieve choices: %s",
+		msgAnswerNotChoice:      "is not one of the available choices: %s",
+		msgAnswersFailed:        "could not run stack with the answers provided:",
+		msgAnswerUnknownProject: "is an unknown project, allow creating projects (-create-projects) to create it",
+		msgRegionsSlow:          "Getting regions can take a little extra time if this is a new project",
+		msgZonesSlow:            "Getting zones can take a little extra time if this is a new project",
+		msgDomainVerifyFailed:   "Trying to validate that you own this domain failed due to an error",
+		msgDomainNotOwned:       "Domain is owned by someone other than the requestor",
+		msgDomainRegisterFailed: "There was a problem registering the domain.",
+		msgDiskStandard:         "Standard",
+		msgDiskBalanced:         "Balanced",
+		msgDiskSSD:              "SSD",
+		msgYes:                  "Yes",
+		msgNo:                   "No",
+		msgPrefillResume:        "Use them, and only ask what is new",
+		msgPrefillReview:        "Review every answer, starting from them",
+		msgPrefillFound:         "Answers from the last run were found in %s",
+		msgPrefillReplaced:      "%s, it will be replaced",
+		msgPrefillNotesTitle:    "Answers from the last run",
+		msgPrefillNotes:         "These answers from the last run can't be used, and will be asked for again:",
+		msgExitStopped:          "You've chosen to stop moving forward through DeployStack. ",
+		msgExitRetry:            "If this was an error, you can try again by typing 'deploystack install' at the command prompt ",
+		msgProjectChoose:        "Choose a project to use for this application.",
+		msgProjectCreate:        "Create New Project",
+		msgProjectCreating:      "Checking if project can be created",
+		msgProjectIDRules:       "Project IDs are immutable and can be set only during project creation. They must start with a lowercase letter and can have lowercase ASCII letters, digits or hyphens. Project IDs must be between 6 and 30 characters. ",
+		msgProjectNamePrompt:    "Please enter a new project name to create:",
+		msgProjectsRetrieving:   "Retrieving Projects",
+		msgProfileChoose:        "Choose the environment profile to install",
+		msgBillingChoose:        "Choose a billing account to use for with this application",
+		msgBillingNewProject:    "Choose an account to use to enable billing on the new project",
+		msgBillingDisabled:      "%s (Billing Disabled)",
+		msgBillingRetrieving:    "Retrieving Billing Accounts",
+		msgValidating:           "validating",
+		msgValidatingPhone:      "Validating phone number",
+		msgValidatingYesNo:      "Validating yes or no",
+		msgValidatingInteger:    "Validating integer",
+		msgSettingsProceed:      "Installation will proceed with these settings",
+		msgStacksDetected:       "Multiple Stacks Detected",
+		msgStackPick:            "Please pick a stack to use",
+		msgStacksFinding:        "Finding stacks",
+		msgStackChosen:          "Stack has been chosen",
+		msgStackProceed:         "Installation will proceed with this stack:",
+	},
+	"es": {
+		msgExplain: "DeployStack le guiará para elegir algunas opciones de la pila que instala esta solución. La mayoría de las preguntas tienen un valor predeterminado que puede elegir pulsando la tecla Intro.",
+		msgFatal: `Se ha producido un problema al recopilar la información necesaria para ejecutar esta aplicación.
+		Puede volver a intentarlo escribiendo 'deploystack install' en la línea de comandos 
+		Si el problema continúa, notifíquelo en: 
+		https://github.com/GoogleCloudPlatform/deploystack/issues
+		`,
+		msgProjectSettings:   "Configuración del proyecto",
+		msgContinue:          "¿Continuar?",
+		msgDomainPrompt:      "Introduzca un dominio que quiera comprar y usar para esta aplicación",
+		msgDomainChecking:    "Comprobando la disponibilidad del dominio",
+		msgDomainEmail:       "Introduzca una dirección de correo electrónico",
+		msgDomainPhone:       "Introduzca un número de teléfono. (Con el código de país, por ejemplo +34 555 555 555 para España)",
+		msgDomainCountry:     "Introduzca un código de país",
+		msgDomainPostalCode:  "Introduzca un código postal",
+		msgDomainState:       "Introduzca una provincia o región administrativa",
+		msgDomainCity:        "Introduzca una ciudad",
+		msgDomainAddress:     "Introduzca una dirección",
+		msgDomainName:        "Introduzca un nombre",
+		msgDomainCost:        "El coste de %s será de %s.  %s",
+		msgDomainConsent:     "La compra de un dominio no se puede deshacer; responder 'y' supondrá un cargo.",
+		msgDomainRegistering: "Intentando registrar el dominio",
+		msgGCETitle:          "Configurar una instancia de Compute Engine",
+		msgGCEIntro: `Vamos a configurar una instancia de Compute Engine (máquina virtual).
+Puede aceptar una configuración predeterminada con ajustes que sirven para
+probar la mayoría de los casos de uso, o configurar a mano los ajustes clave.
+	`,
+		msgGCEDefaults:        "¿Quiere aceptar la configuración predeterminada? (Sí o No)",
+		msgGCEName:            "Introduzca el nombre de la instancia",
+		msgGCEDiskSize:        "Introduzca el tamaño en GB del disco de arranque",
+		msgGCEDiskType:        "Elija el tipo de disco de arranque",
+		msgGCEWebserver:       "¿Quiere que sea un servidor web (exponer los puertos 80 y 443)?",
+		msgGCEMachineFamily:   "Elija una familia de tipos de máquina",
+		msgGCEMachineFamilies: "Obteniendo las familias de tipos de máquina",
+		msgGCEMachineType:     "Elija un tipo de máquina",
+		msgGCEMachineTypes:    "Obteniendo los tipos de máquina",
+		msgGCEMachineTypesInfo: "Hay muchos tipos de máquina entre los que elegir. Para obtener más información \n" +
+			"sobre los tipos de máquina, consulte el siguiente enlace: \n",
+		msgGCEImageProject:  "Elija un sistema operativo",
+		msgGCEImageProjects: "Obteniendo los sistemas operativos",
+		msgGCEImageFamily:   "Elija una familia de discos",
+		msgGCEImageFamilies: "Obteniendo las familias de discos",
+		msgGCEImage:         "Elija una imagen de disco",
+		msgGCEImages:        "Obteniendo las imágenes de disco",
+		msgGCEImagesInfo: "Hay muchas imágenes de máquina entre las que elegir. Para obtener más información \n" +
+			"sobre las imágenes de máquina, consulte el siguiente enlace: \n",
+		msgRegion:           "Elija una región",
+		msgRegions:          "Obteniendo las regiones",
+		msgZone:             "Elija una zona",
+		msgZones:            "Obteniendo las zonas",
+		msgServicesTitle:    "Habilitando las API que necesita esta aplicación",
+		msgServicesEnabling: "Habilitando las API",
+		msgServicesChecked:  "%d de %d comprobadas",
+		msgServicesFailed: "No se han podido habilitar algunas API, por lo que la instalación puede fallar. " +
+			"Pulse la tecla Intro para continuar de todos modos.",
+		msgInstallComplete: "La instalación ha terminado",
+		msgOutputsTitle:    "Esto es lo que se ha configurado:",
+		msgCostTitle:       "Coste mensual estimado",
+		msgCostTotal:       "Unos %s al mes",
+		msgCostUnpriced:    "No incluidos, porque no tienen precio: %s",
+		msgCostDisclaimer: "Es una estimación aproximada a partir de los precios de lista en USD, para recursos que funcionan todo el mes, " +
+			"sin impuestos, descuentos ni niveles gratuitos. Lo que pague dependerá de cuánto los use. " +
+			"Para obtener un presupuesto, use la calculadora de precios en ",
+		msgPermissionsTitle:    "Comprobando sus permisos en el proyecto",
+		msgPermissionsChecking: "Comprobando los permisos",
+		msgPermissionsGranted:  "Tiene los %d permisos que necesita esta aplicación",
+		msgPermissionsMissing:  "Le faltan %d de los %d permisos que necesita esta aplicación:",
+		msgPermissionsProject:  "En %s:",
+		msgPermissionsRoles:    "concedido por %s",
+		msgPermissionsFailed: "Es posible que no pueda crear todo lo que necesita esta aplicación, por lo que la instalación puede fallar. " +
+			"Pida a un administrador del proyecto que le conceda uno de los roles indicados, o pulse la tecla Intro para continuar de todos modos.",
+		msgPermissionsUnknown: "No se han podido comprobar sus permisos en el proyecto, por lo que la instalación puede fallar. " +
+			"Pulse la tecla Intro para continuar de todos modos.",
+		msgResourcesIntro:       "Este proceso instalará los siguientes recursos:",
+		msgDuration:             "Tardará unos %s minutos.",
+		msgDurationOne:          "Tardará unos %s minuto.",
+		msgDocumentation:        "Si quiere más información sobre esta pila, lea la documentación en: ",
+		msgErrorTitle:           "¡Se ha producido un error!",
+		msgErrorDetails:         "Detalles: ",
+		msgErrorExit:            "Puede salir del programa escribiendo ",
+		msgErrorBack:            "Pulse la tecla Intro para volver y cambiar su elección",
+		msgErrorQuit:            "Pulse la tecla Intro para salir",
+		msgPressContinue:        "Pulse la tecla Intro para continuar",
+		msgProgress:             "Progreso",
+		msgStackName:            "Nombre de la pila",
+		msgProjectName:          "Nombre del proyecto",
+		msgProjectID:            "ID del proyecto",
+		msgProjectNumber:        "Número del proyecto",
+		msgColumnSetting:        "Ajuste",
+		msgColumnValue:          "Valor",
+		msgColumnSource:         "Origen",
+		msgColumnResource:       "Recurso",
+		msgColumnDetail:         "Detalle",
+		msgColumnMonthly:        "Mensual",
+		msgDefaultValue:         "(Valor predeterminado)",
+		msgInputError:           "Error: %s",
+		msgInputRequired:        "Debe introducir un valor",
+		msgInputPrompt:          "Escriba un valor y pulse Intro para continuar",
+		msgInputPromptDefault:   "Escriba un valor o pulse Intro para usar '%s'",
+		msgListRequired:         "Debe introducir al menos un valor",
+		msgListValue:            "un valor",
+		msgListPair:             "un par clave=valor",
+		msgListPrompt:           "Escriba %s y pulse Intro para añadirlo. Pulse Intro en una línea vacía cuando termine",
+		msgListPromptDefault:    "Escriba %s y pulse Intro para añadirlo, o pulse Intro para usar '%s'",
+		msgListRemove:           "Pulse Retroceso en una línea vacía para quitar el último",
+		msgAnswerInvalid:        "Su respuesta %s",
+		msgAnswerInteger:        "Su respuesta '%s' no es un número entero válido",
+		msgAnswerYesNo:          "Su respuesta '%s' no es ni 'yes' ni 'no'",
+		msgAnswerPhone:          "Su respuesta '%s' no es un número de teléfono válido. Inténtelo de nuevo",
+		msgAnswerRequired:       "es obligatorio, pero no se ha dado ninguna respuesta",
+		msgAnswerChoices:        "no se han podido obtener las opciones: %s",
+		msgAnswerNotChoice:      "no es ninguna de las opciones disponibles: %s",
+		msgAnswersFailed:        "no se ha podido ejecutar la pila con las respuestas dadas:",
+		msgAnswerUnknownProject: "es un proyecto desconocido; permita crear proyectos (-create-projects) para crearlo",
+		msgRegionsSlow:          "Obtener las regiones puede tardar un poco más si el proyecto es nuevo",
+		msgZonesSlow:            "Obtener las zonas puede tardar un poco más si el proyecto es nuevo",
+		msgDomainVerifyFailed:   "Se ha producido un error al comprobar que es el propietario de este dominio",
+		msgDomainNotOwned:       "El dominio pertenece a otra persona",
+		msgDomainRegisterFailed: "Se ha producido un problema al registrar el dominio.",
+		msgDiskStandard:         "Estándar",
+		msgDiskBalanced:         "Equilibrado",
+		msgDiskSSD:              "SSD",
+		msgYes:                  "Sí",
+		msgNo:                   "No",
+		msgPrefillResume:        "Usarlas y preguntar solo lo nuevo",
+		msgPrefillReview:        "Revisar todas las respuestas, partiendo de ellas",
+		msgPrefillFound:         "Se han encontrado respuestas de la última ejecución en %s",
+		msgPrefillReplaced:      "%s; se sustituirá",
+		msgPrefillNotesTitle:    "Respuestas de la última ejecución",
+		msgPrefillNotes:         "Estas respuestas de la última ejecución no se pueden usar y se volverán a preguntar:",
+		msgExitStopped:          "Ha decidido no seguir adelante con DeployStack. ",
+		msgExitRetry:            "Si ha sido un error, puede volver a intentarlo escribiendo 'deploystack install' en la línea de comandos ",
+		msgProjectChoose:        "Elija un proyecto para esta aplicación.",
+		msgProjectCreate:        "Crear un proyecto nuevo",
+		msgProjectCreating:      "Comprobando si se puede crear el proyecto",
+		msgProjectIDRules:       "Los ID de proyecto no se pueden cambiar y solo se pueden establecer al crear el proyecto. Deben empezar por una letra minúscula y pueden contener letras ASCII minúsculas, dígitos o guiones. Deben tener entre 6 y 30 caracteres. ",
+		msgProjectNamePrompt:    "Introduzca el nombre del proyecto nuevo que quiere crear:",
+		msgProjectsRetrieving:   "Obteniendo los proyectos",
+		msgProfileChoose:        "Elija el perfil de entorno que quiere instalar",
+		msgBillingChoose:        "Elija una cuenta de facturación para esta aplicación",
+		msgBillingNewProject:    "Elija una cuenta para habilitar la facturación en el proyecto nuevo",
+		msgBillingDisabled:      "%s (facturación inhabilitada)",
+		msgBillingRetrieving:    "Obteniendo las cuentas de facturación",
+		msgValidating:           "validando",
+		msgValidatingPhone:      "Validando el número de teléfono",
+		msgValidatingYesNo:      "Validando sí o no",
+		msgValidatingInteger:    "Validando el número entero",
+		msgSettingsProceed:      "La instalación continuará con estos ajustes",
+		msgStacksDetected:       "Se han detectado varias pilas",
+		msgStackPick:            "Elija la pila que quiere usar",
+		msgStacksFinding:        "Buscando pilas",
+		msgStackChosen:          "Se ha elegido la pila",
+		msgStackProceed:         "La instalación continuará con esta pila:",
+	},
+}
+
+// texts looks up the tui's text in one locale. Text from the stack's own
+// catalog wins over the built in one, and English fills in anything neither
+// has. The zero value is English.
+type texts struct {
+	locale   string
+	messages map[string]string
+}
+
+// newTexts returns the text for the locale a stack was localized to
+func newTexts(s *config.Stack) texts {
+	if s == nil {
+		return texts{}
+	}
+	return texts{locale: s.Locale, messages: s.Messages}
+}
+
+// text returns the message for id
+func (t texts) text(id string) string {
+	if v, ok := t.messages[id]; ok && v != "" {
+		return v
+	}
+
+	for _, l := range config.LocaleCandidates(t.locale) {
+		if v, ok := catalogs[l][id]; ok {
+			return v
+		}
+	}
+
+	return catalogs[baseLocale][id]
+}
+
+// textf formats the message for id
+func (t texts) textf(id string, a ...interface{}) string {
+	return fmt.Sprintf(t.text(id), a...)
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/deploystack/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// text and textf look up English, what tests expect unless they pick a
+// locale
+func text(id string) string {
+	return texts{}.text(id)
+}
+
+func textf(id string, a ...interface{}) string {
+	return texts{}.textf(id, a...)
+}
+
+func TestText(t *testing.T) {
+	catalogs["xx"] = catalog{msgContinue: "Continuer ?"}
+	defer delete(catalogs, "xx")
+
+	tests := map[string]struct {
+		locale   string
+		messages map[string]string
+		id       string
+		want     string
+	}{
+		"english":            {id: msgContinue, want: "Continue?"},
+		"built in":           {locale: "xx-YY", id: msgContinue, want: "Continuer ?"},
+		"missing falls back": {locale: "xx", id: msgZone, want: "Pick a zone"},
+		"stack catalog":      {locale: "xx", messages: map[string]string{msgContinue: "Weiter?"}, id: msgContinue, want: "Weiter?"},
+		"unknown locale":     {locale: "zz", id: msgRegion, want: "Pick a region"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := config.NewStack()
+			s.Locale = tc.locale
+			s.Messages = tc.messages
+			q := NewQueue(&s, GetMock(0))
+
+			assert.Equal(t, tc.want, q.text(tc.id))
+		})
+	}
+}
+
+func TestCatalogsComplete(t *testing.T) {
+	for l, c := range catalogs {
+		for id, v := range c {
+			en, ok := catalogs[baseLocale][id]
+			if !ok {
+				t.Errorf("%s: message %s is not in the %s catalog", l, id, baseLocale)
+				continue
+			}
+			if verbs(v) != verbs(en) {
+				t.Errorf("%s: message %s has verbs %q, want %q", l, id, verbs(v), verbs(en))
+			}
+		}
+	}
+}
+
+// verbs returns the formatting verbs in s, in order
+func verbs(s string) string {
+	return strings.Join(regexp.MustCompile(`%[a-z]`).FindAllString(s, -1), "")
+}
+
+func TestNewCustomPagesLocalized(t *testing.T) {
+	q := getTestQueue(appTitle, "test")
+	q.stack.Locale = "es"
+	q.stack.Config.CustomSettings = config.Customs{
+		{Name: "nodes", Description: "How many nodes?", Translations: map[string]string{"es": "¿Cuántos nodos?"}},
+		{Name: "tier", Description: "Tier", Options: []string{"a", "b"}, Translations: map[string]string{"es": "Nivel"}},
+	}
+
+	newCustomPages(&q)
+
+	assert.Equal(t, "¿Cuántos nodos?", q.Model("nodes").(*textInput).label)
+	assert.Equal(t, "Nivel", q.Model("tier").(*picker).list.Title)
+}
+
+func TestPagesRenderLocalized(t *testing.T) {
+	s := config.NewStack()
+	s.Locale = "es-MX"
+	s.Config.Duration = 5
+	q := NewQueue(&s, GetMock(0))
+	q.header = newHeader(appTitle, "test")
+
+	creator := newProjectCreator(&q, "project_id")
+	assert.Equal(t, "Crear un proyecto nuevo", creator.label)
+	assert.Contains(t, creator.content[0].render(), "Los ID de proyecto no se pueden cambiar")
+
+	d := newDescription(&s)
+	assert.Contains(t, d.render(), "Tardará unos")
+
+	q.exitPage()
+	got := q.models[len(q.models)-1].View()
+	assert.Contains(t, got, "Ha decidido no seguir adelante con DeployStack.")
+	assert.NotContains(t, got, "You've chosen to stop")
+}
+
+func TestQueuesKeepTheirOwnLocale(t *testing.T) {
+	es := config.NewStack()
+	es.Locale = "es"
+	en := config.NewStack()
+
+	qes := NewQueue(&es, GetMock(0))
+	qen := NewQueue(&en, GetMock(0))
+
+	t.Run("es", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, catalogs["es"][msgContinue], qes.text(msgContinue))
+	})
+	t.Run("en", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, catalogs[baseLocale][msgContinue], qen.text(msgContinue))
+	})
+}
@@ -43,6 +43,9 @@ type mock struct {
 	d        int
 	forceErr bool
 	cache    map[string]interface{}
+	// secrets are the versions of each secret created, keyed by
+	// project/name, when a test wants to keep track of them
+	secrets map[string][]string
 }
 
 func (m mock) delay() {
@@ -339,6 +342,29 @@ func (m mock) ZoneList(project, region string) ([]string, error) {
 	return r, nil
 }
 
+func (m mock) ProjectMissingIAMPermissions(project string, permissions []string) ([]string, error) {
+	m.delay()
+	if m.forceErr {
+		return nil, errForced
+	}
+
+	missing, ok := m.get("ProjectMissingIAMPermissions/" + project).([]string)
+	if !ok {
+		missing, _ = m.get("ProjectMissingIAMPermissions").([]string)
+	}
+
+	result := []string{}
+	for _, v := range permissions {
+		for _, p := range missing {
+			if v == p {
+				result = append(result, v)
+			}
+		}
+	}
+
+	return result, nil
+}
+
 func (m mock) ProjectParentGet(project string) (*cloudresourcemanager.ResourceId, error) {
 	m.delay()
 	if m.forceErr {
@@ -894,3 +920,42 @@ func (m mock) ServiceIsEnabled(project string, service gcloud.Service) (bool, er
 	}
 	return true, nil
 }
+
+var errSecretExists = fmt.Errorf("failed to create secret: googleapi: Error 409: Secret already exists")
+
+func (m mock) SecretCreate(project, name, payload string) error {
+	m.delay()
+	if m.forceErr {
+		return errForced
+	}
+	if m.secrets != nil {
+		key := fmt.Sprintf("%s/%s", project, name)
+		if _, ok := m.secrets[key]; ok {
+			return errSecretExists
+		}
+		m.secrets[key] = []string{payload}
+	}
+	return nil
+}
+
+func (m mock) SecretExists(project, name string) (bool, error) {
+	m.delay()
+	if m.forceErr {
+		return false, errForced
+	}
+	_, ok := m.secrets[fmt.Sprintf("%s/%s", project, name)]
+	return ok, nil
+}
+
+func (m mock) SecretVersionAdd(project, name, payload string) error {
+	m.delay()
+	if m.forceErr {
+		return errForced
+	}
+	key := fmt.Sprintf("%s/%s", project, name)
+	if _, ok := m.secrets[key]; !ok {
+		return fmt.Errorf("failed to create secret versiopn: secret %s not found", key)
+	}
+	m.secrets[key] = append(m.secrets[key], payload)
+	return nil
+}
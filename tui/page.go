@@ -15,9 +15,11 @@
 package tui
 
 import (
+	"fmt"
 	"os"
 	"strings"
 
+	"github.com/GoogleCloudPlatform/deploystack/config"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -37,6 +39,10 @@ type dynamicPage struct {
 	showProgress     bool
 	omitFromSettings bool
 	querySlowText    string
+	condition        config.Condition
+	defaultTemplate  string
+	// source records whether the value was given or accepted as the default
+	source string
 }
 
 func (p *dynamicPage) getKey() string {
@@ -81,6 +87,18 @@ func (p *dynamicPage) addPreView(f func(*Queue)) {
 	p.preViewFunc = f
 }
 
+func (p *dynamicPage) setCondition(c config.Condition) {
+	p.condition = c
+}
+
+// active reports whether the page should be shown given the answers so far
+func (p *dynamicPage) active() bool {
+	if p.queue == nil {
+		return true
+	}
+	return p.condition.Evaluate(p.queue.stack.Settings)
+}
+
 type page struct {
 	dynamicPage
 }
@@ -94,6 +112,9 @@ func newPage(key string, content []component) page {
 }
 
 func (p page) Init() tea.Cmd {
+	if p.state == "querying" {
+		return tea.Batch(p.spinner.Tick, p.preProcessor)
+	}
 	return p.preProcessor
 }
 
@@ -104,7 +125,7 @@ func (p page) View() string {
 	doc := strings.Builder{}
 	doc.WriteString(p.queue.header.render())
 	if p.showProgress {
-		doc.WriteString(drawProgress(p.queue.calcPercent()))
+		doc.WriteString(drawProgress(p.queue.texts, p.queue.calcPercent()))
 		doc.WriteString("\n\n")
 	}
 
@@ -113,8 +134,21 @@ func (p page) View() string {
 		doc.WriteString("\n")
 	}
 
+	if p.state == "querying" && p.spinnerLabel != "" {
+		spinnerSB := strings.Builder{}
+		spinnerSB.WriteString(textStyle.Render(fmt.Sprintf("%s ", p.spinnerLabel)))
+		spinnerSB.WriteString(spinnerStyle.Render(p.spinner.View()))
+		doc.WriteString(bodyStyle.Render(spinnerSB.String()))
+		return docStyle.Render(doc.String())
+	}
+
+	if err, ok := p.err.(errMsg); ok {
+		doc.WriteString(errorAlert{err: err, texts: p.queue.texts}.Render())
+		doc.WriteString("\n")
+	}
+
 	doc.WriteString("\n")
-	doc.WriteString(bodyStyle.Render(promptStyle.Render(" Press the Enter Key to continue ")))
+	doc.WriteString(bodyStyle.Render(promptStyle.Render(fmt.Sprintf(" %s ", p.queue.text(msgPressContinue)))))
 
 	test := docStyle.Render(doc.String())
 
@@ -123,11 +157,22 @@ func (p page) View() string {
 
 // TODO: a test for this is pretty straight forward
 func (p page) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg.(type) {
+	switch msg := msg.(type) {
 	case successMsg:
 		return p.queue.next()
+	case errMsg:
+		p.state = "idle"
+		p.err = msg
+		return p, nil
+	case spinner.TickMsg:
+		if p.state != "querying" {
+			return p, nil
+		}
+		var cmd tea.Cmd
+		p.spinner, cmd = p.spinner.Update(msg)
+		return p, cmd
 	case tea.KeyMsg:
-		switch msg.(tea.KeyMsg).String() {
+		switch msg.String() {
 
 		case "alt+b", "ctrl+b":
 			return p.queue.prev()
@@ -137,16 +182,16 @@ func (p page) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return p.queue.exitPage()
 		case "enter":
-			if p.postProcessor != nil {
-				if p.state != "querying" {
-					p.state = "querying"
-					p.err = nil
-					return p, p.postProcessor(p.value, p.queue)
-				}
-
+			if p.state == "querying" {
 				return p, nil
 			}
 
+			if p.postProcessor != nil {
+				p.state = "querying"
+				p.err = nil
+				return p, p.postProcessor(p.value, p.queue)
+			}
+
 			return p.queue.next()
 		}
 
@@ -15,7 +15,9 @@
 package tui
 
 import (
+	"fmt"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -141,3 +143,36 @@ func TestPagePreviewFunc(t *testing.T) {
 	assert.Equal(t, "test", page.getValue())
 
 }
+
+func TestPageQuerying(t *testing.T) {
+	q := getTestQueue(appTitle, "test")
+	p := newPage("test", []component{newTextBlock("checking")})
+	p.state = "querying"
+	p.spinnerLabel = "Checking"
+	dummyPage := newPage("dummy", []component{newTextBlock("dummy")})
+	q.add(&p, &dummyPage)
+
+	if !strings.Contains(p.View(), "Checking") {
+		t.Fatalf("expected the spinner label while querying")
+	}
+
+	raw, _ := p.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := raw.(page); got.key != "test" {
+		t.Fatalf("expected enter to be ignored while querying, got page '%s'", got.key)
+	}
+
+	raw, _ = p.Update(errMsg{err: fmt.Errorf("broken"), usermsg: "Something broke"})
+	got := raw.(page)
+	if got.state != "idle" || got.err == nil {
+		t.Fatalf("expected the error to stop the query, got state '%s' err '%v'", got.state, got.err)
+	}
+
+	if !strings.Contains(got.View(), "Something broke") {
+		t.Fatalf("expected the error to be shown")
+	}
+
+	raw, _ = got.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if next, ok := raw.(*page); !ok || next.key != "dummy" {
+		t.Fatalf("expected enter to continue past the error, got %T", raw)
+	}
+}
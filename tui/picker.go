@@ -19,6 +19,7 @@ import (
 	"io"
 	"strings"
 
+	"github.com/GoogleCloudPlatform/deploystack/config"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
@@ -56,6 +57,12 @@ type item struct {
 
 func (i item) FilterValue() string { return i.value }
 
+// isDefault reports whether the item is the one a default value points to
+func (i item) isDefault(t texts, defaultValue string) bool {
+	label := strings.TrimSuffix(i.label, " "+t.text(msgDefaultValue))
+	return i.value == defaultValue || label == defaultValue || defaultValue == i.value+"|"+label
+}
+
 type picker struct {
 	dynamicPage
 
@@ -92,7 +99,7 @@ func newPicker(listLabel, spinnerLabel, key, defaultValue string, preProcessor t
 	return p
 }
 
-func positionDefault(items []list.Item, defaultValue string) ([]list.Item, int) {
+func positionDefault(t texts, items []list.Item, defaultValue string) ([]list.Item, int) {
 	selectedIndex := 0
 	if defaultValue == "" {
 		return items, selectedIndex
@@ -106,15 +113,14 @@ func positionDefault(items []list.Item, defaultValue string) ([]list.Item, int)
 
 	for i, v := range items {
 		item := v.(item)
-		if item.value == defaultValue || item.label == defaultValue || defaultValue == item.value+"|"+item.label {
+		if item.isDefault(t, defaultValue) {
 			defaultItem = item
-			text := defaultItem.label + " (Default Value)"
-			defaultItem.label = text
+			defaultItem.label = fmt.Sprintf("%s %s", defaultItem.label, t.text(msgDefaultValue))
 			items[i] = defaultItem
 			defaultIndex = i
 			continue
 		}
-		if strings.Contains(item.label, "Create New Project") {
+		if strings.Contains(item.label, t.text(msgProjectCreate)) {
 			createItem = item
 			continue
 		}
@@ -164,7 +170,7 @@ func (p picker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			p.list.InsertItem(i+offset, v)
 		}
 
-		tmp, selectedIndex := positionDefault(p.list.Items(), p.defaultValue)
+		tmp, selectedIndex := positionDefault(p.queue.texts, p.list.Items(), p.defaultValue)
 		p.list.SetItems(tmp)
 
 		p.list.Select(selectedIndex)
@@ -177,14 +183,22 @@ func (p picker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return p, nil
 	case successMsg:
 		p.state = "idle"
-		newValue := p.value
+		newValue, source := p.value, p.source
 		if msg.msg == "prependProject" {
 			currentProject := p.queue.Get("currentProject").(string)
 			newValue = fmt.Sprintf("%s-%s", currentProject, newValue)
+			source = config.SourceComputed
+		}
+		// Nobody picked anything, a preprocessor answered on its own
+		if source == "" {
+			source = config.SourceComputed
 		}
 
 		if !msg.unset && !p.omitFromSettings {
-			p.queue.stack.AddSetting(p.key, newValue)
+			p.queue.stack.AddSettingSource(p.key, newValue, source)
+		}
+		if msg.unset {
+			p.queue.claimSetting(p.key, source)
 		}
 
 		return p.queue.next()
@@ -203,8 +217,9 @@ func (p picker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if ok {
 					p.value = string(i.value)
 				}
+				p.source = answerSource(!ok || !i.isDefault(p.queue.texts, p.defaultValue))
 				if !p.omitFromSettings {
-					p.queue.stack.AddSetting(p.key, p.value)
+					p.queue.stack.AddSettingSource(p.key, p.value, p.source)
 				}
 
 				if p.postProcessor != nil {
@@ -258,12 +273,12 @@ func (p picker) View() string {
 	doc.WriteString(p.queue.header.render())
 
 	if p.showProgress && p.err == nil {
-		doc.WriteString(drawProgress(p.queue.calcPercent()))
+		doc.WriteString(drawProgress(p.queue.texts, p.queue.calcPercent()))
 		doc.WriteString("\n\n")
 	}
 
 	if p.err != nil {
-		doc.WriteString(errorAlert{p.err.(errMsg)}.Render())
+		doc.WriteString(errorAlert{err: p.err.(errMsg), texts: p.queue.texts}.Render())
 		return docStyle.Render(doc.String())
 	}
 
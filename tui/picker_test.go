@@ -413,7 +413,7 @@ func TestPositionDefault(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			gotItems, gotIndex := positionDefault(tc.items, tc.defaultValue)
+			gotItems, gotIndex := positionDefault(texts{}, tc.items, tc.defaultValue)
 			assert.Equal(t, tc.wantItems, gotItems)
 			assert.Equal(t, tc.wantIndex, gotIndex)
 
@@ -15,11 +15,14 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
 	"cloud.google.com/go/domains/apiv1beta1/domainspb"
+	"github.com/GoogleCloudPlatform/deploystack/config"
 	"github.com/GoogleCloudPlatform/deploystack/gcloud"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/nyaruka/phonenumbers"
@@ -58,7 +61,7 @@ func handleProjectNumber(projectID string, q *Queue) tea.Msg {
 		if err != nil {
 			return errMsg{err: err}
 		}
-		q.stack.AddSetting("project_number", projectnumber)
+		q.stack.AddSettingSource("project_number", projectnumber, config.SourceComputed)
 	}
 	return nil
 }
@@ -99,13 +102,13 @@ func createProject(projectID string, q *Queue) tea.Cmd {
 		qmod := q.Model("region")
 		if qmod != nil {
 			r := qmod.(*picker)
-			r.querySlowText = "Getting regions can take a little extra time if this is a new project"
+			r.querySlowText = q.text(msgRegionsSlow)
 		}
 
 		qmod = q.Model("zone")
 		if qmod != nil {
 			z := qmod.(*picker)
-			z.querySlowText = "Getting zones can take a little extra time if this is a new project"
+			z.querySlowText = q.text(msgZonesSlow)
 		}
 
 		q.Save("currentProject", projectID)
@@ -150,13 +153,13 @@ func validateDomain(domain string, q *Queue) tea.Cmd {
 			isVerified, err := q.client.DomainIsVerified(projectID, domain)
 			if err != nil {
 				return errMsg{
-					usermsg: "Trying to validate that you own this domain failed due to an error",
+					usermsg: q.text(msgDomainVerifyFailed),
 					err:     fmt.Errorf("validateDomain: error verifying domain: %s", err),
 				}
 			}
 			if !isVerified {
 				return errMsg{
-					usermsg: "Domain is owned by someone other than the requestor",
+					usermsg: q.text(msgDomainNotOwned),
 					err:     fmt.Errorf("validateDomain: not owned by requestor: %w", err),
 				}
 			}
@@ -169,7 +172,7 @@ func validateDomain(domain string, q *Queue) tea.Cmd {
 
 func registerDomain(consent string, q *Queue) tea.Cmd {
 	return func() tea.Msg {
-		userMsg := "There was a problem registering the domain."
+		userMsg := q.text(msgDomainRegisterFailed)
 		c := strings.ToLower(consent)
 		if c != "y" && c != "yes" {
 			q.stack.DeleteSetting("domain_consent")
@@ -224,7 +227,7 @@ func registerDomain(consent string, q *Queue) tea.Cmd {
 
 		err := q.client.DomainRegister(projectID, domainInfo, d)
 		if err != nil {
-			q.stack.AddSetting("domain_consent", "")
+			q.stack.AddSettingSource("domain_consent", "", config.SourceComputed)
 			return errMsg{
 				usermsg: userMsg,
 				err:     fmt.Errorf("registerDomain: error registering domain: %w", err),
@@ -247,7 +250,7 @@ func validateInteger(input string, q *Queue) tea.Cmd {
 	return func() tea.Msg {
 		_, err := strconv.Atoi(input)
 		if err != nil {
-			return errMsg{err: fmt.Errorf("Your answer '%s' not a valid integer", input)}
+			return errMsg{err: errors.New(q.textf(msgAnswerInteger, input))}
 		}
 		return successMsg{}
 	}
@@ -263,10 +266,10 @@ func checkYesOrNo(input string) bool {
 
 func validateYesOrNo(input string, q *Queue) tea.Cmd {
 	return func() tea.Msg {
-		text := strings.TrimSpace(strings.ToLower(input))
+		answer := strings.TrimSpace(strings.ToLower(input))
 
-		if !checkYesOrNo(text) {
-			return errMsg{err: fmt.Errorf("Your answer '%s' is neither 'yes' nor 'no'", input)}
+		if !checkYesOrNo(answer) {
+			return errMsg{err: errors.New(q.textf(msgAnswerYesNo, input))}
 		}
 
 		return successMsg{}
@@ -277,7 +280,7 @@ func validatePhoneNumber(input string, q *Queue) tea.Cmd {
 	return func() tea.Msg {
 		_, err := massagePhoneNumber(input)
 		if err != nil {
-			return errMsg{err: fmt.Errorf("Your answer '%s' is not a valid phone number. Please try again", input)}
+			return errMsg{err: errors.New(q.textf(msgAnswerPhone, input))}
 		}
 
 		return successMsg{}
@@ -300,13 +303,13 @@ func massagePhoneNumber(s string) (string, error) {
 // TODO: see if you can test these error conditions
 func validateGCEDefault(input string, q *Queue) tea.Cmd {
 	return func() tea.Msg {
-		text := strings.TrimSpace(strings.ToLower(input))
+		answer := strings.TrimSpace(strings.ToLower(input))
 
-		if !checkYesOrNo(text) {
-			return errMsg{err: fmt.Errorf("Your answer '%s' is neither 'yes' nor 'no'", input)}
+		if !checkYesOrNo(answer) {
+			return errMsg{err: errors.New(q.textf(msgAnswerYesNo, input))}
 		}
 
-		if string(text[0]) == "n" {
+		if string(answer[0]) == "n" {
 			return successMsg{}
 		}
 
@@ -338,7 +341,7 @@ func validateGCEDefault(input string, q *Queue) tea.Cmd {
 		}
 
 		for i, v := range defaultConfig {
-			q.stack.AddSetting(i, v)
+			q.stack.AddSettingSource(i, v, config.SourceDefault)
 		}
 		q.removeModel("instance-webserver")
 		q.removeModel("instance-image-project")
@@ -360,11 +363,11 @@ func validateGCEDefault(input string, q *Queue) tea.Cmd {
 
 func validateGCEConfiguration(input string, q *Queue) tea.Cmd {
 	return func() tea.Msg {
-		q.stack.AddSetting("instance-tags", "")
+		q.stack.AddSettingSource("instance-tags", "", config.SourceComputed)
 		instanceWebserver := q.stack.GetSetting("instance-webserver")
 
 		if instanceWebserver == "y" || input == "y" {
-			q.stack.AddSetting("instance-tags", gcloud.HTTPServerTags)
+			q.stack.AddSettingSource("instance-tags", gcloud.HTTPServerTags, config.SourceComputed)
 		}
 		q.stack.DeleteSetting("gce-use-defaults")
 		q.stack.DeleteSetting("instance-webserver")
@@ -375,12 +378,100 @@ func validateGCEConfiguration(input string, q *Queue) tea.Cmd {
 	}
 }
 
+// validateCustom checks an answer against the validation rules an author
+// configured for a custom setting, and stores it as the type the author asked
+// for, marked as secret if need be. Rules are looked up when the answer is
+// given so that named rules can live anywhere in the config. Lists and maps
+// are validated item by item.
+func validateCustom(c config.Custom) func(string, *Queue) tea.Cmd {
+	return func(input string, q *Queue) tea.Cmd {
+		return func() tea.Msg {
+			kind := config.BaseType(c.Type)
+
+			set := config.Setting{}
+			values := []string{input}
+
+			if kind != "string" || c.Secret {
+				var err error
+				set, err = config.NewSettingTyped(c.Name, c.Type, input)
+				if err != nil {
+					return errMsg{err: errors.New(q.textf(msgAnswerInvalid, err))}
+				}
+
+				switch kind {
+				case "list":
+					values = set.List
+				case "map":
+					values = []string{}
+					for _, v := range set.Map {
+						values = append(values, v)
+					}
+					sort.Strings(values)
+				}
+			}
+
+			for _, v := range values {
+				if err := q.stack.Config.Validate(c, v); err != nil {
+					msg := errMsg{err: err}
+
+					var verr config.ValidationError
+					if errors.As(err, &verr) {
+						msg.usermsg = verr.Message
+					}
+
+					return msg
+				}
+			}
+
+			if kind != "string" || c.Secret {
+				set.Secret = c.Secret
+				// Only strings can have the project put in front of them, and
+				// lint reports prepend_project on anything else
+				if currentProject, ok := q.Get("currentProject").(string); ok && c.PrependProject && kind == "string" {
+					set.Value = fmt.Sprintf("%s-%s", currentProject, set.Value)
+					set.Source = config.SourceComputed
+				}
+				q.stack.AddSettingComplete(set)
+				return successMsg{unset: true}
+			}
+
+			if c.PrependProject {
+				return successMsg{msg: "prependProject"}
+			}
+
+			return successMsg{}
+		}
+	}
+}
+
 func prependProject(value string, q *Queue) tea.Cmd {
 	return func() tea.Msg {
 		return successMsg{msg: "prependProject"}
 	}
 }
 
+func applyProfile(profile string, q *Queue) tea.Cmd {
+	err := q.applyProfile(profile)
+
+	return func() tea.Msg {
+		if err != nil {
+			return errMsg{err: fmt.Errorf("applyProfile: could not apply profile: %s", err)}
+		}
+		return successMsg{}
+	}
+}
+
+func applyPrefill(mode string, q *Queue) tea.Cmd {
+	err := q.applyPrefill(mode)
+
+	return func() tea.Msg {
+		if err != nil {
+			return errMsg{err: fmt.Errorf("applyPrefill: could not use the answers from the last run: %s", err)}
+		}
+		return successMsg{}
+	}
+}
+
 func handleStackSelection(stack string, q *Queue) tea.Cmd {
 	q.Save("stack", stack)
 
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"cloud.google.com/go/domains/apiv1beta1/domainspb"
+	"github.com/GoogleCloudPlatform/deploystack/config"
 	"github.com/GoogleCloudPlatform/deploystack/gcloud"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/stretchr/testify/assert"
@@ -235,6 +236,85 @@ func TestValidateInteger(t *testing.T) {
 	}
 }
 
+func TestValidateCustom(t *testing.T) {
+	tests := map[string]struct {
+		c   config.Custom
+		in  string
+		msg tea.Msg
+	}{
+		"builtin": {
+			c:   config.Custom{Name: "nodes", Validation: "integer"},
+			in:  "five",
+			msg: errMsg{err: fmt.Errorf("Your answer 'five' not a valid integer")},
+		},
+		"named": {
+			c:   config.Custom{Name: "tier", Validation: "tier"},
+			in:  "medium",
+			msg: errMsg{err: fmt.Errorf("Your answer 'medium' is not one of: small, large"), usermsg: "Tiers are small or large"},
+		},
+		"inline": {
+			c:   config.Custom{Name: "name", Rule: &config.Rule{MaxLength: 3}},
+			in:  "abc",
+			msg: successMsg{},
+		},
+		"prepend": {
+			c:   config.Custom{Name: "bucket", Validation: "gcs_bucket", PrependProject: true},
+			in:  "assets",
+			msg: successMsg{msg: "prependProject"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getTestQueue(appTitle, "test")
+			q.stack.Config.Validations = map[string]config.Rule{
+				"tier": {Enum: []string{"small", "large"}, Message: "Tiers are small or large"},
+			}
+			cmd := validateCustom(tc.c)(tc.in, &q)
+
+			got := cmd()
+
+			switch tc.msg.(type) {
+			case successMsg:
+				if tc.msg != got {
+					t.Fatalf("%s - want: \n'%+v' \ngot: \n'%+v'", tc.in, tc.msg, got)
+				}
+			case errMsg:
+				gotE := got.(errMsg)
+				tcmsgE := tc.msg.(errMsg)
+
+				if tcmsgE.err.Error() != gotE.err.Error() {
+					t.Fatalf("want: \n'%+v' \ngot: \n'%+v'", tcmsgE.err.Error(), gotE.err.Error())
+				}
+
+				if tcmsgE.usermsg != gotE.usermsg {
+					t.Fatalf("usermsg want: \n'%+v' \ngot: \n'%+v'", tcmsgE.usermsg, gotE.usermsg)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateCustomPrependSecret(t *testing.T) {
+	q := getTestQueue(appTitle, "test")
+	c := config.Custom{Name: "bucket", Secret: true, PrependProject: true}
+
+	got := validateCustom(c)("assets", &q)()
+	if got != (successMsg{unset: true}) {
+		t.Fatalf("want: successMsg{unset: true} got: %+v", got)
+	}
+
+	set := q.stack.Settings.Find("bucket")
+	if set == nil {
+		t.Fatalf("expected bucket to be set")
+	}
+
+	want := fmt.Sprintf("%s-assets", q.Get("currentProject"))
+	if set.Value != want || !set.Secret || set.Source != config.SourceComputed {
+		t.Fatalf("want: %s, secret and computed got: %+v", want, set)
+	}
+}
+
 func TestValidateDomain(t *testing.T) {
 	tests := map[string]struct {
 		in  string
@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"github.com/GoogleCloudPlatform/deploystack/config"
+	"github.com/GoogleCloudPlatform/deploystack/terraform"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/stretchr/testify/assert"
@@ -293,3 +294,157 @@ func TestCleanUp(t *testing.T) {
 		})
 	}
 }
+
+func TestEnableServices(t *testing.T) {
+	services := []string{"compute.googleapis.com", "sqladmin.googleapis.com"}
+
+	tests := map[string]struct {
+		project  string
+		second   string
+		current  bool
+		forceErr bool
+		want     tea.Msg
+		checked  int
+	}{
+		"enabled": {
+			project: "test-project",
+			want:    successMsg{},
+			checked: 2,
+		},
+		"current project": {
+			want:    successMsg{},
+			checked: 2,
+		},
+		"noproject": {
+			current: true,
+			want:    successMsg{},
+		},
+		"failed": {
+			project:  "test-project",
+			forceErr: true,
+			want: errMsg{
+				err:     fmt.Errorf("could not enable compute.googleapis.com (%s), sqladmin.googleapis.com (%s)", errForced, errForced),
+				usermsg: text(msgServicesFailed),
+			},
+			checked: 2,
+		},
+		"projects failed": {
+			project:  "test-project",
+			second:   "test-project-2",
+			forceErr: true,
+			want: errMsg{
+				err:     fmt.Errorf("could not enable compute.googleapis.com (test-project: %s), sqladmin.googleapis.com (test-project: %s)", errForced, errForced),
+				usermsg: text(msgServicesFailed),
+			},
+			checked: 2,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			stack := config.NewStack()
+			q := NewQueue(&stack, mock{forceErr: tc.forceErr})
+			if tc.project != "" {
+				q.stack.AddSetting("project_id", tc.project)
+			}
+			if tc.second != "" {
+				q.stack.Config.Projects.Items = []config.Project{{Name: "project_id"}, {Name: "project_id_2"}}
+				q.stack.AddSetting("project_id_2", tc.second)
+			}
+			if tc.current {
+				q.Save("currentProject", "")
+			}
+
+			progress := newServiceProgress(texts{}, services)
+			got := enableServices(&q, progress)()
+
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.checked, len(progress.done))
+		})
+	}
+}
+
+func TestCheckPermissions(t *testing.T) {
+	needed := []string{"compute.instances.create", "resourcemanager.projects.setIamPolicy"}
+
+	tests := map[string]struct {
+		projects map[string]string
+		current  string
+		missing  map[string][]string
+		forceErr bool
+		want     tea.Msg
+		results  []projectPermissions
+	}{
+		"granted": {
+			projects: map[string]string{"project_id": "test-project"},
+			want:     successMsg{},
+			results:  []projectPermissions{{project: "test-project", missing: []string{}}},
+		},
+		"current project": {
+			current: "ds-tester-singlevm",
+			want:    successMsg{},
+			results: []projectPermissions{{project: "ds-tester-singlevm", missing: []string{}}},
+		},
+		"noproject": {
+			want: successMsg{},
+		},
+		"missing": {
+			projects: map[string]string{"project_id": "test-project"},
+			missing:  map[string][]string{"test-project": {"resourcemanager.projects.setIamPolicy"}},
+			want: errMsg{
+				err:     fmt.Errorf("missing resourcemanager.projects.setIamPolicy on test-project"),
+				usermsg: text(msgPermissionsFailed),
+				warning: true,
+			},
+			results: []projectPermissions{{project: "test-project", missing: []string{"resourcemanager.projects.setIamPolicy"}}},
+		},
+		"projects": {
+			projects: map[string]string{"project_id": "test-project", "project_id_2": "test-project-2"},
+			missing:  map[string][]string{"test-project-2": {"compute.instances.create"}},
+			want: errMsg{
+				err:     fmt.Errorf("missing compute.instances.create on test-project-2"),
+				usermsg: text(msgPermissionsFailed),
+				warning: true,
+			},
+			results: []projectPermissions{
+				{project: "test-project", missing: []string{}},
+				{project: "test-project-2", missing: []string{"compute.instances.create"}},
+			},
+		},
+		"failed": {
+			projects: map[string]string{"project_id": "test-project"},
+			forceErr: true,
+			want: errMsg{
+				err:     fmt.Errorf("could not check permissions on test-project: %s", errForced),
+				usermsg: text(msgPermissionsUnknown),
+				warning: true,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := mock{forceErr: tc.forceErr}
+			for project, missing := range tc.missing {
+				m.save("ProjectMissingIAMPermissions/"+project, missing)
+			}
+
+			stack := config.NewStack()
+			q := NewQueue(&stack, m)
+			q.Save("currentProject", tc.current)
+			for _, name := range []string{"project_id", "project_id_2"} {
+				if project, ok := tc.projects[name]; ok {
+					q.stack.Config.Projects.Items = append(q.stack.Config.Projects.Items, config.Project{Name: name})
+					q.stack.AddSetting(name, project)
+				}
+			}
+
+			report := newPermissionReport(texts{}, terraform.Permissions{}, needed)
+			got := checkPermissions(&q, report)()
+
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.results != nil, report.checked)
+			assert.Equal(t, tc.results, report.results)
+		})
+	}
+}
@@ -17,6 +17,7 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/GoogleCloudPlatform/deploystack/config"
 	"github.com/GoogleCloudPlatform/deploystack/gcloud"
@@ -34,7 +35,7 @@ func getProjects(q *Queue) tea.Cmd {
 		items := []list.Item{}
 		for _, v := range p {
 			if !v.BillingEnabled {
-				label := fmt.Sprintf("%s (Billing Diabled)", v.Name)
+				label := q.textf(msgBillingDisabled, v.Name)
 				items = append(items, item{value: v.ID, label: billingDisabledStyle.Render(label)})
 				continue
 			}
@@ -122,10 +123,15 @@ func handleReports(q *Queue) tea.Cmd {
 }
 
 func getZones(q *Queue) tea.Cmd {
+	return getZonesIn(q, "region")
+}
+
+// getZonesIn lists the zones of the region held in the regionKey setting
+func getZonesIn(q *Queue, regionKey string) tea.Cmd {
 	return func() tea.Msg {
 		s := q.stack
 		project := s.GetSetting("project_id")
-		region := s.GetSetting("region")
+		region := s.GetSetting(regionKey)
 
 		p, err := q.client.ZoneList(project, region)
 		if err != nil {
@@ -265,9 +271,35 @@ func getImageDisks(q *Queue) tea.Cmd {
 func getDiskTypes(q *Queue) tea.Cmd {
 	return func() tea.Msg {
 		items := []list.Item{
-			item{"Standard", "pd-standard"},
-			item{"Balanced", "pd-balanced"},
-			item{"SSD", "pd-sdd"},
+			item{q.text(msgDiskStandard), "pd-standard"},
+			item{q.text(msgDiskBalanced), "pd-balanced"},
+			item{q.text(msgDiskSSD), "pd-sdd"},
+		}
+
+		return items
+	}
+}
+
+func getProfiles(q *Queue) tea.Cmd {
+	return func() tea.Msg {
+		items := []list.Item{}
+		for _, v := range q.stack.Config.Profiles {
+			label := v.Name
+			if v.Description != "" {
+				label = fmt.Sprintf("%s - %s", v.Name, v.Description)
+			}
+			items = append(items, item{label, v.Name})
+		}
+
+		return items
+	}
+}
+
+func getPrefillOptions(q *Queue) tea.Cmd {
+	return func() tea.Msg {
+		items := []list.Item{
+			item{q.text(msgPrefillResume), prefillResume},
+			item{q.text(msgPrefillReview), prefillReview},
 		}
 
 		return items
@@ -277,8 +309,8 @@ func getDiskTypes(q *Queue) tea.Cmd {
 func getYesOrNo(q *Queue) tea.Cmd {
 	return func() tea.Msg {
 		items := []list.Item{
-			item{"Yes", "y"},
-			item{"No", "n"},
+			item{q.text(msgYes), "y"},
+			item{q.text(msgNo), "n"},
 		}
 
 		return items
@@ -288,14 +320,109 @@ func getYesOrNo(q *Queue) tea.Cmd {
 func getNoOrYes(q *Queue) tea.Cmd {
 	return func() tea.Msg {
 		items := []list.Item{
-			item{"No", "n"},
-			item{"Yes", "y"},
+			item{q.text(msgNo), "n"},
+			item{q.text(msgYes), "y"},
 		}
 
 		return items
 	}
 }
 
+// serviceWorkers is how many services are enabled at once
+const serviceWorkers = 5
+
+// enableServices turns on the services the stack needs in the chosen project
+// before Terraform gets a chance to trip over one that is off. Failures are
+// reported together once every service has been tried.
+func enableServices(q *Queue, progress *serviceProgress) tea.Cmd {
+	return func() tea.Msg {
+		projects := q.projects()
+		if len(projects) == 0 {
+			return successMsg{}
+		}
+
+		progress.reset()
+
+		wg := sync.WaitGroup{}
+		workers := make(chan bool, serviceWorkers)
+		for _, v := range progress.services {
+			wg.Add(1)
+			workers <- true
+			go func(service string) {
+				defer wg.Done()
+				defer func() { <-workers }()
+				progress.finish(service, enableService(q, projects, service))
+			}(v)
+		}
+		wg.Wait()
+
+		if failed := progress.failures(); len(failed) > 0 {
+			return errMsg{
+				err:     fmt.Errorf("could not enable %s", strings.Join(failed, ", ")),
+				usermsg: q.text(msgServicesFailed),
+			}
+		}
+
+		return successMsg{}
+	}
+}
+
+// enableService enables service in each of the projects, stopping at the
+// first that fails
+func enableService(q *Queue, projects []string, service string) error {
+	for _, project := range projects {
+		if err := q.client.ServiceEnable(project, gcloud.NewService(service)); err != nil {
+			if len(projects) > 1 {
+				return fmt.Errorf("%s: %s", project, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPermissions asks each of the stack's projects whether the current user
+// has the permissions the stack needs, so one that is missing turns up now
+// rather than halfway through Terraform.
+func checkPermissions(q *Queue, report *permissionReport) tea.Cmd {
+	return func() tea.Msg {
+		projects := q.projects()
+		if len(projects) == 0 {
+			return successMsg{}
+		}
+
+		results := []projectPermissions{}
+		failed := []string{}
+		for _, project := range projects {
+			missing, err := q.client.ProjectMissingIAMPermissions(project, report.needed)
+			if err != nil {
+				return errMsg{
+					err:     fmt.Errorf("could not check permissions on %s: %s", project, err),
+					usermsg: q.text(msgPermissionsUnknown),
+					warning: true,
+				}
+			}
+
+			results = append(results, projectPermissions{project: project, missing: missing})
+			for _, v := range missing {
+				failed = append(failed, fmt.Sprintf("%s on %s", v, project))
+			}
+		}
+
+		report.finish(results)
+
+		if len(failed) > 0 {
+			return errMsg{
+				err:     fmt.Errorf("missing %s", strings.Join(failed, ", ")),
+				usermsg: q.text(msgPermissionsFailed),
+				warning: true,
+			}
+		}
+
+		return successMsg{}
+	}
+}
+
 func cleanUp(q *Queue) tea.Cmd {
 	return func() tea.Msg {
 		// // Don't let these get leaked to terraform
@@ -15,7 +15,13 @@
 package tui
 
 import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
 	"github.com/GoogleCloudPlatform/deploystack/config"
+	"github.com/GoogleCloudPlatform/deploystack/terraform"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -29,6 +35,8 @@ type QueueModel interface {
 	addContent(...string)
 	clearContent()
 	clear()
+	setCondition(config.Condition)
+	active() bool
 }
 
 // Queue represents the flow of the application from screen to screen, or
@@ -43,11 +51,12 @@ type Queue struct {
 	store   map[string]interface{}
 	index   []string
 	client  UIClient
+	texts   texts
 }
 
 // NewQueue creates a new queue. You should need only one per app
 func NewQueue(s *config.Stack, client UIClient) Queue {
-	q := Queue{stack: s, store: map[string]interface{}{}}
+	q := Queue{stack: s, store: map[string]interface{}{}, texts: newTexts(s)}
 	q.client = client
 	q.index = []string{}
 
@@ -57,6 +66,20 @@ func NewQueue(s *config.Stack, client UIClient) Queue {
 	return q
 }
 
+// text returns the message for id in the locale of the queue's stack. Pages
+// that haven't been added to a queue yet get English.
+func (q *Queue) text(id string) string {
+	if q == nil {
+		return texts{}.text(id)
+	}
+	return q.texts.text(id)
+}
+
+// textf formats the message for id in the locale of the queue's stack
+func (q *Queue) textf(id string, a ...interface{}) string {
+	return fmt.Sprintf(q.text(id), a...)
+}
+
 // Model retrieves a given model by key from the queue
 func (q *Queue) Model(key string) QueueModel {
 
@@ -102,6 +125,7 @@ func (q *Queue) goToModel(key string) (tea.Model, tea.Cmd) {
 		if v.getKey() == key {
 			q.current = i
 			r := q.models[q.current]
+			q.resolveDefault(r)
 			return r, r.Init()
 		}
 	}
@@ -124,25 +148,353 @@ func (q *Queue) clear(key string) {
 
 func (q *Queue) next() (tea.Model, tea.Cmd) {
 	q.current++
+	q.skipInactive(1)
 	if q.current >= len(q.models) {
 		return q.models[len(q.models)-1], tea.Quit
 	}
 
 	r := q.models[q.current]
+	q.resolveDefault(r)
 	return r, r.Init()
 }
 
 func (q *Queue) prev() (tea.Model, tea.Cmd) {
 	q.current--
+	q.skipInactive(-1)
 	if q.current <= 0 {
 		return q.models[0], nil
 	}
 
 	r := q.models[q.current]
 	r.setValue("")
+	q.resolveDefault(r)
 	return r, r.Init()
 }
 
+// skipInactive moves past any pages whose conditions are not met by the
+// answers given so far, in the direction given. Conditions are checked here
+// rather than when the queue is built so that going back and changing an
+// answer brings the right questions in and out of the flow. Anything a
+// skipped page may have set before is removed so it does not end up in the
+// tfvars file.
+func (q *Queue) skipInactive(direction int) {
+	for q.current > 0 && q.current < len(q.models) {
+		r := q.models[q.current]
+		if q.answered(r.getKey()) {
+			q.current += direction
+			continue
+		}
+		if r.active() {
+			return
+		}
+		r.clear()
+		q.stack.DeleteSetting(r.getKey())
+		q.current += direction
+	}
+}
+
+// resolveDefault renders a templated default against the answers given so
+// far. It happens as a page is shown, so that the default reflects changes
+// made by going back, but answers that haven't changed get the same default
+// as before.
+func (q *Queue) resolveDefault(r QueueModel) {
+	var tmpl string
+	var set func(string)
+	var setErr func(error)
+
+	switch m := r.(type) {
+	case *textInput:
+		tmpl = m.defaultTemplate
+		set = func(s string) { m.ti.Placeholder = s }
+		setErr = func(err error) { m.err = err }
+	case *listInput:
+		tmpl = m.defaultTemplate
+		set = func(s string) { m.defaults = s }
+		setErr = func(err error) { m.err = err }
+	case *picker:
+		tmpl = m.defaultTemplate
+		set = func(s string) { m.defaultValue = s }
+		setErr = func(err error) { m.err = errMsg{err: err} }
+	}
+
+	if answer, ok := q.prefillAnswer(r.getKey()); ok {
+		set(answer)
+		return
+	}
+
+	if tmpl == "" {
+		return
+	}
+
+	value, err := q.renderDefault(r.getKey(), tmpl)
+	if err != nil {
+		set("")
+		setErr(err)
+		return
+	}
+
+	set(value)
+}
+
+type rendering struct {
+	tmpl    string
+	answers map[string]string
+	value   string
+	err     error
+}
+
+// renderDefault renders tmpl for the setting called name once a run, kept by
+// name, so defaults that use random don't change each time their page is
+// shown. It is only rendered again if the template or the other answers
+// change.
+func (q *Queue) renderDefault(name, tmpl string) (string, error) {
+	answers := map[string]string{}
+	for _, v := range q.stack.Settings {
+		if v.Name != name {
+			answers[v.Name] = v.Value
+		}
+	}
+
+	cache, ok := q.Get(renderedDefaults).(map[string]rendering)
+	if !ok {
+		cache = map[string]rendering{}
+		q.Save(renderedDefaults, cache)
+	}
+
+	if r, ok := cache[name]; ok && r.tmpl == tmpl && reflect.DeepEqual(r.answers, answers) {
+		return r.value, r.err
+	}
+
+	value, err := config.RenderDefault(tmpl, q.stack.Settings)
+	cache[name] = rendering{tmpl: tmpl, answers: answers, value: value, err: err}
+
+	return value, err
+}
+
+// applyProfile lays a profile over the config the queue was built from. The
+// pages after the current one are rebuilt, and anything they collected is
+// removed, because the profile may have changed their defaults and options
+// or answered them outright.
+func (q *Queue) applyProfile(name string) error {
+	base, ok := q.Get(profileBase).(config.Config)
+	if !ok {
+		base = q.stack.Config
+	}
+
+	c, err := base.WithProfile(name)
+	if err != nil {
+		return err
+	}
+
+	tail := []QueueModel{}
+	for _, v := range q.models[q.current+1:] {
+		if v.getKey() == "endpage" || v.getKey() == "costs" {
+			tail = append(tail, v)
+			continue
+		}
+		q.stack.DeleteSetting(v.getKey())
+	}
+
+	for _, v := range q.stack.Config.AuthorSettings {
+		if !q.stack.Overridden(v.Name) {
+			q.stack.DeleteSetting(v.Name)
+		}
+	}
+	q.stack.Config = c
+	for _, v := range q.stack.Config.GetAuthorSettings() {
+		if !q.stack.Overridden(v.Name) {
+			v.Source = config.SourceAuthor
+			q.stack.AddSettingComplete(v)
+		}
+	}
+
+	q.models = q.models[:q.current+1]
+	q.index = q.index[:q.current+1]
+	q.addConfigPages()
+	q.add(tail...)
+
+	return nil
+}
+
+// loadPrefill reads the tfvars file left by an earlier run of the stack, so
+// those answers can be used instead of asking every question again. Answers
+// that no longer fit the config are left out, and the reasons saved so they
+// can be shown before the questions are asked again.
+func (q *Queue) loadPrefill() {
+	file := q.stack.FindTFvars()
+	if file == "" {
+		return
+	}
+
+	prior, err := config.ReadTFvars(file)
+	if err != nil {
+		q.Save(prefillNotes, []string{q.textf(msgPrefillReplaced, err)})
+		return
+	}
+
+	sets, rejected := q.stack.Prefill(prior)
+	q.Save(prefillFile, file)
+	q.Save(prefilled, sets)
+	q.Save(prefillNotes, rejected)
+}
+
+// applyPrefill acts on the choice of what to do with the answers from an
+// earlier run. Resuming makes them settings, so the pages that ask for them
+// are skipped. Reviewing leaves every page in place, with the earlier answer
+// as its default.
+func (q *Queue) applyPrefill(mode string) error {
+	sets, _ := q.Get(prefilled).(config.Settings)
+	q.Save(prefillMode, mode)
+
+	if mode != prefillResume {
+		return nil
+	}
+
+	if profile := sets.Find(config.ProfileSetting); profile != nil && len(q.stack.Config.Profiles) > 0 {
+		if err := q.applyProfile(profile.Value); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range sets {
+		if q.stack.Config.AuthorSettings.Find(v.Name) != nil {
+			continue
+		}
+		v.Source = config.SourcePrevious
+		q.stack.AddSettingComplete(v)
+	}
+
+	for _, v := range q.stack.Config.Projects.Items {
+		if q.stack.GetSetting(v.Name) == "" {
+			continue
+		}
+
+		// A project that has gone away since the last run is asked for again
+		if !q.useProject(v.Name) {
+			q.stack.DeleteSetting(v.Name)
+			continue
+		}
+
+		q.removeModel(v.Name + projNewSuffix)
+		q.removeModel(v.Name + billNewSuffix)
+	}
+
+	return nil
+}
+
+// useProject makes a project that was set without its page, from the
+// environment or an earlier run, the one to work in, the way picking it would
+// have. It reports false if the project can't be used.
+func (q *Queue) useProject(key string) bool {
+	project := q.stack.GetSetting(key)
+	if project == "" {
+		return false
+	}
+
+	if err := q.client.ProjectIDSet(project); err != nil {
+		return false
+	}
+
+	q.Save("currentProject", project)
+	return true
+}
+
+// projects returns the projects the stack installs into: the one chosen or
+// created for each of its project questions, or the current project when it
+// doesn't ask for one
+func (q *Queue) projects() []string {
+	result := []string{}
+	seen := map[string]bool{}
+	add := func(project string) {
+		if project != "" && !seen[project] {
+			seen[project] = true
+			result = append(result, project)
+		}
+	}
+
+	for _, v := range q.stack.Config.Projects.Items {
+		add(q.stack.GetSetting(v.Name))
+	}
+	add(q.stack.GetSetting("project_id"))
+
+	if len(result) == 0 {
+		current, _ := q.Get("currentProject").(string)
+		add(current)
+	}
+
+	return result
+}
+
+// answered reports whether a page is answered by a setting that came from
+// outside the queue, either the environment or an earlier run being
+// resumed, and so should not be shown.
+func (q *Queue) answered(key string) bool {
+	if q.stack.Settings.Find(key) == nil {
+		return false
+	}
+
+	if q.stack.Overridden(key) {
+		return true
+	}
+
+	if q.Get(prefillMode) != prefillResume {
+		return false
+	}
+
+	sets, _ := q.Get(prefilled).(config.Settings)
+	return sets.Find(key) != nil
+}
+
+// prefillAnswer returns the answer given to a page in an earlier run, as it
+// would be typed in, when that run is being reviewed.
+func (q *Queue) prefillAnswer(key string) (string, bool) {
+	if q.Get(prefillMode) != prefillReview {
+		return "", false
+	}
+
+	sets, _ := q.Get(prefilled).(config.Settings)
+	set := sets.Find(key)
+	if set == nil {
+		return "", false
+	}
+	answer := set.Answer()
+
+	// The project is added back by the page, so it can't be part of the
+	// default as well.
+	c := q.stack.Config.CustomSettings.Get(key)
+	if currentProject, ok := q.Get("currentProject").(string); ok && c.PrependProject && currentProject != "" {
+		answer = strings.TrimPrefix(answer, currentProject+"-")
+	}
+
+	return answer, true
+}
+
+// answerSource names where an answer came from, given whether it was typed
+// or picked rather than accepted as the default.
+func answerSource(given bool) string {
+	if given {
+		return config.SourceUser
+	}
+	return config.SourceDefault
+}
+
+// claimSetting records where a setting stored by a post processor came from,
+// unless the post processor already did.
+func (q *Queue) claimSetting(key, source string) {
+	set := q.stack.Settings.Find(key)
+	if set == nil || set.Source != "" {
+		return
+	}
+	q.stack.SetSettingSource(key, source)
+}
+
+// setCondition makes the display of models dependent on earlier answers
+func (q *Queue) setCondition(c config.Condition, models ...QueueModel) {
+	for _, v := range models {
+		v.setCondition(c)
+	}
+}
+
 func (q *Queue) currentKey() string {
 	if len(q.models) == 0 {
 		return ""
@@ -158,14 +510,14 @@ func (q *Queue) InitializeUI() {
 	desc := newDescription(q.stack)
 	appHeader := newHeader(appTitle, q.stack.Config.Title)
 
-	firstPage := newPage("firstpage", []component{newTextBlock(explainText)})
+	firstPage := newPage("firstpage", []component{newTextBlock(q.text(msgExplain))})
 	descPage := newPage("descpage", []component{desc})
 
 	firstPage.showProgress = false
 	descPage.showProgress = false
 
 	endpage := newPage("endpage", []component{
-		newTextBlock(titleStyle.Render("Project Settings")),
+		newTextBlock(titleStyle.Render(q.text(msgProjectSettings))),
 		newSettingsTable(q.stack),
 	})
 	endpage.addPreProcessor(cleanUp(q))
@@ -174,6 +526,7 @@ func (q *Queue) InitializeUI() {
 	q.add(&firstPage)
 	q.add(&descPage)
 	q.ProcessConfig()
+	newCostPage(q)
 	q.add(&endpage)
 }
 
@@ -183,10 +536,115 @@ func (q *Queue) getSettings() string {
 	return r.render()
 }
 
+// deliverSettings hands the collected settings over to Terraform. Everything
+// but secrets is written to the tfvars file, in the format the stack asks
+// for. Secrets are either stored in Secret Manager, with only a reference to
+// them passed along, or written to a file of TF_VAR_ exports for the install
+// script to source. Secrets already in Secret Manager from an earlier run get
+// a new version. A summary of every setting and where its value came from is
+// written alongside.
+func (q *Queue) deliverSettings() error {
+	project := q.stack.GetSetting("project_id")
+	if project == "" {
+		project, _ = q.Get("currentProject").(string)
+	}
+
+	for _, v := range q.stack.Config.CustomSettings {
+		if !v.Secret || v.Store() != config.SecretStoreSecretManager {
+			continue
+		}
+
+		set := q.stack.Settings.Find(v.Name)
+		if set == nil || set.Value == "" {
+			continue
+		}
+
+		name := strings.ReplaceAll(fmt.Sprintf("%s-%s", q.stack.Config.Name, set.Name), "_", "-")
+		if err := q.storeSecret(project, name, set.Value); err != nil {
+			return fmt.Errorf("could not store secret setting (%s): %s", set.Name, err)
+		}
+
+		q.stack.AddSettingComplete(config.Setting{
+			Name:   set.Name,
+			Value:  fmt.Sprintf("projects/%s/secrets/%s/versions/latest", project, name),
+			Type:   "string",
+			Source: config.SourceComputed,
+		})
+	}
+
+	tfvarsfile := q.stack.TFvarsFilename()
+	if err := q.stack.TerraformFile(tfvarsfile); err != nil {
+		return fmt.Errorf("could not write %s: %s", tfvarsfile, err)
+	}
+
+	if err := q.stack.TerraformEnvFile(secretsfile); err != nil {
+		return fmt.Errorf("could not write %s: %s", secretsfile, err)
+	}
+
+	if err := q.stack.SummaryFile(summaryfile); err != nil {
+		return fmt.Errorf("could not write %s: %s", summaryfile, err)
+	}
+
+	return nil
+}
+
+// extraction is the outcome of extracting the blocks from some Terraform
+type extraction struct {
+	blocks terraform.Blocks
+	err    error
+}
+
+// terraformBlocks returns the blocks of the stack's Terraform, or none if it
+// has no Terraform. They are extracted once a run, and kept by path, as a
+// profile can point the stack at different Terraform.
+func (q *Queue) terraformBlocks() (terraform.Blocks, error) {
+	s := q.stack
+	if s.Config.PathTerraform == "" {
+		return nil, nil
+	}
+
+	cache, ok := q.Get(terraformBlocks).(map[string]extraction)
+	if !ok {
+		cache = map[string]extraction{}
+		q.Save(terraformBlocks, cache)
+	}
+
+	dir := filepath.Join(s.Config.Getwd(), s.Config.PathTerraform)
+	if e, ok := cache[dir]; ok {
+		return e.blocks, e.err
+	}
+
+	e := extraction{}
+	blocks, err := terraform.Extract(dir)
+	if err != nil {
+		e.err = err
+	} else {
+		e.blocks = *blocks
+	}
+	cache[dir] = e
+
+	return e.blocks, e.err
+}
+
+// storeSecret puts value in Secret Manager as the latest version of the
+// secret called name, creating the secret if this is the first run to use it
+func (q *Queue) storeSecret(project, name, value string) error {
+	exists, err := q.client.SecretExists(project, name)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return q.client.SecretVersionAdd(project, name, value)
+	}
+
+	return q.client.SecretCreate(project, name, value)
+}
+
 func (q *Queue) exitPage() (tea.Model, tea.Cmd) {
 	page := newPage("exit", []component{
-		newTextBlock("You've chosen to stop moving forward through DeployStack. \n"),
-		newTextBlock("If this was an error, you can try again by typing 'deploystack install' at the command prompt \n"),
+		newTextBlock(q.text(msgExitStopped) + "\n"),
+		newTextBlock(q.text(msgExitRetry) + "\n"),
 	})
 	page.showProgress = false
 	q.add(&page)
@@ -213,7 +671,7 @@ func (q *Queue) countTotalSteps() int {
 			total--
 		}
 
-		if v.getKey() == "endpage" {
+		if v.getKey() == "endpage" || v.getKey() == "costs" {
 			total--
 		}
 	}
@@ -253,13 +711,19 @@ func (q *Queue) ProcessConfig() error {
 	sets := s.Config.GetAuthorSettings()
 
 	for _, v := range sets {
+		if s.Overridden(v.Name) {
+			continue
+		}
+		v.Source = config.SourceAuthor
 		s.AddSettingComplete(v)
 
 	}
 
+	if s.Overridden("project_id") && !q.useProject("project_id") {
+		s.DeleteSetting("project_id")
+	}
+
 	project = s.GetSetting("project_id")
-	region := s.GetSetting("region")
-	zone := s.GetSetting("zone")
 	name = s.Config.Name
 
 	if name == "" {
@@ -268,55 +732,93 @@ func (q *Queue) ProcessConfig() error {
 			return err
 		}
 	}
-	s.AddSetting("stack_name", s.Config.Name)
+	s.AddSettingSource("stack_name", s.Config.Name, config.SourceComputed)
 
 	if s.Config.Project && len(project) == 0 {
 		p := config.Project{
 			Name:       "project_id",
-			UserPrompt: "Choose a project to use for this application.",
+			UserPrompt: q.text(msgProjectChoose),
 		}
 		s.Config.Projects.Items = append(s.Config.Projects.Items, p)
 	}
 
+	if notes, ok := q.Get(prefillNotes).([]string); ok && len(notes) > 0 {
+		newPrefillNotes(q, notes)
+	}
+
+	if sets, ok := q.Get(prefilled).(config.Settings); ok && len(sets) > 0 {
+		newPrefillSelector(q)
+	}
+
+	if len(s.Config.Profiles) > 0 && len(s.GetSetting(config.ProfileSetting)) == 0 {
+		q.Save(profileBase, s.Config)
+		newProfileSelector(q)
+	}
+
+	q.addConfigPages()
+
+	return err
+}
+
+// addConfigPages adds the pages for everything the config asks to collect.
+// It is separate from ProcessConfig so the pages can be rebuilt when a
+// profile changes the config part way through the flow.
+func (q *Queue) addConfigPages() {
+	s := q.stack
+
 	if len(s.Config.Projects.Items) > 0 {
 
 		currentProject := q.Get("currentProject").(string)
 
 		for _, v := range s.Config.Projects.Items {
-			s := newProjectSelector(v.Name, v.UserPrompt, currentProject, getProjects(q))
-			c := newProjectCreator(v.Name + projNewSuffix)
-			b := newBillingSelector(v.Name+billNewSuffix, getBillingAccounts(q), attachBilling)
+			if q.answered(v.Name) && q.useProject(v.Name) {
+				continue
+			}
+
+			s := newProjectSelector(q, v.Name, v.UserPrompt, currentProject, getProjects(q))
+			c := newProjectCreator(q, v.Name+projNewSuffix)
+			b := newBillingSelector(q, v.Name+billNewSuffix, getBillingAccounts(q), attachBilling)
 			q.add(&s, &c, &b)
 		}
 	}
 
 	if s.Config.BillingAccount {
-		b := newBillingSelector("billing_account", getBillingAccounts(q), nil)
-		b.list.Title = "Choose a billing account to use for with this application"
+		b := newBillingSelector(q, "billing_account", getBillingAccounts(q), nil)
+		b.list.Title = q.text(msgBillingChoose)
 		q.add(&b)
 	}
 
+	newPermissionsPage(q)
+
+	if services := stackServices(q); len(services) > 0 {
+		newServicesPage(q, services)
+	}
+
 	if s.Config.ConfigureGCEInstance {
 		newGCEInstance(q)
 	}
 
-	region = s.GetSetting("region")
+	region := s.GetSetting("region")
 	if s.Config.Region && len(region) == 0 {
+		start := len(q.models)
 		newRegion(q)
+		q.setCondition(s.Config.RegionWhen, q.models[start:]...)
 	}
 
-	zone = s.GetSetting("zone")
+	zone := s.GetSetting("zone")
 	if s.Config.Zone && len(zone) == 0 {
+		start := len(q.models)
 		newZone(q)
+		q.setCondition(s.Config.ZoneWhen, q.models[start:]...)
 	}
 
 	if s.Config.Domain {
+		start := len(q.models)
 		newDomain(q)
+		q.setCondition(s.Config.DomainWhen, q.models[start:]...)
 	}
 
 	newCustomPages(q)
-
-	return err
 }
 
 func (q *Queue) add(m ...QueueModel) {
@@ -15,11 +15,13 @@
 package tui
 
 import (
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/GoogleCloudPlatform/deploystack/config"
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/stretchr/testify/assert"
@@ -560,3 +562,503 @@ func TestQueuePrev(t *testing.T) {
 		})
 	}
 }
+
+func TestQueueCondition(t *testing.T) {
+	tests := map[string]struct {
+		value    string
+		forward  []string
+		backward []string
+	}{
+		"condition met": {
+			value:    "y",
+			forward:  []string{"test2", "test3", "test4"},
+			backward: []string{"test3", "test2", "test"},
+		},
+		"condition not met": {
+			value:    "n",
+			forward:  []string{"test3", "test4"},
+			backward: []string{"test3", "test"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getTestQueue(appTitle, "test")
+
+			first := newPage("test", nil)
+			second := newPage("test2", nil)
+			second.setCondition("enable_sql == y")
+			third := newPage("test3", nil)
+			fourth := newPage("test4", nil)
+			q.add(&first, &second, &third, &fourth)
+
+			q.stack.AddSetting("enable_sql", tc.value)
+			q.stack.AddSetting("test2", "stale")
+			q.Start()
+
+			for _, want := range tc.forward {
+				got, _ := q.next()
+				assert.Equal(t, want, got.(QueueModel).getKey())
+			}
+
+			for _, want := range tc.backward {
+				got, _ := q.prev()
+				assert.Equal(t, want, got.(QueueModel).getKey())
+			}
+
+			if tc.value == "n" && q.stack.GetSetting("test2") != "" {
+				t.Fatalf("setting for skipped page was not removed")
+			}
+		})
+	}
+}
+
+func TestQueueDeliverSettings(t *testing.T) {
+	tests := map[string]struct {
+		store   string
+		tfvars  string
+		secrets string
+	}{
+		"env": {
+			store:   config.SecretStoreEnv,
+			tfvars:  "project_id=\"ds-tester\"\n",
+			secrets: "export TF_VAR_db_password='hunter2'\n",
+		},
+		"secret manager": {
+			store:   config.SecretStoreSecretManager,
+			tfvars:  "db_password=\"projects/ds-tester/secrets/test-db-password/versions/latest\"\nproject_id=\"ds-tester\"\n",
+			secrets: "",
+		},
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("could not get working directory: %s", err)
+	}
+	defer os.Chdir(wd)
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := os.Chdir(t.TempDir()); err != nil {
+				t.Fatalf("could not change directory: %s", err)
+			}
+
+			q := getTestQueue(appTitle, "test")
+			q.stack.Config.Name = "test"
+			q.stack.Config.CustomSettings = config.Customs{
+				{Name: "db_password", Secret: true, SecretStore: tc.store},
+			}
+			q.stack.AddSetting("project_id", "ds-tester")
+
+			msg := validateCustom(q.stack.Config.CustomSettings[0])("hunter2", &q)()
+			assert.Equal(t, successMsg{unset: true}, msg)
+
+			if strings.Contains(q.getSettings(), "hunter2") {
+				t.Fatalf("secret was shown in the settings table")
+			}
+
+			if err := q.deliverSettings(); err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+
+			tfvars, err := os.ReadFile(q.stack.TFvarsFilename())
+			if err != nil {
+				t.Fatalf("could not read %s: %s", q.stack.TFvarsFilename(), err)
+			}
+			assert.Equal(t, tc.tfvars, string(tfvars))
+
+			summary, err := os.ReadFile(summaryfile)
+			if err != nil {
+				t.Fatalf("could not read %s: %s", summaryfile, err)
+			}
+			assert.NotContains(t, string(summary), "hunter2")
+
+			secrets, err := os.ReadFile(secretsfile)
+			if tc.secrets == "" {
+				assert.True(t, os.IsNotExist(err))
+				return
+			}
+			if err != nil {
+				t.Fatalf("could not read %s: %s", secretsfile, err)
+			}
+			assert.Equal(t, tc.secrets, string(secrets))
+		})
+	}
+}
+
+func TestQueueDeliverSettingsRerun(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("could not get working directory: %s", err)
+	}
+	defer os.Chdir(wd)
+
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("could not change directory: %s", err)
+	}
+
+	m := mock{secrets: map[string][]string{}}
+
+	for i, password := range []string{"hunter2", "hunter3"} {
+		stack := config.NewStack()
+		q := NewQueue(&stack, m)
+		q.stack.Config.Name = "test"
+		q.stack.Config.CustomSettings = config.Customs{
+			{Name: "db_password", Secret: true, SecretStore: config.SecretStoreSecretManager},
+		}
+		q.stack.AddSetting("project_id", "ds-tester")
+		validateCustom(q.stack.Config.CustomSettings[0])(password, &q)()
+
+		if err := q.deliverSettings(); err != nil {
+			t.Fatalf("run %d: expected no error, got: %s", i+1, err)
+		}
+	}
+
+	want := map[string][]string{"ds-tester/test-db-password": {"hunter2", "hunter3"}}
+	assert.Equal(t, want, m.secrets)
+}
+
+func TestQueueResolveDefault(t *testing.T) {
+	tests := map[string]struct {
+		custom   config.Custom
+		region   string
+		changeTo string
+		want     string
+		err      bool
+	}{
+		"text input": {
+			custom: config.Custom{Name: "bucket", Default: "{{ .project_id }}-{{ .region }}"},
+			region: "us-central1",
+			want:   "ds-tester-us-central1",
+		},
+		"changed answer": {
+			custom:   config.Custom{Name: "bucket", Default: "{{ .project_id }}-{{ .region }}"},
+			region:   "us-central1",
+			changeTo: "europe-west1",
+			want:     "ds-tester-europe-west1",
+		},
+		"picker": {
+			custom: config.Custom{Name: "location", Default: "{{ .region | upper | trunc 2 }}", Options: []string{"US", "EU"}},
+			region: "eu-west1",
+			want:   "EU",
+		},
+		"list": {
+			custom: config.Custom{Name: "zones", Type: "list", Default: "{{ .region }}-a,{{ .region }}-b"},
+			region: "us-east1",
+			want:   "us-east1-a,us-east1-b",
+		},
+		"broken": {
+			custom: config.Custom{Name: "bucket", Default: "{{ .project_id }"},
+			region: "us-central1",
+			want:   "",
+			err:    true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getTestQueue(appTitle, "test")
+			q.stack.Config.CustomSettings = config.Customs{tc.custom}
+
+			first := newPage("first", nil)
+			q.add(&first)
+			newCustomPages(&q)
+
+			q.stack.AddSetting("project_id", "ds-tester")
+			q.stack.AddSetting("region", tc.region)
+			q.Start()
+			q.next()
+
+			if tc.changeTo != "" {
+				q.prev()
+				q.stack.AddSetting("region", tc.changeTo)
+				q.next()
+			}
+
+			got := ""
+			var err error
+			switch m := q.models[q.current].(type) {
+			case *textInput:
+				got, err = m.ti.Placeholder, m.err
+			case *picker:
+				got = m.defaultValue
+				if m.err != nil {
+					err = m.err
+				}
+			case *listInput:
+				got, err = m.defaults, m.err
+			}
+
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.err, err != nil)
+		})
+	}
+}
+
+func TestQueueResolveDefaultOnce(t *testing.T) {
+	q := getTestQueue(appTitle, "test")
+	q.stack.Config.CustomSettings = config.Customs{
+		{Name: "bucket", Default: "{{ .project_id }}-{{ random 8 }}"},
+	}
+
+	first := newPage("first", nil)
+	q.add(&first)
+	newCustomPages(&q)
+
+	q.stack.AddSetting("project_id", "ds-tester")
+	q.Start()
+	q.next()
+
+	placeholder := func() string {
+		return q.models[q.current].(*textInput).ti.Placeholder
+	}
+
+	want := placeholder()
+	assert.Regexp(t, "^ds-tester-[a-z0-9]{8}$", want)
+
+	for i := 0; i < 3; i++ {
+		q.prev()
+		q.next()
+		assert.Equal(t, want, placeholder())
+	}
+
+	q.prev()
+	q.stack.AddSetting("project_id", "ds-other")
+	q.next()
+	assert.Regexp(t, "^ds-other-[a-z0-9]{8}$", placeholder())
+}
+
+func TestQueueApplyProfile(t *testing.T) {
+	tests := map[string]struct {
+		profile string
+		keys    []string
+		nodes   string
+		options []string
+		region  string
+		err     bool
+	}{
+		"prod": {
+			profile: "prod",
+			keys:    []string{"profile", "nodes", "machine"},
+			nodes:   "5",
+			options: []string{"c", "d"},
+			region:  "us-east1",
+		},
+		"changed to dev": {
+			profile: "dev",
+			keys:    []string{"profile", "nodes", "machine"},
+			nodes:   "1",
+			options: []string{"a", "b"},
+			region:  "",
+		},
+		"unknown": {
+			profile: "qa",
+			err:     true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getTestQueue(appTitle, "test")
+			q.stack.Config = config.Config{
+				Name: "test",
+				CustomSettings: config.Customs{
+					{Name: "nodes", Description: "Nodes", Default: "3"},
+					{Name: "machine", Description: "Machine", Options: []string{"a", "b"}},
+				},
+				Profiles: config.Profiles{
+					{Name: "dev", Defaults: map[string]string{"nodes": "1"}},
+					{
+						Name:           "prod",
+						AuthorSettings: config.Settings{{Name: "region", Value: "us-east1"}},
+						Defaults:       map[string]string{"nodes": "5"},
+						Options:        map[string][]string{"machine": {"c", "d"}},
+					},
+				},
+			}
+
+			if err := q.ProcessConfig(); err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+			assert.Equal(t, config.ProfileSetting, q.currentKey())
+
+			// Always start from prod so that switching away is covered.
+			assert.Equal(t, successMsg{}, applyProfile("prod", &q)())
+
+			msg := applyProfile(tc.profile, &q)()
+			if tc.err {
+				if _, ok := msg.(errMsg); !ok {
+					t.Fatalf("expected an error, got: %v", msg)
+				}
+				return
+			}
+			assert.Equal(t, successMsg{}, msg)
+
+			keys := []string{}
+			for _, v := range q.models {
+				keys = append(keys, v.getKey())
+			}
+			assert.Equal(t, tc.keys, keys)
+
+			assert.Equal(t, tc.profile, q.stack.GetSetting(config.ProfileSetting))
+			assert.Equal(t, tc.region, q.stack.GetSetting("region"))
+			assert.Equal(t, tc.nodes, q.Model("nodes").(*textInput).ti.Placeholder)
+
+			options := []string{}
+			for _, v := range q.Model("machine").(*picker).preProcessor().([]list.Item) {
+				options = append(options, v.(item).value)
+			}
+			assert.Equal(t, tc.options, options)
+		})
+	}
+}
+
+func getPrefillQueue() Queue {
+	q := getTestQueue(appTitle, "test")
+	q.stack.Config = config.Config{
+		Name:          "test",
+		Region:        true,
+		RegionType:    "compute",
+		RegionDefault: "us-central1",
+		CustomSettings: config.Customs{
+			{Name: "nodes", Description: "Nodes", Type: "number", Default: "3"},
+			{Name: "machine", Description: "Machine", Options: []string{"a", "b"}},
+			{Name: "zones", Description: "Zones", Type: "list(string)", Default: "us-central1-a"},
+			{Name: "bucket", Description: "Bucket", Default: "assets"},
+		},
+	}
+	q.Save(prefilled, config.Settings{
+		{Name: "region", Value: "us-east1", Type: "string"},
+		{Name: "nodes", Value: "5", Type: "number"},
+		{Name: "machine", Value: "b", Type: "string"},
+		{Name: "zones", List: []string{"us-east1-b", "us-east1-c"}, Type: "list"},
+	})
+	q.Save(prefillFile, "terraform.tfvars")
+
+	return q
+}
+
+func TestQueuePrefill(t *testing.T) {
+	tests := map[string]struct {
+		mode     string
+		asked    []string
+		defaults map[string]string
+		settings map[string]string
+	}{
+		"resume": {
+			mode:     prefillResume,
+			asked:    []string{"bucket"},
+			settings: map[string]string{"region": "us-east1", "nodes": "5", "machine": "b"},
+		},
+		"review": {
+			mode:     prefillReview,
+			asked:    []string{"region", "nodes", "machine", "zones", "bucket"},
+			defaults: map[string]string{"region": "us-east1", "nodes": "5", "machine": "b", "zones": "us-east1-b,us-east1-c", "bucket": "assets"},
+			settings: map[string]string{"region": "", "nodes": "", "machine": ""},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getPrefillQueue()
+
+			if err := q.ProcessConfig(); err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+			assert.Equal(t, "prefill", q.currentKey())
+			assert.Equal(t, successMsg{}, applyPrefill(tc.mode, &q)())
+
+			asked := []string{}
+			defaults := map[string]string{}
+			for {
+				_, cmd := q.next()
+				if q.current >= len(q.models) {
+					break
+				}
+				assert.NotNil(t, cmd)
+
+				key := q.currentKey()
+				asked = append(asked, key)
+
+				switch m := q.models[q.current].(type) {
+				case *textInput:
+					defaults[key] = m.ti.Placeholder
+				case *listInput:
+					defaults[key] = m.defaults
+				case *picker:
+					defaults[key] = m.defaultValue
+				}
+			}
+			assert.Equal(t, tc.asked, asked)
+
+			for k, v := range tc.defaults {
+				assert.Equal(t, v, defaults[k], k)
+			}
+
+			for k, v := range tc.settings {
+				assert.Equal(t, v, q.stack.GetSetting(k), k)
+			}
+
+			if tc.mode == prefillResume {
+				assert.Equal(t, []string{"us-east1-b", "us-east1-c"}, q.stack.Settings.Find("zones").List)
+			}
+		})
+	}
+}
+
+func TestQueueLoadPrefill(t *testing.T) {
+	dir := t.TempDir()
+	content := "region=\"us-east1\"\nnodes=\"five\"\nmachine=\"z\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "terraform.tfvars"), []byte(content), 0o644); err != nil {
+		t.Fatalf("could not write tfvars: %s", err)
+	}
+
+	q := getPrefillQueue()
+	q.Save(prefilled, nil)
+	q.stack.Config.Setwd(dir)
+
+	q.loadPrefill()
+
+	assert.Equal(t, filepath.Join(dir, "terraform.tfvars"), q.Get(prefillFile))
+	assert.Equal(t, config.Settings{{Name: "region", Value: "us-east1", Type: "string"}}, q.Get(prefilled))
+	assert.Len(t, q.Get(prefillNotes), 2)
+
+	if err := q.ProcessConfig(); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	keys := []string{}
+	for _, v := range q.models[:2] {
+		keys = append(keys, v.getKey())
+	}
+	assert.Equal(t, []string{"prefillnotes", "prefill"}, keys)
+}
+
+func TestQueueTerraformBlocks(t *testing.T) {
+	dir := t.TempDir()
+	tf := filepath.Join(dir, "main.tf")
+	if err := os.WriteFile(tf, []byte(`resource "google_storage_bucket" "assets" { name = "assets" }`), 0o644); err != nil {
+		t.Fatalf("could not write terraform: %s", err)
+	}
+
+	q := getTestQueue(appTitle, "test")
+	q.stack.Config.Setwd(dir)
+
+	got, err := q.terraformBlocks()
+	assert.Nil(t, err)
+	assert.Len(t, got, 0)
+
+	q.stack.Config.PathTerraform = "."
+	got, err = q.terraformBlocks()
+	assert.Nil(t, err)
+	assert.Len(t, got, 1)
+
+	// The blocks are extracted once, so they don't change with the files
+	if err := os.Remove(tf); err != nil {
+		t.Fatalf("could not remove terraform: %s", err)
+	}
+
+	again, err := q.terraformBlocks()
+	assert.Nil(t, err)
+	assert.Equal(t, got, again)
+}
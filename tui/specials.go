@@ -16,12 +16,16 @@ package tui
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"cloud.google.com/go/domains/apiv1beta1/domainspb"
 	"github.com/GoogleCloudPlatform/deploystack/config"
 	"github.com/GoogleCloudPlatform/deploystack/gcloud"
+	"github.com/GoogleCloudPlatform/deploystack/terraform"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -30,34 +34,170 @@ var (
 	billNewSuffix = "_new_billing_selector"
 )
 
-func newProjectCreator(key string) textInput {
-	r := newTextInput("Create New Project",
+func newProjectCreator(q *Queue, key string) textInput {
+	r := newTextInput(q.text(msgProjectCreate),
 		"",
 		key,
-		"Checking if project can be created",
+		q.text(msgProjectCreating),
 	)
 	r.addPostProcessor(createProject)
 
-	r.addContent("Project IDs are immutable and can be set only during project ")
-	r.addContent("creation. They must start with a lowercase letter and can have ")
-	r.addContent("lowercase ASCII letters, digits or hyphens. ")
-	r.addContent("Project IDs must be between 6 and 30 characters. ")
+	r.addContent(q.text(msgProjectIDRules))
 	r.addContent("\n\n")
-	r.addContent(textInputDefaultStyle.Render("Please enter a new project name to create:"))
+	r.addContent(textInputDefaultStyle.Render(q.text(msgProjectNamePrompt)))
 	return r
 }
 
-func newProjectSelector(key, listLabel, currentProject string, preProcessor tea.Cmd) picker {
+func newProjectSelector(q *Queue, key, listLabel, currentProject string, preProcessor tea.Cmd) picker {
 
-	result := newPicker(listLabel, "Retrieving Projects", key, currentProject, preProcessor)
-	create := item{"Create New Project", ""}
+	result := newPicker(listLabel, q.text(msgProjectsRetrieving), key, currentProject, preProcessor)
+	create := item{q.text(msgProjectCreate), ""}
 	result.list.InsertItem(0, create)
 	result.addPostProcessor(processProjectSelection)
 	return result
 }
 
-func newBillingSelector(key string, preProcessor tea.Cmd, postProccessor func(string, *Queue) tea.Cmd) picker {
-	result := newPicker("Choose an account to use to enable billing on the new project", "Retrieving Billing Accounts", key, "", preProcessor)
+func newProfileSelector(q *Queue) {
+	p := newPicker(q.text(msgProfileChoose), "", config.ProfileSetting, q.stack.Config.ProfileDefault, getProfiles(q))
+	p.addPostProcessor(applyProfile)
+	q.add(&p)
+}
+
+func newPrefillNotes(q *Queue, notes []string) {
+	content := []component{
+		newTextBlock(titleStyle.Render(q.text(msgPrefillNotesTitle))),
+		newTextBlock(q.text(msgPrefillNotes) + "\n"),
+	}
+	for _, v := range notes {
+		content = append(content, newTextBlock(fmt.Sprintf(" * %s", v)))
+	}
+
+	p := newPage("prefillnotes", content)
+	p.showProgress = false
+	q.add(&p)
+}
+
+// stackServices lists the services the stack's Terraform needs, followed by
+// any extra ones the config asks for
+func stackServices(q *Queue) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	add := func(services ...string) {
+		for _, v := range services {
+			if !seen[v] {
+				seen[v] = true
+				result = append(result, v)
+			}
+		}
+	}
+
+	blocks, err := q.terraformBlocks()
+	resources, rerr := terraform.NewGCPResources()
+	if err == nil && rerr == nil {
+		add(resources.Services(blocks)...)
+	}
+
+	add(q.stack.Config.Services...)
+
+	return result
+}
+
+// newServicesPage checks that the services the stack needs are enabled in the
+// chosen project, and enables the ones that aren't.
+func newServicesPage(q *Queue, services []string) {
+	progress := newServiceProgress(q.texts, services)
+
+	p := newPage("services", []component{
+		newTextBlock(titleStyle.Render(q.text(msgServicesTitle))),
+		progress,
+	})
+	p.state = "querying"
+	p.spinnerLabel = q.text(msgServicesEnabling)
+	p.spinner = spinner.New()
+	p.spinner.Spinner = spinnerType
+	p.addPreProcessor(enableServices(q, progress))
+	q.add(&p)
+}
+
+// newPermissionsPage checks that the current user has the permissions the
+// stack needs on the chosen project, and lists the roles that would grant
+// any that are missing. Stacks that need no permissions DeployStack knows
+// about don't get the page.
+func newPermissionsPage(q *Queue) {
+	blocks, err := q.terraformBlocks()
+	if err != nil {
+		return
+	}
+
+	resources, err := terraform.NewGCPResources()
+	if err != nil {
+		return
+	}
+
+	permissions, err := terraform.NewPermissions()
+	if err != nil {
+		return
+	}
+
+	needed := permissions.Needed(resources.APICalls(blocks))
+	if len(needed) == 0 {
+		return
+	}
+
+	report := newPermissionReport(q.texts, permissions, needed)
+
+	p := newPage("permissions", []component{
+		newTextBlock(titleStyle.Render(q.text(msgPermissionsTitle))),
+		report,
+	})
+	p.state = "querying"
+	p.spinnerLabel = q.text(msgPermissionsChecking)
+	p.spinner = spinner.New()
+	p.spinner.Spinner = spinnerType
+	p.addPreProcessor(checkPermissions(q, report))
+	q.add(&p)
+}
+
+// newCostPage shows what the stack is expected to cost a month, once the
+// settings that change it have been collected. Stacks with nothing to price
+// don't get the page.
+func newCostPage(q *Queue) {
+	blocks, err := q.terraformBlocks()
+	if err != nil || len(blocks) == 0 {
+		return
+	}
+
+	pricing, err := terraform.NewPricing()
+	if err != nil {
+		return
+	}
+
+	estimate := newCostEstimate(q.stack, blocks, pricing)
+	if e := estimate.estimate(); len(e.Items) == 0 && len(e.Unpriced) == 0 {
+		return
+	}
+
+	p := newPage("costs", []component{
+		newTextBlock(titleStyle.Render(q.text(msgCostTitle))),
+		estimate,
+	})
+	q.add(&p)
+}
+
+func newPrefillSelector(q *Queue) {
+	file, _ := q.Get(prefillFile).(string)
+	label := q.textf(msgPrefillFound, filepath.Base(file))
+
+	p := newPicker(label, "", "prefill", prefillResume, getPrefillOptions(q))
+	p.omitFromSettings = true
+	p.list.SetShowStatusBar(false)
+	p.list.SetShowFilter(false)
+	p.addPostProcessor(applyPrefill)
+	q.add(&p)
+}
+
+func newBillingSelector(q *Queue, key string, preProcessor tea.Cmd, postProccessor func(string, *Queue) tea.Cmd) picker {
+	result := newPicker(q.text(msgBillingNewProject), q.text(msgBillingRetrieving), key, "", preProcessor)
 	result.postProcessor = postProccessor
 	return result
 }
@@ -73,26 +213,44 @@ func newYesOrNo(q *Queue, listLabel, key string, defaultNo bool, postProcessor f
 	return p
 }
 
-func newCustom(c config.Custom) QueueModel {
+func newCustom(q *Queue, c config.Custom) QueueModel {
+	switch config.BaseType(c.Type) {
+	case "list", "map":
+		l := newListInput(c.Description, c.Default, c.Name, config.BaseType(c.Type))
+		l.addPostProcessor(validateCustom(c))
+		if config.IsTemplate(c.Default) {
+			l.defaultTemplate = c.Default
+		}
+		return &l
+	}
+
 	r := newTextInput(c.Description,
 		c.Default,
 		c.Name,
-		"validating",
+		q.text(msgValidating),
 	)
 
 	switch c.Validation {
 	case validationPhoneNumber:
-		r.spinnerLabel = "Validating phone number"
-		r.addPostProcessor(validatePhoneNumber)
+		r.spinnerLabel = q.text(msgValidatingPhone)
 	case validationYesOrNo:
-		r.spinnerLabel = "Validating yes or no"
-		r.addPostProcessor(validateYesOrNo)
+		r.spinnerLabel = q.text(msgValidatingYesNo)
 	case validationInteger:
-		r.spinnerLabel = "Validating integer"
-		r.addPostProcessor(validateInteger)
+		r.spinnerLabel = q.text(msgValidatingInteger)
+	}
+
+	if config.IsTemplate(c.Default) {
+		r.defaultTemplate = c.Default
 	}
 
-	if c.PrependProject {
+	if c.Secret {
+		r.ti.EchoMode = textinput.EchoPassword
+		r.ti.EchoCharacter = '•'
+	}
+
+	if c.Validation != "" || c.Rule != nil || c.Secret || config.BaseType(c.Type) != "string" {
+		r.addPostProcessor(validateCustom(c))
+	} else if c.PrependProject {
 		r.addPostProcessor(prependProject)
 	}
 
@@ -103,10 +261,10 @@ func newDomain(q *Queue) {
 	contact := gcloud.ContactData{}
 
 	t := newTextInput(
-		"Enter a domain you wish to purchase and use for this application",
+		q.text(msgDomainPrompt),
 		"",
 		"domain",
-		"Checking Domain Availability",
+		q.text(msgDomainChecking),
 	)
 	t.postProcessor = validateDomain
 	q.add(&t)
@@ -119,50 +277,50 @@ func newDomain(q *Queue) {
 	}{
 		{
 			Name:         "domain_email",
-			Description:  "Enter an email address",
+			Description:  q.text(msgDomainEmail),
 			DefaultValue: "person@example.com",
 		},
 
 		{
 			Name:         "domain_phone",
-			Description:  "Enter a phone number. (Please enter with country code - +1 555 555 5555 for US for example)",
+			Description:  q.text(msgDomainPhone),
 			DefaultValue: "+14155551234",
 			Validator:    validatePhoneNumber,
 		},
 
 		{
 			Name:         "domain_country",
-			Description:  "Enter a country code",
+			Description:  q.text(msgDomainCountry),
 			DefaultValue: "US",
 		},
 
 		{
 			Name:         "domain_postalcode",
-			Description:  "Enter a postal code",
+			Description:  q.text(msgDomainPostalCode),
 			DefaultValue: "94502",
 		},
 
 		{
 			Name:         "domain_state",
-			Description:  "Enter a state or administrative area",
+			Description:  q.text(msgDomainState),
 			DefaultValue: "CA",
 		},
 
 		{
 			Name:         "domain_city",
-			Description:  "Enter a city",
+			Description:  q.text(msgDomainCity),
 			DefaultValue: "San Francisco",
 		},
 
 		{
 			Name:         "domain_address",
-			Description:  "Enter an address",
+			Description:  q.text(msgDomainAddress),
 			DefaultValue: "345 Spear Street",
 		},
 
 		{
 			Name:         "domain_name",
-			Description:  "Enter name",
+			Description:  q.text(msgDomainName),
 			DefaultValue: "Googler",
 		},
 	}
@@ -187,8 +345,8 @@ func newDomain(q *Queue) {
 		info := q.Get("domainInfo").(*domainspb.RegisterParameters)
 
 		if info.YearlyPrice != nil {
-			msg := fmt.Sprintf(
-				"Cost for %s will be %s.  %s",
+			msg := q.textf(
+				msgDomainCost,
 				domain,
 				purchaseStyle.Render(
 					fmt.Sprintf(
@@ -197,7 +355,7 @@ func newDomain(q *Queue) {
 						info.YearlyPrice.CurrencyCode,
 					),
 				),
-				textStyle.Render("Continue?"),
+				textStyle.Render(q.text(msgContinue)),
 			)
 			p := q.models[q.current]
 			p.clearContent()
@@ -207,12 +365,12 @@ func newDomain(q *Queue) {
 
 	dy := newYesOrNo(
 		q,
-		"Buying a domain is not reversible, saying 'y' will incur a charge.",
+		q.text(msgDomainConsent),
 		"domain_consent",
 		false,
 		nil,
 	)
-	dy.spinnerLabel = "Attempting to register domain"
+	dy.spinnerLabel = q.text(msgDomainRegistering)
 	dy.addPreView(f)
 	dy.addPostProcessor(registerDomain)
 	q.add(&dy)
@@ -221,6 +379,21 @@ func newDomain(q *Queue) {
 func newCustomPages(q *Queue) {
 	for _, v := range q.stack.Config.CustomSettings {
 		temp := q.stack.GetSetting(v.Name)
+		v.Description = v.LocalDescription(q.stack.Locale)
+
+		// Booleans are a yes or no question
+		if config.BaseType(v.Type) == "bool" && len(v.Options) == 0 {
+			v.Options = []string{"true|" + q.text(msgYes), "false|" + q.text(msgNo)}
+			if b, err := config.NewSettingTyped(v.Name, v.Type, v.Default); err == nil {
+				v.Default = b.Value
+			}
+		}
+
+		if v.Picker != "" {
+			p := newLocationPicker(q, v)
+			q.add(&p)
+			continue
+		}
 
 		if len(v.Options) > 0 {
 
@@ -243,41 +416,77 @@ func newCustomPages(q *Queue) {
 			}
 
 			pickerPage := newPicker(v.Description, "", v.Name, v.Default, f(items))
-			if v.PrependProject {
+			if config.IsTemplate(v.Default) {
+				pickerPage.defaultTemplate = v.Default
+			}
+			switch {
+			case v.Validation != "" || v.Rule != nil || v.Secret || config.BaseType(v.Type) != "string":
+				pickerPage.addPostProcessor(validateCustom(v))
+			case v.PrependProject:
 				pickerPage.addPostProcessor(prependProject)
 			}
+			pickerPage.setCondition(v.When)
 			q.add(&pickerPage)
 			continue
 		}
 
 		if len(temp) < 1 {
-			tiPage := newCustom(v)
+			tiPage := newCustom(q, v)
+			tiPage.setCondition(v.When)
 			q.add(tiPage)
 		}
 
 	}
 }
 
+// newLocationPicker asks for a custom setting with the region or zone picker
+func newLocationPicker(q *Queue, c config.Custom) picker {
+	label, listLabel, defaultValue := c.Description, q.text(msgRegions), c.Default
+	preProcessor := getRegions(q)
+
+	switch c.Picker {
+	case config.PickerZone:
+		if label == "" {
+			label = q.text(msgZone)
+		}
+		listLabel = q.text(msgZones)
+		preProcessor = getZonesIn(q, q.stack.Config.RegionFor(c.Name))
+	default:
+		if label == "" {
+			label = q.text(msgRegion)
+		}
+		if defaultValue == "" {
+			defaultValue = q.stack.Config.RegionDefault
+		}
+	}
+
+	p := newPicker(label, listLabel, c.Name, defaultValue, preProcessor)
+	if config.IsTemplate(c.Default) {
+		p.defaultTemplate = c.Default
+	}
+	if c.Validation != "" || c.Rule != nil {
+		p.addPostProcessor(validateCustom(c))
+	}
+	p.setCondition(c.When)
+
+	return p
+}
+
 func newGCEInstance(q *Queue) {
-	r := newPicker("Do you want to accept the default configuration? (Yes or No)", "", "gce-use-defaults", "", getYesOrNo(q))
+	r := newPicker(q.text(msgGCEDefaults), "", "gce-use-defaults", "", getYesOrNo(q))
 	r.omitFromSettings = true
 	r.list.SetShowFilter(false)
 	r.list.SetShowHelp(false)
 	r.list.SetShowStatusBar(false)
 	r.addPostProcessor(validateGCEDefault)
-	r.addContent(textStyle.Bold(true).Render("Configure a Compute Engine Instance"))
+	r.addContent(textStyle.Bold(true).Render(q.text(msgGCETitle)))
 	r.addContent("\n\n")
 
-	m := `Let's walk through configuring a Compute Engine Instance (Virtual Machine).
-you can either accept a default configuration with settings that work for
-trying out most use cases, or hand configure key settings.
-	`
-
-	r.addContent(m)
+	r.addContent(q.text(msgGCEIntro))
 	q.add(&r)
 
 	basename := q.stack.GetSetting("basename")
-	name := newTextInput("Enter the name of the instance",
+	name := newTextInput(q.text(msgGCEName),
 		fmt.Sprintf("%s-instance", basename),
 		"instance-name",
 		"",
@@ -289,7 +498,7 @@ trying out most use cases, or hand configure key settings.
 	newMachineTypeManager(q)
 	newDiskImageManager(q)
 
-	ds := newTextInput("Enter the size of the boot disk you want in GB",
+	ds := newTextInput(q.text(msgGCEDiskSize),
 		"100",
 		"instance-disksize",
 		"",
@@ -297,12 +506,12 @@ trying out most use cases, or hand configure key settings.
 	ds.addPostProcessor(validateInteger)
 	q.add(&ds)
 
-	dt := newPicker("Pick the type of the boot disk you want", "", "instance-disktype", gcloud.DefaultDiskType, getDiskTypes(q))
+	dt := newPicker(q.text(msgGCEDiskType), "", "instance-disktype", gcloud.DefaultDiskType, getDiskTypes(q))
 	q.add(&dt)
 
 	dy := newYesOrNo(
 		q,
-		"Do you want this to be a webserver (Expose ports 80 & 443)?",
+		q.text(msgGCEWebserver),
 		"instance-webserver",
 		false,
 		validateGCEConfiguration,
@@ -311,55 +520,50 @@ trying out most use cases, or hand configure key settings.
 }
 
 func newRegion(q *Queue) {
-	r := newPicker("Pick a region", "Retrieving regions", "region", q.stack.Config.RegionDefault, getRegions(q))
+	r := newPicker(q.text(msgRegion), q.text(msgRegions), "region", q.stack.Config.RegionDefault, getRegions(q))
 	q.add(&r)
 }
 
 func newZone(q *Queue) {
-	z := newPicker("Pick a zone", "Retrieving zones", "zone", gcloud.DefaultZone, getZones(q))
+	z := newPicker(q.text(msgZone), q.text(msgZones), "zone", gcloud.DefaultZone, getZones(q))
 	q.add(&z)
 }
 
 func newMachineTypeManager(q *Queue) {
-	p := newPicker("Pick a Machine Type Family", "Retrieving machine type families", "instance-machine-type-family", gcloud.DefaultMachineFamily, getMachineTypeFamilies(q))
-	p.addContent(textStyle.Bold(true).Render("Configure a Compute Engine Instance"))
+	p := newPicker(q.text(msgGCEMachineFamily), q.text(msgGCEMachineFamilies), "instance-machine-type-family", gcloud.DefaultMachineFamily, getMachineTypeFamilies(q))
+	p.addContent(textStyle.Bold(true).Render(q.text(msgGCETitle)))
 	p.addContent("\n\n")
-	p.addContent("There are a large number of machine types to choose from. For more information \n")
-	p.addContent("please refer to the following link for more information about Machine types: \n")
+	p.addContent(q.text(msgGCEMachineTypesInfo))
 	p.addContent(url.Render("https://cloud.google.com/compute/docs/machine-types"))
 	q.add(&p)
 
-	p2 := newPicker("Pick a Machine Type", "Retrieving machine types", "instance-machine-type", gcloud.DefaultMachineType, getMachineTypes(q))
-	p2.addContent(textStyle.Bold(true).Render("Configure a Compute Engine Instance"))
+	p2 := newPicker(q.text(msgGCEMachineType), q.text(msgGCEMachineTypes), "instance-machine-type", gcloud.DefaultMachineType, getMachineTypes(q))
+	p2.addContent(textStyle.Bold(true).Render(q.text(msgGCETitle)))
 	p2.addContent("\n\n")
-	p2.addContent("There are a large number of machine types to choose from. For more information \n")
-	p2.addContent("please refer to the following link for more information about Machine types: \n")
+	p2.addContent(q.text(msgGCEMachineTypesInfo))
 	p2.addContent(url.Render("https://cloud.google.com/compute/docs/machine-types"))
 	q.add(&p2)
 }
 
 func newDiskImageManager(q *Queue) {
-	p := newPicker("Pick an operating system", "Retrieving operating systems", "instance-image-project", gcloud.DefaultImageProject, getDiskProjects(q))
-	p.addContent(textStyle.Bold(true).Render("Configure a Compute Engine Instance"))
+	p := newPicker(q.text(msgGCEImageProject), q.text(msgGCEImageProjects), "instance-image-project", gcloud.DefaultImageProject, getDiskProjects(q))
+	p.addContent(textStyle.Bold(true).Render(q.text(msgGCETitle)))
 	p.addContent("\n\n")
-	p.addContent("There are a large number of machine images to choose from. For more information \n")
-	p.addContent("please refer to the following link for more information about Machine images: \n")
+	p.addContent(q.text(msgGCEImagesInfo))
 	p.addContent(url.Render("https://cloud.google.com/compute/docs/images"))
 	q.add(&p)
 
-	p2 := newPicker("Pick a disk family", "Retrieving disk family", "instance-image-family", gcloud.DefaultImageFamily, getImageFamilies(q))
-	p2.addContent(textStyle.Bold(true).Render("Configure a Compute Engine Instance"))
+	p2 := newPicker(q.text(msgGCEImageFamily), q.text(msgGCEImageFamilies), "instance-image-family", gcloud.DefaultImageFamily, getImageFamilies(q))
+	p2.addContent(textStyle.Bold(true).Render(q.text(msgGCETitle)))
 	p2.addContent("\n\n")
-	p2.addContent("There are a large number of machine images to choose from. For more information \n")
-	p2.addContent("please refer to the following link for more information about Machine images: \n")
+	p2.addContent(q.text(msgGCEImagesInfo))
 	p2.addContent(url.Render("https://cloud.google.com/compute/docs/images"))
 	q.add(&p2)
 
-	p3 := newPicker("Pick a disk image", "Retrieving disk image", "instance-image", "", getImageDisks(q))
-	p3.addContent(textStyle.Bold(true).Render("Configure a Compute Engine Instance"))
+	p3 := newPicker(q.text(msgGCEImage), q.text(msgGCEImages), "instance-image", "", getImageDisks(q))
+	p3.addContent(textStyle.Bold(true).Render(q.text(msgGCETitle)))
 	p3.addContent("\n\n")
-	p3.addContent("There are a large number of machine images to choose from. For more information \n")
-	p3.addContent("please refer to the following link for more information about Machine images: \n")
+	p3.addContent(q.text(msgGCEImagesInfo))
 	p3.addContent(url.Render("https://cloud.google.com/compute/docs/images"))
 	q.add(&p3)
 }
@@ -15,10 +15,14 @@
 package tui
 
 import (
+	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/GoogleCloudPlatform/deploystack/config"
+	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"google.golang.org/api/cloudbilling/v1"
 )
@@ -37,7 +41,7 @@ func TestNewProjectCreator(t *testing.T) {
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			q := getTestQueue(appTitle, "test")
-			out := newProjectCreator(tc.key)
+			out := newProjectCreator(&q, tc.key)
 			q.add(&out)
 
 			got := out.View()
@@ -77,7 +81,7 @@ func TestNewProjectSelector(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			q := getTestQueue(appTitle, "test")
 
-			out := newProjectSelector(tc.key, tc.listLabel, "", getProjects(&q))
+			out := newProjectSelector(&q, tc.key, tc.listLabel, "", getProjects(&q))
 			q.add(&out)
 
 			if tc.update {
@@ -146,9 +150,9 @@ func TestNewBillingSelector(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			q := getTestQueue(appTitle, "test")
 
-			out := newBillingSelector(tc.key, getBillingAccounts(&q), nil)
+			out := newBillingSelector(&q, tc.key, getBillingAccounts(&q), nil)
 			q.add(&out)
-			p := newBillingSelector("dummy", getBillingAccounts(&q), nil)
+			p := newBillingSelector(&q, "dummy", getBillingAccounts(&q), nil)
 			p.spinnerLabel = "dummy"
 			q.add(&p)
 
@@ -234,8 +238,8 @@ func TestNewProjectFlow(t *testing.T) {
 			key := "project_id"
 
 			q := getTestQueue(appTitle, "test")
-			p1 := newProjectSelector(key, "", "", getProjects(&q))
-			p2 := newProjectCreator(key + projNewSuffix)
+			p1 := newProjectSelector(&q, key, "", "", getProjects(&q))
+			p2 := newProjectCreator(&q, key+projNewSuffix)
 			p3 := newPage("dummy", []component{})
 			q.add(&p1, &p2, &p3)
 
@@ -303,7 +307,7 @@ func TestNewCustom(t *testing.T) {
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			q := getTestQueue(appTitle, "test")
-			out := newCustom(tc.c)
+			out := newCustom(&q, tc.c)
 			q.add(out)
 
 			got := out.View()
@@ -474,3 +478,226 @@ func TestCustomPages(t *testing.T) {
 		})
 	}
 }
+
+func TestNewLocationPicker(t *testing.T) {
+	tests := map[string]struct {
+		custom       config.Custom
+		regionType   string
+		title        string
+		defaultValue string
+		options      int
+	}{
+		"region": {
+			custom:       config.Custom{Name: "secondary_region", Description: "Where should the replica run?", Picker: config.PickerRegion},
+			title:        "Where should the replica run?",
+			defaultValue: "us-central1",
+			options:      35,
+		},
+		"regiondefault": {
+			custom:       config.Custom{Name: "secondary_region", Default: "europe-west1", Picker: config.PickerRegion},
+			title:        text(msgRegion),
+			defaultValue: "europe-west1",
+			options:      35,
+		},
+		"zone": {
+			custom:  config.Custom{Name: "secondary_zone", Picker: config.PickerZone},
+			title:   text(msgZone),
+			options: 106,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getTestQueue(appTitle, "test")
+			q.stack.Config.RegionDefault = "us-central1"
+			q.stack.Config.CustomSettings = config.Customs{tc.custom}
+
+			newCustomPages(&q)
+
+			p, ok := q.Model(tc.custom.Name).(*picker)
+			if !ok {
+				t.Fatalf("expected a picker for %s, got %T", tc.custom.Name, q.Model(tc.custom.Name))
+			}
+
+			if tc.title != p.list.Title {
+				t.Fatalf("title - want '%s' got '%s'", tc.title, p.list.Title)
+			}
+
+			if tc.defaultValue != p.defaultValue {
+				t.Fatalf("default - want '%s' got '%s'", tc.defaultValue, p.defaultValue)
+			}
+
+			items, ok := p.preProcessor().([]list.Item)
+			if !ok {
+				t.Fatalf("expected the choices from the preprocessor")
+			}
+			if tc.options != len(items) {
+				t.Fatalf("options - want '%d' got '%d'", tc.options, len(items))
+			}
+		})
+	}
+}
+
+func TestStackServices(t *testing.T) {
+	dir := t.TempDir()
+	tf := `
+variable "project_id" {
+  type = string
+}
+
+resource "google_sql_database_instance" "main" {
+  name = "main"
+}
+
+resource "google_compute_network" "main" {
+  name = "main"
+}
+
+resource "random_id" "suffix" {
+  byte_length = 2
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(tf), 0o644); err != nil {
+		t.Fatalf("could not write terraform: %s", err)
+	}
+
+	tests := map[string]struct {
+		path     string
+		services []string
+		want     []string
+	}{
+		"terraform": {
+			path: ".",
+			want: []string{"compute.googleapis.com", "sqladmin.googleapis.com"},
+		},
+		"extra": {
+			path:     ".",
+			services: []string{"redis.googleapis.com", "compute.googleapis.com"},
+			want:     []string{"compute.googleapis.com", "sqladmin.googleapis.com", "redis.googleapis.com"},
+		},
+		"noterraform": {
+			services: []string{"redis.googleapis.com"},
+			want:     []string{"redis.googleapis.com"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getTestQueue(appTitle, "test")
+			q.stack.Config.Setwd(dir)
+			q.stack.Config.PathTerraform = tc.path
+			q.stack.Config.Services = tc.services
+
+			got := stackServices(&q)
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("want %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestNewServicesPage(t *testing.T) {
+	q := getTestQueue(appTitle, "test")
+	q.stack.Config = config.Config{Name: "test", Services: []string{"sqladmin.googleapis.com"}}
+	q.stack.AddSetting("project_id", "test-project")
+	q.InitializeUI()
+
+	p, ok := q.Model("services").(*page)
+	if !ok {
+		t.Fatalf("expected a services page, got %T", q.Model("services"))
+	}
+
+	if p.state != "querying" {
+		t.Fatalf("expected the page to start out querying, got '%s'", p.state)
+	}
+
+	if _, ok := p.preProcessor().(successMsg); !ok {
+		t.Fatalf("expected the services to be enabled")
+	}
+
+	if !strings.Contains(p.View(), "1 of 1 checked") {
+		t.Fatalf("expected the page to show progress, got: %s", p.View())
+	}
+}
+
+func TestNewCostPage(t *testing.T) {
+	tests := map[string]struct {
+		path string
+		want bool
+	}{
+		"priced":    {path: "attributes", want: true},
+		"unpriced":  {path: "variables", want: false},
+		"no folder": {path: "", want: false},
+		"not there": {path: "notthere", want: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getTestQueue(appTitle, "test")
+			q.stack.Config = config.Config{Name: "test", PathTerraform: tc.path}
+			q.stack.Config.Setwd(filepath.Join(testFilesDir, "terraform"))
+			q.InitializeUI()
+
+			p, ok := q.Model("costs").(*page)
+			if ok != tc.want {
+				t.Fatalf("expected a cost page: %t, got: %t", tc.want, ok)
+			}
+
+			if !tc.want {
+				return
+			}
+
+			if q.index[len(q.index)-2] != "costs" {
+				t.Fatalf("expected the cost page right before the end page, got: %v", q.index)
+			}
+
+			if !strings.Contains(p.View(), "google_compute_instance.web") {
+				t.Fatalf("expected the page to list the instance, got: %s", p.View())
+			}
+		})
+	}
+}
+
+func TestNewPermissionsPage(t *testing.T) {
+	tests := map[string]struct {
+		path string
+		want bool
+	}{
+		"resources": {path: "attributes", want: true},
+		"variables": {path: "variables", want: false},
+		"no folder": {path: "", want: false},
+		"not there": {path: "notthere", want: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getTestQueue(appTitle, "test")
+			q.stack.Config = config.Config{Name: "test", PathTerraform: tc.path}
+			q.stack.Config.Setwd(filepath.Join(testFilesDir, "terraform"))
+			q.InitializeUI()
+
+			_, ok := q.Model("permissions").(*page)
+			if ok != tc.want {
+				t.Fatalf("expected a permissions page: %t, got: %t", tc.want, ok)
+			}
+
+			if !tc.want {
+				return
+			}
+
+			permissions, services := -1, -1
+			for i, v := range q.index {
+				switch v {
+				case "permissions":
+					permissions = i
+				case "services":
+					services = i
+				}
+			}
+
+			if services != -1 && permissions > services {
+				t.Fatalf("expected the permissions page before the services page, got: %v", q.index)
+			}
+		})
+	}
+}
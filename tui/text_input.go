@@ -15,9 +15,11 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/GoogleCloudPlatform/deploystack/config"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -80,9 +82,10 @@ func (p textInput) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if val == "" {
 				val = p.ti.Placeholder
 			}
+			p.source = answerSource(p.ti.Value() != "")
 			// TODO: see if you can figure out a test for these empty bits
 			if val == "" {
-				p.err = fmt.Errorf("You must enter a value")
+				p.err = errors.New(p.queue.text(msgInputRequired))
 				return p, nil
 			}
 			p.value = val
@@ -98,7 +101,7 @@ func (p textInput) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return p, nil
 			}
 			if !p.omitFromSettings {
-				p.queue.stack.AddSetting(p.key, p.value)
+				p.queue.stack.AddSettingSource(p.key, p.value, p.source)
 			}
 			return p.queue.next()
 		}
@@ -119,14 +122,18 @@ func (p textInput) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Filter project creation screens screeens
 		newKey := strings.ReplaceAll(p.key, projNewSuffix, "")
 
-		newValue := p.value
+		newValue, source := p.value, p.source
 		if msg.msg == "prependProject" {
 			currentProject := p.queue.Get("currentProject").(string)
 			newValue = fmt.Sprintf("%s-%s", currentProject, newValue)
+			source = config.SourceComputed
 		}
 
-		if !p.omitFromSettings {
-			p.queue.stack.AddSetting(newKey, newValue)
+		if !msg.unset && !p.omitFromSettings {
+			p.queue.stack.AddSettingSource(newKey, newValue, source)
+		}
+		if msg.unset {
+			p.queue.claimSetting(newKey, source)
 		}
 		return p.queue.next()
 
@@ -146,7 +153,7 @@ func (p textInput) View() string {
 	doc.WriteString(p.queue.header.render())
 
 	if p.showProgress {
-		doc.WriteString(drawProgress(p.queue.calcPercent()))
+		doc.WriteString(drawProgress(p.queue.texts, p.queue.calcPercent()))
 		doc.WriteString("\n\n")
 	}
 
@@ -173,7 +180,7 @@ func (p textInput) View() string {
 	if p.err != nil {
 		height := len(p.err.Error()) / width
 		doc.WriteString("\n")
-		doc.WriteString(alertStyle.Width(width).Height(height).Render(fmt.Sprintf("Error: %s", p.err)))
+		doc.WriteString(alertStyle.Width(width).Height(height).Render(p.queue.textf(msgInputError, p.err)))
 		doc.WriteString("\n")
 	}
 
@@ -188,9 +195,9 @@ func (p textInput) View() string {
 	if p.state != "querying" {
 		if p.ti.Placeholder != "" {
 			styledPlaceHolder := textInputDefaultStyle.Render(p.ti.Placeholder)
-			doc.WriteString(textInputPrompt.Render(fmt.Sprintf("Type a value or hit enter for '%s'", styledPlaceHolder)))
+			doc.WriteString(textInputPrompt.Render(p.queue.textf(msgInputPromptDefault, styledPlaceHolder)))
 		} else {
-			doc.WriteString(textInputPrompt.Render("Type a value and hit enter to continue"))
+			doc.WriteString(textInputPrompt.Render(p.queue.text(msgInputPrompt)))
 		}
 	}
 
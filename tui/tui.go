@@ -20,10 +20,12 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"cloud.google.com/go/domains/apiv1beta1/domainspb"
 	"github.com/GoogleCloudPlatform/deploystack/config"
 	"github.com/GoogleCloudPlatform/deploystack/gcloud"
+	"github.com/GoogleCloudPlatform/deploystack/terraform"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"google.golang.org/api/cloudbilling/v1"
@@ -32,12 +34,27 @@ import (
 )
 
 const (
-	explainText           = "DeployStack will walk you through setting some options for the stack this solutions installs. Most questions have a default that you can choose by hitting the Enter key."
-	appTitle              = "DeployStack"
-	contactfile           = "contact.yaml.tmp"
-	validationPhoneNumber = "phonenumber"
-	validationYesOrNo     = "yesorno"
-	validationInteger     = "integer"
+	explainText            = "DeployStack will walk you through setting some options for the stack this solutions installs. Most questions have a default that you can choose by hitting the Enter key."
+	appTitle               = "DeployStack"
+	contactfile            = "contact.yaml.tmp"
+	secretsfile            = "terraform.secrets.env"
+	summaryfile            = "deploystack.settings.json"
+	redacted               = "********"
+	profileBase            = "profileBase"
+	prefilled              = "prefilled"
+	prefillFile            = "prefillFile"
+	prefillNotes           = "prefillNotes"
+	prefillMode            = "prefillMode"
+	prefillResume          = "resume"
+	prefillReview          = "review"
+	terraformBlocks        = "terraformBlocks"
+	renderedDefaults       = "renderedDefaults"
+	headlessStrict         = "headlessStrict"
+	headlessCreateProjects = "headlessCreateProjects"
+	headlessWarnings       = "headlessWarnings"
+	validationPhoneNumber  = "phonenumber"
+	validationYesOrNo      = "yesorno"
+	validationInteger      = "integer"
 )
 
 var (
@@ -53,10 +70,21 @@ type errMsg struct {
 	quit    bool
 	usermsg string
 	target  string
+	// warning marks problems that can be carried on past, which headless
+	// runs report without stopping unless they are strict
+	warning bool
 }
 
 func (e errMsg) Error() string { return e.err.Error() }
 
+// reason prefers the message meant for users over the underlying error
+func (e errMsg) reason() string {
+	if e.usermsg != "" {
+		return e.usermsg
+	}
+	return e.Error()
+}
+
 type successMsg struct {
 	msg   string
 	unset bool
@@ -72,6 +100,7 @@ type UIClient interface {
 	ProjectCreate(project, parent, parentType string) error
 	ProjectNumberGet(id string) (string, error)
 	ProjectIDSet(id string) error
+	ProjectMissingIAMPermissions(project string, permissions []string) ([]string, error)
 	// Compute Engine
 	RegionList(project, product string) ([]string, error)
 	ZoneList(project, region string) ([]string, error)
@@ -92,6 +121,10 @@ type UIClient interface {
 	// ServiceUsage
 	ServiceEnable(project string, service gcloud.Service) error
 	ServiceIsEnabled(project string, service gcloud.Service) (bool, error)
+	// SecretManager
+	SecretCreate(project, name, payload string) error
+	SecretExists(project, name string) (bool, error)
+	SecretVersionAdd(project, name, payload string) error
 }
 
 // Run takes a deploystack configuration and walks someone through all of the
@@ -115,6 +148,7 @@ func Run(s *config.Stack, useMock bool) {
 		q = NewQueue(s, GetMock(1))
 	}
 
+	q.loadPrefill()
 	q.InitializeUI()
 
 	p := tea.NewProgram(q.Start(), tea.WithAltScreen())
@@ -126,25 +160,98 @@ func Run(s *config.Stack, useMock bool) {
 		Fatal(nil)
 	}
 
-	s.TerraformFile("terraform.tfvars")
+	if err := q.deliverSettings(); err != nil {
+		Fatal(err)
+	}
 
 	fmt.Print("\n\n")
 	fmt.Print(titleStyle.Render("Deploystack"))
 	fmt.Print("\n")
 	fmt.Print(subTitleStyle.Render(s.Config.Title))
 	fmt.Print("\n")
-	fmt.Print(strong.Render("Installation will proceed with these settings"))
+	fmt.Print(strong.Render(q.text(msgSettingsProceed)))
 	fmt.Print(q.getSettings())
 }
 
+// Summary shows what an installed stack set up: the outputs of its
+// Terraform that aren't sensitive, followed by its success message. The
+// success message can use the settings the stack was installed with and its
+// outputs. The outputs are still shown when the message can't be rendered,
+// and the error is returned.
+func Summary(s *config.Stack, outputs terraform.OutputValues) error {
+	if file := s.FindTFvars(); file != "" {
+		if prior, err := config.ReadTFvars(file); err == nil {
+			for _, v := range prior {
+				if s.Settings.Find(v.Name) == nil {
+					s.AddSettingComplete(v)
+				}
+			}
+		}
+	}
+
+	success, err := s.SuccessMessage(outputs.Map())
+
+	q := NewQueue(s, GetMock(0))
+	fmt.Print(renderSummary(&q, outputs, outputDescriptions(&q), success))
+
+	return err
+}
+
+func renderSummary(q *Queue, outputs terraform.OutputValues, descriptions map[string]string, success string) string {
+	s := q.stack
+	doc := strings.Builder{}
+
+	doc.WriteString("\n\n")
+	doc.WriteString(titleStyle.Render("Deploystack"))
+	doc.WriteString("\n")
+	doc.WriteString(subTitleStyle.Render(s.Config.Title))
+	doc.WriteString("\n")
+	doc.WriteString(strong.Render(q.text(msgInstallComplete)))
+	doc.WriteString("\n\n")
+
+	if list := newOutputList(outputs, descriptions).render(); list != "" {
+		doc.WriteString(normal.Render(q.text(msgOutputsTitle)))
+		doc.WriteString("\n\n")
+		doc.WriteString(list)
+	}
+
+	if success != "" {
+		doc.WriteString(success)
+		doc.WriteString("\n")
+	}
+
+	return doc.String()
+}
+
+// outputDescriptions returns the descriptions of the stack's outputs by name
+func outputDescriptions(q *Queue) map[string]string {
+	result := map[string]string{}
+
+	blocks, err := q.terraformBlocks()
+	if err != nil {
+		return result
+	}
+
+	for _, v := range blocks {
+		if !v.IsOutput() || !v.InRoot() {
+			continue
+		}
+		if o, err := v.Output(); err == nil && o.Description != "" {
+			result[o.Name] = o.Description
+		}
+	}
+
+	return result
+}
+
 // PreCheck handles presenting a choice to a user amongst multiple stacks
 func PreCheck(reports []config.Report) string {
 
 	q := NewQueue(nil, GetMock(0))
 	q.Save("reports", reports)
 
-	appHeader := newHeader(appTitle, "Multiple Stacks Detected")
-	firstPage := newPicker("Please pick a stack to use", "Finding stacks", "stack", "", handleReports(&q))
+	appHeader := newHeader(appTitle, q.text(msgStacksDetected))
+	firstPage := newPicker(q.text(msgStackPick), q.text(msgStacksFinding), "stack", "", handleReports(&q))
 	firstPage.showProgress = false
 	firstPage.omitFromSettings = true
 	firstPage.addPostProcessor(handleStackSelection)
@@ -161,9 +268,9 @@ func PreCheck(reports []config.Report) string {
 	fmt.Print("\n\n")
 	fmt.Print(titleStyle.Render("Deploystack"))
 	fmt.Print("\n")
-	fmt.Print(subTitleStyle.Render("Stack has been chosen"))
+	fmt.Print(subTitleStyle.Render(q.text(msgStackChosen)))
 	fmt.Print("\n")
-	fmt.Print(strong.Render("Installation will proceed with this stack:"))
+	fmt.Print(strong.Render(q.text(msgStackProceed)))
 	fmt.Print("\n")
 	fmt.Print(response)
 	fmt.Print("\n")
@@ -176,19 +283,14 @@ func PreCheck(reports []config.Report) string {
 // with an eye towards not processing in the shell script of things go wrong.
 func Fatal(err error) {
 	if err != nil {
-		content := `There was an issue collecting the information it takes to run this application.
-		You can try again by typing 'deploystack install' at the command prompt 
-		If the issue persists, please report at: 
-		https://github.com/GoogleCloudPlatform/deploystack/issues
-		`
-
 		errmsg := errMsg{
 			err:     err,
-			usermsg: content,
+			usermsg: texts{}.text(msgFatal),
 			quit:    true,
 		}
 
-		msg := errorAlert{errmsg}
+		// Fatal has no stack to take a locale from, so it is in English
+		msg := errorAlert{err: errmsg}
 		fmt.Print("\n\n")
 		fmt.Println(titleStyle.Render("DeployStack"))
 		fmt.Println(msg.Render())
@@ -19,7 +19,12 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/deploystack/config"
+	"github.com/GoogleCloudPlatform/deploystack/terraform"
 )
 
 var testFilesDir = filepath.Join(os.Getenv("DEPLOYSTACK_PATH"), "testdata")
@@ -43,3 +48,72 @@ func writeDebugFile(content string, target string) {
 		log.Printf("err: %s", err)
 	}
 }
+
+func TestRenderSummary(t *testing.T) {
+	s := config.NewStack()
+	s.Config.Title = "Test Stack"
+
+	outputs := terraform.OutputValues{{Name: "url", Value: "https://app.example.com"}}
+
+	tests := map[string]struct {
+		outputs terraform.OutputValues
+		success string
+		want    []string
+		missing []string
+	}{
+		"outputs": {
+			outputs: outputs,
+			success: "Enjoy!",
+			want:    []string{text(msgInstallComplete), text(msgOutputsTitle), "https://app.example.com", "Enjoy!"},
+		},
+		"no outputs": {
+			outputs: terraform.OutputValues{{Name: "password", Value: "hunter2", Sensitive: true}},
+			want:    []string{text(msgInstallComplete)},
+			missing: []string{text(msgOutputsTitle), "hunter2"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := NewQueue(&s, GetMock(0))
+			got := renderSummary(&q, tc.outputs, nil, tc.success)
+
+			for _, v := range tc.want {
+				if !strings.Contains(got, v) {
+					t.Fatalf("expected %q in summary, got: %s", v, got)
+				}
+			}
+
+			for _, v := range tc.missing {
+				if strings.Contains(got, v) {
+					t.Fatalf("expected no %q in summary, got: %s", v, got)
+				}
+			}
+		})
+	}
+}
+
+func TestOutputDescriptions(t *testing.T) {
+	tests := map[string]struct {
+		path string
+		want map[string]string
+	}{
+		"outputs": {path: "outputs", want: map[string]string{"url": "Where the app is served"}},
+		"none":    {path: "", want: map[string]string{}},
+		"missing": {path: "notthere", want: map[string]string{}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := getTestQueue(appTitle, "test")
+			q.stack.Config.PathTerraform = tc.path
+			q.stack.Config.Setwd(filepath.Join(testFilesDir, "terraform"))
+
+			got := outputDescriptions(&q)
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}